@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"net"
+	nethttp "net/http"
 
 	docs "github.com/Xillon/golang-todo-api/docs"
 	"github.com/Xillon/golang-todo-api/http"
@@ -32,36 +34,52 @@ func init() {
 func startApiServer() {
 	app := fx.New(
 		FxModules,
-		fx.Invoke(func(handler *http.TodoHandler) {
+		fx.Invoke(func(lc fx.Lifecycle, handler *http.TodoHandler, shares *http.ShareHandler, auth *http.AuthHandler, authenticator *http.Authenticator, apiKeys *http.APIKeyHandler, webhooks *http.WebhookHandler, stream *http.EventStream, collab *http.CollabHandler, graphql *http.GraphQLHandler, idempotency *http.Idempotency) {
 			r := gin.Default()
 
 			docs.SwaggerInfo.Title = "Go Todo API"
-			docs.SwaggerInfo.Description = "Batch create, update, and list todos. Protected via X-API-Key header when configured."
+			docs.SwaggerInfo.Description = "Batch create, update, and list todos. Users log in for a bearer token, or bring a JWT from the SSO gateway, scoped to their own todos and those shared with them; API keys sent as X-API-Key see every todo, as far as their scopes allow."
 			docs.SwaggerInfo.Version = "1.0"
 			docs.SwaggerInfo.BasePath = "/"
 
 			r.GET("/", func(c *gin.Context) { c.Status(200) })
 			r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-			apiKey := os.Getenv("API_KEY")
-			if apiKey == "" {
-				log.Println("warning: API_KEY not set; requests will not be authenticated")
-			}
+			http.RegisterRoutes(r, http.Handlers{
+				Todos:         handler,
+				Shares:        shares,
+				Auth:          auth,
+				Authenticator: authenticator,
+				APIKeys:       apiKeys,
+				Webhooks:      webhooks,
+				Events:        stream,
+				Collab:        collab,
+				GraphQL:       graphql,
+				Idempotency:   idempotency,
+			})
 
-			secured := r.Group("/")
-			if apiKey != "" {
-				secured.Use(http.APIKeyMiddleware(apiKey))
-			}
-
-			secured.POST("/todos", handler.AddTodos)
-			secured.PATCH("/todos", handler.UpdateTodos)
-			secured.GET("/todos", handler.GetTodos)
-			secured.DELETE("/todos/:id", handler.DeleteTodoById)
-
-			fmt.Println("API server is running on http://localhost:8080/swagger/index.html")
-			if err := r.Run(":8080"); err != nil {
-				fmt.Printf("Failed to run server: %v\n", err)
-			}
+			// The server is started from a lifecycle hook rather than blocking
+			// here, so that fx can also start and stop the background workers.
+			srv := &nethttp.Server{Addr: ":8080", Handler: r}
+			srv.RegisterOnShutdown(stream.Close)
+			lc.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					listener, err := net.Listen("tcp", srv.Addr)
+					if err != nil {
+						return fmt.Errorf("failed to listen on %s: %w", srv.Addr, err)
+					}
+					fmt.Println("API server is running on http://localhost:8080/swagger/index.html")
+					go func() {
+						if err := srv.Serve(listener); err != nil && !errors.Is(err, nethttp.ErrServerClosed) {
+							fmt.Printf("Failed to run server: %v\n", err)
+						}
+					}()
+					return nil
+				},
+				OnStop: func(ctx context.Context) error {
+					return srv.Shutdown(ctx)
+				},
+			})
 		}),
 	)
 
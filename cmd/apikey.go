@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/http"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/spf13/cobra"
+)
+
+var apiKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage the API keys stored in the database",
+	Long: `Create, list, revoke and rotate API keys. Keys are shown once, when
+they are created or rotated; only their hash is stored.`,
+}
+
+var apiKeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an API key and print it",
+	Example: `  golang-todo-api apikey create --name reporting --scope todos:read --expires-in 2160h
+  golang-todo-api apikey create --name ops --scope admin`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("name")
+		scopes, _ := cmd.Flags().GetStringSlice("scope")
+		expiresIn, _ := cmd.Flags().GetDuration("expires-in")
+		var expiresAt *time.Time
+		if expiresIn != 0 {
+			at := time.Now().Add(expiresIn)
+			expiresAt = &at
+		}
+
+		key, secret, err := http.NewAPIKey(name, scopes, expiresAt)
+		if err != nil {
+			return err
+		}
+		keys, err := openAPIKeyStore()
+		if err != nil {
+			return err
+		}
+		if err := keys.CreateAPIKey(cmd.Context(), key); err != nil {
+			return err
+		}
+		printNewAPIKey(key, secret)
+		return nil
+	},
+}
+
+var apiKeyListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List API keys, revoked and expired ones included",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, err := openAPIKeyStore()
+		if err != nil {
+			return err
+		}
+		list, err := keys.ListAPIKeys(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tSTATUS\tEXPIRES\tLAST USED")
+		now := time.Now()
+		for _, key := range list {
+			status := "active"
+			if key.RevokedAt != nil {
+				status = "revoked"
+			} else if !key.Active(now) {
+				status = "expired"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix, strings.Join(key.Scopes, ","), status, formatTime(key.ExpiresAt), formatTime(key.LastUsedAt))
+		}
+		return w.Flush()
+	},
+}
+
+var apiKeyRevokeCmd = &cobra.Command{
+	Use:          "revoke <id>",
+	Short:        "Revoke an API key",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseAPIKeyID(args[0])
+		if err != nil {
+			return err
+		}
+		keys, err := openAPIKeyStore()
+		if err != nil {
+			return err
+		}
+		if err := keys.RevokeAPIKey(cmd.Context(), id, time.Now()); err != nil {
+			return err
+		}
+		fmt.Printf("API key %d revoked.\n", id)
+		return nil
+	},
+}
+
+var apiKeyRotateCmd = &cobra.Command{
+	Use:          "rotate <id>",
+	Short:        "Replace an API key by a new one with the same name, scopes and expiry",
+	Example:      `  golang-todo-api apikey rotate 3 --grace 24h`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseAPIKeyID(args[0])
+		if err != nil {
+			return err
+		}
+		grace, _ := cmd.Flags().GetDuration("grace")
+		keys, err := openAPIKeyStore()
+		if err != nil {
+			return err
+		}
+
+		key, secret, err := http.RotateAPIKey(cmd.Context(), keys, id, grace)
+		if err != nil {
+			return err
+		}
+		printNewAPIKey(key, secret)
+		if grace > 0 {
+			fmt.Printf("API key %d keeps working for %s.\n", id, grace)
+		} else {
+			fmt.Printf("API key %d no longer works.\n", id)
+		}
+		return nil
+	},
+}
+
+func init() {
+	apiKeyCreateCmd.Flags().String("name", "", "what the key is for")
+	apiKeyCreateCmd.Flags().StringSlice("scope", []string{models.ScopeTodosRead}, "todos:read, todos:write or admin; repeat or separate with commas")
+	apiKeyCreateCmd.Flags().Duration("expires-in", 0, "lifetime of the key, e.g. 720h; 0 never expires")
+	apiKeyCreateCmd.MarkFlagRequired("name")
+	apiKeyRotateCmd.Flags().Duration("grace", 0, "how long the old key keeps working")
+
+	apiKeyCmd.AddCommand(apiKeyCreateCmd, apiKeyListCmd, apiKeyRevokeCmd, apiKeyRotateCmd)
+	rootCmd.AddCommand(apiKeyCmd)
+}
+
+// openAPIKeyStore connects to the database configured for the API server.
+func openAPIKeyStore() (repository.APIKeyStore, error) {
+	db, err := repository.ProvideDatabase()
+	if err != nil {
+		return nil, err
+	}
+	return repository.ProvideAPIKeyStore(db), nil
+}
+
+func parseAPIKeyID(raw string) (uint, error) {
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("id must be a positive integer")
+	}
+	return uint(id), nil
+}
+
+func printNewAPIKey(key *models.APIKey, secret string) {
+	fmt.Printf("Created API key %d (%s) with scopes %s, expiring %s.\n", key.ID, key.Name, strings.Join(key.Scopes, ","), formatTime(key.ExpiresAt))
+	fmt.Println("Store it now, it is not shown again:")
+	fmt.Println(secret)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Local().Format(time.RFC3339)
+}
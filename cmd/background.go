@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+)
+
+// runInBackground ties a long running loop to the fx lifecycle. The loop starts
+// with the app, and on shutdown its context is cancelled and the loop awaited.
+func runInBackground(lc fx.Lifecycle, run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
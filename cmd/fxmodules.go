@@ -1,14 +1,96 @@
 package cmd
 
 import (
+	"context"
+
+	"github.com/Xillon/golang-todo-api/collab"
+	"github.com/Xillon/golang-todo-api/grpc"
 	"github.com/Xillon/golang-todo-api/http"
+	"github.com/Xillon/golang-todo-api/notify"
 	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/Xillon/golang-todo-api/worker"
 	"go.uber.org/fx"
 )
 
 var FxModules = fx.Options(
 	fx.Provide(
 		repository.ProvideDatabase,
+		repository.ProvideEventLog,
+		repository.ProvideEventBus,
+		repository.ProvideEventStream,
+		repository.ProvideTodoRepository,
+		repository.ProvideIdempotencyStore,
+		repository.ProvideUserStore,
+		repository.ProvideAPIKeyStore,
+		http.ProvideCursorSigner,
+		http.ProvideIdempotency,
 		http.ProvideTodoHandler,
+		http.ProvideShareHandler,
+		http.ProvideAuthHandler,
+		http.ProvideJWTVerifier,
+		http.ProvideAuthenticator,
+		http.ProvideAPIKeyHandler,
+		http.ProvideEventStream,
+		http.ProvideGraphQLHandler,
+		worker.ProvideTrashPurger,
+		worker.ProvideIdempotencyPurger,
+		worker.ProvideEventLogPurger,
+		worker.ProvideAuthTokenPurger,
+	),
+	fx.Invoke(
+		func(lc fx.Lifecycle, purger *worker.TrashPurger) { runInBackground(lc, purger.Run) },
+		func(lc fx.Lifecycle, purger *worker.IdempotencyPurger) { runInBackground(lc, purger.Run) },
+		func(lc fx.Lifecycle, purger *worker.EventLogPurger) { runInBackground(lc, purger.Run) },
+		func(lc fx.Lifecycle, purger *worker.AuthTokenPurger) { runInBackground(lc, purger.Run) },
+	),
+	ReminderModule,
+	WebhookModule,
+	CollabModule,
+	GRPCModule,
+)
+
+// ReminderModule sends reminders about due todos in the background.
+var ReminderModule = fx.Module("reminders",
+	fx.Provide(
+		repository.ProvideReminderStore,
+		notify.ProvideNotifier,
+		worker.ProvideReminderScheduler,
+	),
+	fx.Invoke(func(lc fx.Lifecycle, scheduler *worker.ReminderScheduler) { runInBackground(lc, scheduler.Run) }),
+)
+
+// WebhookModule posts todo events to subscribed webhooks in the background.
+var WebhookModule = fx.Module("webhooks",
+	fx.Provide(
+		repository.ProvideWebhookStore,
+		worker.ProvideWebhookDispatcher,
+		http.ProvideWebhookHandler,
+	),
+	fx.Invoke(func(lc fx.Lifecycle, dispatcher *worker.WebhookDispatcher) { runInBackground(lc, dispatcher.Run) }),
+)
+
+// CollabModule serves the collaboration WebSocket. Hijacked connections are
+// not closed by the server's shutdown, so the hub disconnects them on stop.
+var CollabModule = fx.Module("collab",
+	fx.Provide(
+		collab.ProvideBroker,
+		collab.ProvideHub,
+		http.ProvideCollabHandler,
+	),
+	fx.Invoke(func(lc fx.Lifecycle, hub *collab.Hub) {
+		lc.Append(fx.Hook{OnStop: func(context.Context) error {
+			hub.Close()
+			return nil
+		}})
+	}),
+)
+
+// GRPCModule serves the TodoService next to the REST API, over the same
+// repository.
+var GRPCModule = fx.Module("grpc",
+	fx.Provide(
+		grpc.ProvideTodoServer,
+		grpc.ProvideServer,
 	),
+	fx.Invoke(serveGRPC),
 )
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+
+	"github.com/Xillon/golang-todo-api/events"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+)
+
+// serveGRPC listens on GRPC_ADDR, ":9090" by default, for as long as the app
+// runs. Watch calls only end once the event stream closes, so the stream is
+// closed before the server waits for calls in flight.
+func serveGRPC(lc fx.Lifecycle, server *grpc.Server, stream *events.Stream) {
+	addr := os.Getenv("GRPC_ADDR")
+	if addr == "" {
+		addr = ":9090"
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ln, err := new(net.ListenConfig).Listen(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+			log.Printf("gRPC server listening on %s", ln.Addr())
+			go server.Serve(ln)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			stream.Close()
+			stopped := make(chan struct{})
+			go func() {
+				server.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				server.Stop()
+				return ctx.Err()
+			}
+		},
+	})
+}
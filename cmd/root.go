@@ -11,8 +11,8 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "golang-todo-api",
 	Short: "Manage and operate the Go Todo API service",
-	Long: `Use the "api" command to boot the server and
-"migrate" to apply schema updates.`,
+	Long: `Use the "api" command to boot the server,
+"migrate" to apply schema updates and "apikey" to manage API keys.`,
 }
 
 func Execute() {
@@ -0,0 +1,62 @@
+// Package collab connects the clients of the collaboration WebSocket: it
+// tracks who is viewing which todo and fans todo changes out to everyone
+// watching them, across API instances.
+package collab
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Broker carries messages between the API instances that share it. Every
+// payload published on a topic is handed to every subscriber of that topic,
+// including those of the publishing instance.
+type Broker interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe calls handler for each payload published on topic until the
+	// returned cancel function is called. Handlers must not block.
+	Subscribe(topic string, handler func(payload []byte)) (cancel func(), err error)
+}
+
+// MemoryBroker is a Broker for a single instance, delivering payloads
+// synchronously to the subscribers in the same process.
+type MemoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[string][]*func(payload []byte)
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subscribers: map[string][]*func(payload []byte){}}
+}
+
+// ProvideBroker returns the in-process broker. Deployments running several
+// instances provide a Broker backed by a shared message bus instead.
+func ProvideBroker() Broker {
+	return NewMemoryBroker()
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, payload []byte) error {
+	b.mu.RLock()
+	subscribers := b.subscribers[topic]
+	b.mu.RUnlock()
+
+	for _, handler := range subscribers {
+		(*handler)(slices.Clone(payload))
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(topic string, handler func(payload []byte)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscription := &handler
+	b.subscribers[topic] = append(b.subscribers[topic], subscription)
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		// Publish may still be iterating over the old slice, so build a new one.
+		b.subscribers[topic] = slices.DeleteFunc(slices.Clone(b.subscribers[topic]), func(h *func(payload []byte)) bool { return h == subscription })
+	}, nil
+}
@@ -0,0 +1,354 @@
+package collab
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/Xillon/golang-todo-api/events"
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+const (
+	eventsTopic   = "todo-events"
+	presenceTopic = "todo-presence"
+	// clientBuffer is how many messages a client may fall behind by before
+	// it is disconnected.
+	clientBuffer = 64
+)
+
+// Message types sent to clients.
+const (
+	MessageSubscribed   = "subscribed"
+	MessageUnsubscribed = "unsubscribed"
+	MessageUpdated      = "updated"
+	MessageEvent        = "event"
+	MessagePresence     = "presence"
+	MessageError        = "error"
+)
+
+// Message is what the server sends to collaboration clients.
+type Message struct {
+	Type string `json:"type"`
+	// RequestID echoes the id of the client message being answered.
+	RequestID string            `json:"request_id,omitempty"`
+	TodoID    uint              `json:"todo_id,omitempty"`
+	Todo      *models.Todo      `json:"todo,omitempty"`
+	Todos     []models.Todo     `json:"todos,omitempty"`
+	Event     *models.TodoEvent `json:"event,omitempty"`
+	// Viewers lists who is viewing TodoID, on any instance. It is left out
+	// when nobody is.
+	Viewers []string `json:"viewers,omitempty"`
+	Status  int      `json:"status,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Client is one connection to the hub.
+type Client struct {
+	name     string
+	readable func(todo models.Todo) bool
+	outbox   chan []byte
+	watching map[uint]struct{}
+	closed   bool
+}
+
+// Name is how the client is shown to other viewers.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Outbox yields the messages to write to the client's connection. It is
+// closed when the client leaves or cannot keep up.
+func (c *Client) Outbox() <-chan []byte {
+	return c.outbox
+}
+
+// presenceUpdate is the broker message with the local viewers of a todo on
+// one instance.
+type presenceUpdate struct {
+	Instance string   `json:"instance"`
+	TodoID   uint     `json:"todo_id"`
+	Viewers  []string `json:"viewers"`
+}
+
+// Hub tracks which clients watch which todos and relays todo events and
+// presence changes to them. Both travel through the broker, so clients
+// connected to different instances see the same thing.
+type Hub struct {
+	instance string
+	broker   Broker
+	cancel   []func()
+
+	// presenceMu orders the presence updates this instance publishes.
+	presenceMu sync.Mutex
+
+	mu       sync.Mutex
+	clients  map[*Client]struct{}
+	watchers map[uint]map[*Client]struct{}
+	// presence holds the viewers of each todo per instance, as last heard
+	// from the broker.
+	presence map[uint]map[string][]string
+}
+
+func NewHub(broker Broker) (*Hub, error) {
+	instance := make([]byte, 8)
+	rand.Read(instance)
+	h := &Hub{
+		instance: hex.EncodeToString(instance),
+		broker:   broker,
+		clients:  map[*Client]struct{}{},
+		watchers: map[uint]map[*Client]struct{}{},
+		presence: map[uint]map[string][]string{},
+	}
+	for topic, handler := range map[string]func([]byte){eventsTopic: h.onEvent, presenceTopic: h.onPresence} {
+		cancel, err := broker.Subscribe(topic, handler)
+		if err != nil {
+			h.Close()
+			return nil, err
+		}
+		h.cancel = append(h.cancel, cancel)
+	}
+	return h, nil
+}
+
+// ProvideHub returns a hub that relays the events published on bus.
+func ProvideHub(broker Broker, bus *events.Bus) (*Hub, error) {
+	hub, err := NewHub(broker)
+	if err != nil {
+		return nil, err
+	}
+	bus.Subscribe(hub.PublishEvent)
+	return hub, nil
+}
+
+// PublishEvent hands a todo event to the broker, which brings it to the
+// watchers of the todo on every instance. It is an events.Handler.
+func (h *Hub) PublishEvent(ctx context.Context, event models.TodoEvent) {
+	payload, err := json.Marshal(event)
+	if err == nil {
+		err = h.broker.Publish(ctx, eventsTopic, payload)
+	}
+	if err != nil {
+		log.Printf("relaying %s event for todo %d failed: %v", event.Type, event.Todo.ID, err)
+	}
+}
+
+// Join registers a client shown to others as name. When readable is not nil,
+// it is asked before every event whether the client may still read the todo;
+// a client that may not stops watching it and is told so with an unsubscribed
+// message.
+func (h *Hub) Join(name string, readable func(todo models.Todo) bool) *Client {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client := &Client{name: name, readable: readable, outbox: make(chan []byte, clientBuffer), watching: map[uint]struct{}{}}
+	h.clients[client] = struct{}{}
+	return client
+}
+
+// Leave stops watching everything the client watched and closes its outbox.
+func (h *Hub) Leave(client *Client) {
+	h.mu.Lock()
+	ids := slices.Collect(maps.Keys(client.watching))
+	h.unwatch(client, ids)
+	delete(h.clients, client)
+	h.close(client)
+	h.mu.Unlock()
+
+	h.publishPresence(ids)
+}
+
+// Watch subscribes the client to the events of the given todos and adds it
+// to their viewers.
+func (h *Hub) Watch(client *Client, ids []uint) {
+	h.mu.Lock()
+	for _, id := range ids {
+		if h.watchers[id] == nil {
+			h.watchers[id] = map[*Client]struct{}{}
+		}
+		h.watchers[id][client] = struct{}{}
+		client.watching[id] = struct{}{}
+	}
+	h.mu.Unlock()
+
+	h.publishPresence(ids)
+}
+
+// Unwatch undoes Watch for the given todos.
+func (h *Hub) Unwatch(client *Client, ids []uint) {
+	h.mu.Lock()
+	h.unwatch(client, ids)
+	h.mu.Unlock()
+
+	h.publishPresence(ids)
+}
+
+// Viewers returns who is viewing the todo on any instance, sorted by name.
+func (h *Hub) Viewers(id uint) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.viewers(id)
+}
+
+// Send queues a message for one client.
+func (h *Hub) Send(client *Client, message Message) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("encoding %s message failed: %v", message.Type, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.deliver(client, payload)
+}
+
+// Close stops listening to the broker and disconnects every client.
+func (h *Hub) Close() {
+	for _, cancel := range h.cancel {
+		cancel()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		h.close(client)
+	}
+}
+
+func (h *Hub) onEvent(payload []byte) {
+	var event models.TodoEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		log.Printf("dropping malformed todo event: %v", err)
+		return
+	}
+	id := event.Todo.ID
+	message, _ := json.Marshal(Message{Type: MessageEvent, TodoID: id, Event: &event})
+
+	h.mu.Lock()
+	watchers := slices.Collect(maps.Keys(h.watchers[id]))
+	h.mu.Unlock()
+
+	// Access may have been taken away since the client subscribed. Checking
+	// can mean a lookup, so it is done without holding mu.
+	revoked := map[*Client]bool{}
+	for _, client := range watchers {
+		if client.readable != nil && !client.readable(event.Todo) {
+			revoked[client] = true
+		}
+	}
+
+	unsubscribed, _ := json.Marshal(Message{Type: MessageUnsubscribed, TodoID: id})
+	h.mu.Lock()
+	for _, client := range watchers {
+		if _, ok := h.watchers[id][client]; !ok {
+			continue
+		}
+		if revoked[client] {
+			h.unwatch(client, []uint{id})
+			h.deliver(client, unsubscribed)
+			continue
+		}
+		h.deliver(client, message)
+	}
+	h.mu.Unlock()
+
+	if len(revoked) > 0 {
+		h.publishPresence([]uint{id})
+	}
+}
+
+func (h *Hub) onPresence(payload []byte) {
+	var update presenceUpdate
+	if err := json.Unmarshal(payload, &update); err != nil {
+		log.Printf("dropping malformed presence update: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(update.Viewers) == 0 {
+		delete(h.presence[update.TodoID], update.Instance)
+	} else {
+		if h.presence[update.TodoID] == nil {
+			h.presence[update.TodoID] = map[string][]string{}
+		}
+		h.presence[update.TodoID][update.Instance] = update.Viewers
+	}
+	if len(h.presence[update.TodoID]) == 0 {
+		delete(h.presence, update.TodoID)
+	}
+
+	message, _ := json.Marshal(Message{Type: MessagePresence, TodoID: update.TodoID, Viewers: h.viewers(update.TodoID)})
+	for client := range h.watchers[update.TodoID] {
+		h.deliver(client, message)
+	}
+}
+
+// publishPresence tells every instance who views the given todos here.
+func (h *Hub) publishPresence(ids []uint) {
+	h.presenceMu.Lock()
+	defer h.presenceMu.Unlock()
+
+	for _, id := range ids {
+		h.mu.Lock()
+		var viewers []string
+		for client := range h.watchers[id] {
+			viewers = append(viewers, client.name)
+		}
+		h.mu.Unlock()
+		slices.Sort(viewers)
+
+		payload, _ := json.Marshal(presenceUpdate{Instance: h.instance, TodoID: id, Viewers: slices.Compact(viewers)})
+		if err := h.broker.Publish(context.Background(), presenceTopic, payload); err != nil {
+			log.Printf("publishing presence of todo %d failed: %v", id, err)
+		}
+	}
+}
+
+// unwatch must be called with mu held.
+func (h *Hub) unwatch(client *Client, ids []uint) {
+	for _, id := range ids {
+		delete(h.watchers[id], client)
+		if len(h.watchers[id]) == 0 {
+			delete(h.watchers, id)
+		}
+		delete(client.watching, id)
+	}
+}
+
+// viewers must be called with mu held.
+func (h *Hub) viewers(id uint) []string {
+	var viewers []string
+	for _, names := range h.presence[id] {
+		viewers = append(viewers, names...)
+	}
+	slices.Sort(viewers)
+	return slices.Compact(viewers)
+}
+
+// deliver must be called with mu held. A client whose outbox is full is
+// disconnected instead of holding everyone else back.
+func (h *Hub) deliver(client *Client, payload []byte) {
+	if client.closed {
+		return
+	}
+	select {
+	case client.outbox <- payload:
+	default:
+		h.close(client)
+	}
+}
+
+// close must be called with mu held.
+func (h *Hub) close(client *Client) {
+	if !client.closed {
+		client.closed = true
+		close(client.outbox)
+	}
+}
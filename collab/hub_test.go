@@ -0,0 +1,127 @@
+package collab_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/collab"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHub(t *testing.T, broker collab.Broker) *collab.Hub {
+	t.Helper()
+
+	hub, err := collab.NewHub(broker)
+	require.NoError(t, err)
+	t.Cleanup(hub.Close)
+	return hub
+}
+
+// drain returns the messages queued for client so far.
+func drain(t *testing.T, client *collab.Client) []collab.Message {
+	t.Helper()
+
+	var messages []collab.Message
+	for {
+		select {
+		case payload, ok := <-client.Outbox():
+			if !ok {
+				return messages
+			}
+			var message collab.Message
+			require.NoError(t, json.Unmarshal(payload, &message))
+			messages = append(messages, message)
+		default:
+			return messages
+		}
+	}
+}
+
+func TestHubSharesPresenceAndEventsThroughBroker(t *testing.T) {
+	broker := collab.NewMemoryBroker()
+	first, second := newHub(t, broker), newHub(t, broker)
+
+	alice, bob, carol := first.Join("alice", nil), second.Join("bob", nil), second.Join("carol", nil)
+	first.Watch(alice, []uint{1})
+	second.Watch(bob, []uint{1, 2})
+	second.Watch(carol, []uint{2})
+
+	assert.Equal(t, []string{"alice", "bob"}, first.Viewers(1))
+	assert.Equal(t, []string{"bob", "carol"}, first.Viewers(2))
+	for _, client := range []*collab.Client{alice, bob, carol} {
+		drain(t, client)
+	}
+
+	second.PublishEvent(context.Background(), models.TodoEvent{ID: 7, Type: models.EventTodoUpdated, Todo: models.Todo{ID: 1, Title: "Edited"}})
+	for _, client := range []*collab.Client{alice, bob} {
+		messages := drain(t, client)
+		require.Len(t, messages, 1, client.Name())
+		assert.Equal(t, collab.MessageEvent, messages[0].Type)
+		assert.Equal(t, uint(7), messages[0].Event.ID)
+	}
+	assert.Empty(t, drain(t, carol))
+
+	second.Leave(bob)
+	assert.Equal(t, []string{"alice"}, first.Viewers(1))
+	assert.Equal(t, []string{"carol"}, second.Viewers(2))
+	messages := drain(t, alice)
+	require.Len(t, messages, 1)
+	assert.Equal(t, collab.MessagePresence, messages[0].Type)
+	assert.Equal(t, []string{"alice"}, messages[0].Viewers)
+}
+
+func TestHubDisconnectsClientsThatFallBehind(t *testing.T) {
+	hub := newHub(t, collab.NewMemoryBroker())
+	slow := hub.Join("slow", nil)
+	hub.Watch(slow, []uint{1})
+
+	for i := range 200 {
+		hub.PublishEvent(context.Background(), models.TodoEvent{ID: uint(i + 1), Type: models.EventTodoUpdated, Todo: models.Todo{ID: 1}})
+	}
+
+	received := 0
+	for range slow.Outbox() {
+		received++
+	}
+	assert.Less(t, received, 200, "the outbox should have been closed once full")
+
+	// Leaving afterwards is harmless.
+	hub.Leave(slow)
+	assert.Empty(t, hub.Viewers(1))
+}
+
+func TestHubStopsSendingEventsOfTodosClientsMayNoLongerRead(t *testing.T) {
+	hub := newHub(t, collab.NewMemoryBroker())
+	shared := true
+	alice := hub.Join("alice", nil)
+	bob := hub.Join("bob", func(models.Todo) bool { return shared })
+	hub.Watch(alice, []uint{1})
+	hub.Watch(bob, []uint{1})
+	drain(t, alice)
+	drain(t, bob)
+
+	hub.PublishEvent(context.Background(), models.TodoEvent{ID: 1, Type: models.EventTodoUpdated, Todo: models.Todo{ID: 1}})
+	messages := drain(t, bob)
+	require.Len(t, messages, 1)
+	assert.Equal(t, collab.MessageEvent, messages[0].Type)
+
+	// Once the share is gone the next event unsubscribes bob instead.
+	shared = false
+	hub.PublishEvent(context.Background(), models.TodoEvent{ID: 2, Type: models.EventTodoUpdated, Todo: models.Todo{ID: 1}})
+	messages = drain(t, bob)
+	require.Len(t, messages, 1)
+	assert.Equal(t, collab.MessageUnsubscribed, messages[0].Type)
+	assert.Equal(t, uint(1), messages[0].TodoID)
+	assert.Equal(t, []string{"alice"}, hub.Viewers(1))
+
+	hub.PublishEvent(context.Background(), models.TodoEvent{ID: 3, Type: models.EventTodoUpdated, Todo: models.Todo{ID: 1}})
+	assert.Empty(t, drain(t, bob))
+	var types []string
+	for _, message := range drain(t, alice) {
+		types = append(types, message.Type)
+	}
+	assert.Equal(t, []string{collab.MessageEvent, collab.MessageEvent, collab.MessagePresence, collab.MessageEvent}, types)
+}
@@ -0,0 +1,62 @@
+// Package events fans todo events out to the parts of the application that
+// react to them, such as webhooks and live streams.
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+// Handler receives published events. It runs on the publishing goroutine, so
+// it should hand slow work off instead of doing it inline.
+type Handler func(ctx context.Context, event models.TodoEvent)
+
+// Recorder stores events and assigns their IDs.
+type Recorder interface {
+	Append(ctx context.Context, event *models.TodoEvent) error
+}
+
+// Bus delivers every published event to all subscribed handlers, in the order
+// they subscribed. Publishing is serialised, so handlers see events in the
+// order of their IDs.
+type Bus struct {
+	recorder   Recorder
+	publishing sync.Mutex
+	mu         sync.RWMutex
+	handlers   []Handler
+}
+
+// NewBus records every event with recorder before handing it to the
+// handlers. A nil recorder leaves events without an ID.
+func NewBus(recorder Recorder) *Bus {
+	return &Bus{recorder: recorder}
+}
+
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+func (b *Bus) Publish(ctx context.Context, event models.TodoEvent) {
+	b.publishing.Lock()
+	defer b.publishing.Unlock()
+
+	if b.recorder != nil {
+		// The write behind the event has committed, so record it even when
+		// the request that made it is going away.
+		if err := b.recorder.Append(context.WithoutCancel(ctx), &event); err != nil {
+			log.Printf("recording %s event for todo %d failed: %v", event.Type, event.Todo.ID, err)
+		}
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
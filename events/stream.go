@@ -0,0 +1,191 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+const (
+	// replayBatch is how many logged events are read at a time when a
+	// follower resumes.
+	replayBatch = 500
+	// followerBuffer is how many live events a follower may fall behind by
+	// before it is dropped. It can then resume from the log.
+	followerBuffer = 256
+)
+
+// Log is the part of the event log a Stream replays from.
+type Log interface {
+	// Since returns up to limit events with an ID above afterID, oldest first.
+	Since(ctx context.Context, afterID uint, limit int) ([]models.TodoEvent, error)
+}
+
+// Filter narrows events down to the todos a user may read, of a project or
+// carrying some tags, like the matching todo list filters. The zero Filter
+// matches every event.
+type Filter struct {
+	// Readable, when set, only lets through the events of todos it accepts.
+	Readable  func(todo models.Todo) bool
+	ProjectID *uint
+	Tags      []string
+	// AllTags requires all of Tags instead of any of them.
+	AllTags bool
+}
+
+func (f Filter) Matches(event models.TodoEvent) bool {
+	todo := event.Todo
+	if f.ProjectID != nil && (todo.ProjectID == nil || *todo.ProjectID != *f.ProjectID) {
+		return false
+	}
+	if len(f.Tags) > 0 && !f.matchesTags(todo) {
+		return false
+	}
+	// Checked last since it may have to look the todo up.
+	return f.Readable == nil || f.Readable(todo)
+}
+
+func (f Filter) matchesTags(todo models.Todo) bool {
+	names := models.TagNames(todo.Tags)
+	matched := 0
+	for _, tag := range f.Tags {
+		if slices.Contains(names, tag) {
+			matched++
+		}
+	}
+	if f.AllTags {
+		return matched == len(f.Tags)
+	}
+	return matched > 0
+}
+
+// Stream lets long-lived clients follow events: those they missed are
+// replayed from the log, then live ones are passed on as they are published.
+type Stream struct {
+	log       Log
+	mu        sync.Mutex
+	listeners map[chan models.TodoEvent]struct{}
+	closed    bool
+}
+
+func NewStream(log Log) *Stream {
+	return &Stream{log: log, listeners: map[chan models.TodoEvent]struct{}{}}
+}
+
+// Publish passes a live event on to the followers. It is a Handler.
+func (s *Stream) Publish(ctx context.Context, event models.TodoEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for listener := range s.listeners {
+		select {
+		case listener <- event:
+		default:
+			// A follower that cannot keep up is dropped rather than allowed
+			// to hold the publisher back.
+			delete(s.listeners, listener)
+			close(listener)
+		}
+	}
+}
+
+// Close ends every follower and refuses new ones. It is meant to run when
+// the server shuts down, since followers would otherwise keep it waiting.
+func (s *Stream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for listener := range s.listeners {
+		delete(s.listeners, listener)
+		close(listener)
+	}
+}
+
+// Follow yields the events matching filter: first those logged after lastID
+// when resume is set, then live ones. The channel is closed when ctx is done,
+// the log cannot be read, the follower falls too far behind or the stream is
+// closed. It reports false once the stream is closed.
+func (s *Stream) Follow(ctx context.Context, filter Filter, lastID uint, resume bool) (<-chan models.TodoEvent, bool) {
+	// Listen before replaying so that nothing published in between is lost;
+	// live events already replayed are skipped by id.
+	listener, ok := s.subscribe()
+	if !ok {
+		return nil, false
+	}
+
+	events := make(chan models.TodoEvent)
+	go func() {
+		defer close(events)
+		defer s.unsubscribe(listener)
+
+		send := func(event models.TodoEvent) bool {
+			if !filter.Matches(event) {
+				return true
+			}
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for resume {
+			replay, err := s.log.Since(ctx, lastID, replayBatch)
+			if err != nil {
+				return
+			}
+			for _, event := range replay {
+				lastID = event.ID
+				if !send(event) {
+					return
+				}
+			}
+			resume = len(replay) == replayBatch
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, open := <-listener:
+				if !open {
+					return
+				}
+				if event.ID != 0 && event.ID <= lastID {
+					continue
+				}
+				lastID = max(lastID, event.ID)
+				if !send(event) {
+					return
+				}
+			}
+		}
+	}()
+	return events, true
+}
+
+func (s *Stream) subscribe() (chan models.TodoEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, false
+	}
+	listener := make(chan models.TodoEvent, followerBuffer)
+	s.listeners[listener] = struct{}{}
+	return listener, true
+}
+
+func (s *Stream) unsubscribe(listener chan models.TodoEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.listeners[listener]; ok {
+		delete(s.listeners, listener)
+		close(listener)
+	}
+}
@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	todov1 "github.com/Xillon/golang-todo-api/proto/todo/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProto(todo models.Todo) *todov1.Todo {
+	msg := &todov1.Todo{
+		Id:          uint64(todo.ID),
+		Title:       todo.Title,
+		Description: todo.Description,
+		DueDate:     optionalTimestamp(todo.DueDate),
+		RemindAt:    optionalTimestamp(todo.RemindAt),
+		Complete:    todo.Complete,
+		ProjectId:   optionalUint64(todo.ProjectID),
+		ParentId:    optionalUint64(todo.ParentID),
+		Tags:        models.TagNames(todo.Tags),
+		Recurrence:  todo.Recurrence,
+		Timezone:    todo.Timezone,
+		SeriesId:    optionalUint64(todo.SeriesID),
+		Blocked:     todo.Blocked,
+		Version:     uint64(todo.Version),
+		CreateTime:  timestamppb.New(todo.CreatedAt),
+		UpdateTime:  timestamppb.New(todo.UpdatedAt),
+	}
+	for _, id := range todo.BlockedBy {
+		msg.BlockedBy = append(msg.BlockedBy, uint64(id))
+	}
+	return msg
+}
+
+// fromProto returns the user-editable fields of msg, along with its id and
+// version.
+func fromProto(msg *todov1.Todo) models.Todo {
+	todo := models.Todo{
+		ID:          uint(msg.GetId()),
+		Title:       msg.GetTitle(),
+		Description: msg.GetDescription(),
+		DueDate:     optionalTime(msg.GetDueDate()),
+		RemindAt:    optionalTime(msg.GetRemindAt()),
+		Complete:    msg.GetComplete(),
+		ProjectID:   optionalID(msg.ProjectId),
+		ParentID:    optionalID(msg.ParentId),
+		Recurrence:  msg.GetRecurrence(),
+		Timezone:    msg.GetTimezone(),
+		Version:     uint(msg.GetVersion()),
+	}
+	for _, name := range msg.GetTags() {
+		todo.Tags = append(todo.Tags, models.Tag{Name: name})
+	}
+	return todo
+}
+
+func eventToProto(event models.TodoEvent) *todov1.TodoEvent {
+	return &todov1.TodoEvent{
+		Id:        uint64(event.ID),
+		Type:      event.Type,
+		OccurTime: timestamppb.New(event.OccurredAt),
+		Todo:      toProto(event.Todo),
+	}
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func optionalUint64(id *uint) *uint64 {
+	if id == nil {
+		return nil
+	}
+	value := uint64(*id)
+	return &value
+}
+
+func optionalID(id *uint64) *uint {
+	if id == nil {
+		return nil
+	}
+	value := uint(*id)
+	return &value
+}
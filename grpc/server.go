@@ -0,0 +1,286 @@
+// Package grpc serves the TodoService of proto/todo/v1. It goes through the
+// same repository as the REST handlers, so both share storage, validation,
+// version checks, history and events.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Xillon/golang-todo-api/events"
+	"github.com/Xillon/golang-todo-api/http"
+	"github.com/Xillon/golang-todo-api/models"
+	todov1 "github.com/Xillon/golang-todo-api/proto/todo/v1"
+	"github.com/Xillon/golang-todo-api/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const defaultPageSize = 10
+
+// TodoServer implements todov1.TodoServiceServer.
+type TodoServer struct {
+	todov1.UnimplementedTodoServiceServer
+	Todos   repository.TodoRepository
+	Cursors *http.CursorSigner
+	Stream  *events.Stream
+}
+
+func ProvideTodoServer(todos repository.TodoRepository, cursors *http.CursorSigner, stream *events.Stream) *TodoServer {
+	return &TodoServer{Todos: todos, Cursors: cursors, Stream: stream}
+}
+
+// NewServer returns a gRPC server offering todos. Every call has to carry an
+// API key as x-api-key metadata, checked by auth like X-API-Key headers are.
+func NewServer(todos *TodoServer, auth *http.Authenticator) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(APIKeyUnaryInterceptor(auth)),
+		grpc.ChainStreamInterceptor(APIKeyStreamInterceptor(auth)),
+	)
+	todov1.RegisterTodoServiceServer(server, todos)
+	return server
+}
+
+// ProvideServer protects the server with the API keys of the REST API.
+func ProvideServer(todos *TodoServer, auth *http.Authenticator) *grpc.Server {
+	return NewServer(todos, auth)
+}
+
+func (s *TodoServer) Create(ctx context.Context, req *todov1.CreateRequest) (*todov1.Todo, error) {
+	if req.GetTodo() == nil {
+		return nil, status.Error(codes.InvalidArgument, "todo is required")
+	}
+	todo := fromProto(req.GetTodo())
+	todo.ID, todo.Version = 0, 0
+
+	err := s.Todos.Transaction(ctx, func(repo repository.TodoRepository) error {
+		if err := repo.Create(ctx, &todo); err != nil {
+			return err
+		}
+		saved, err := repo.FindByID(ctx, todo.ID)
+		if err != nil {
+			return err
+		}
+		todo = *saved
+		return nil
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(todo), nil
+}
+
+func (s *TodoServer) Get(ctx context.Context, req *todov1.GetRequest) (*todov1.Todo, error) {
+	todo, err := s.Todos.FindByID(ctx, uint(req.GetId()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(*todo), nil
+}
+
+func (s *TodoServer) List(ctx context.Context, req *todov1.ListRequest) (*todov1.ListResponse, error) {
+	limit := int(req.GetPageSize())
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	if limit < 0 || limit > repository.MaxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 1 and %d", repository.MaxPageSize)
+	}
+
+	query := listQuery(req)
+	var position *repository.TodoPosition
+	if token := req.GetPageToken(); token != "" {
+		decoded, err := s.Cursors.DecodeListPosition(token, query)
+		if errors.Is(err, http.ErrCursorFilters) {
+			return nil, status.Error(codes.InvalidArgument, "page_token was issued for different filters")
+		}
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+		position = &decoded
+	}
+
+	todos, more, total, err := repository.ListPage(ctx, s.Todos, query, position, false, limit)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &todov1.ListResponse{TotalSize: total}
+	for _, todo := range todos {
+		resp.Todos = append(resp.Todos, toProto(todo))
+	}
+	if more {
+		last := todos[len(todos)-1]
+		resp.NextPageToken = s.Cursors.EncodeListPosition(repository.TodoPosition{CreatedAt: last.CreatedAt, ID: last.ID}, query)
+	}
+	return resp, nil
+}
+
+// listQuery turns the filters of a ListRequest into the TodoQuery GET /todos
+// would build from the matching parameters.
+func listQuery(req *todov1.ListRequest) repository.TodoQuery {
+	query := repository.TodoQuery{
+		Complete:  req.Complete,
+		DueBefore: optionalTime(req.GetDueBefore()),
+		DueAfter:  optionalTime(req.GetDueAfter()),
+		Search:    req.GetSearch(),
+		AllTags:   req.GetAllTags(),
+		ProjectID: optionalID(req.ProjectId),
+		ParentID:  optionalID(req.ParentId),
+	}
+	for _, name := range req.GetTags() {
+		if name != "" && !slices.Contains(query.Tags, name) {
+			query.Tags = append(query.Tags, name)
+		}
+	}
+	return query
+}
+
+func (s *TodoServer) Update(ctx context.Context, req *todov1.UpdateRequest) (*todov1.Todo, error) {
+	if req.GetTodo() == nil || req.GetTodo().GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "todo with an id is required")
+	}
+	if req.GetForce() {
+		ctx = repository.AllowBlockedCompletion(ctx)
+	}
+	changes := fromProto(req.GetTodo())
+
+	var saved *models.Todo
+	err := s.Todos.Transaction(ctx, func(repo repository.TodoRepository) error {
+		if mask := req.GetUpdateMask(); mask != nil {
+			current, err := repo.FindByID(ctx, changes.ID)
+			if err != nil {
+				return err
+			}
+			if changes.Version != 0 && current.Version != changes.Version {
+				return repository.ErrVersionConflict
+			}
+			if err := applyMask(current, &changes, mask.GetPaths()); err != nil {
+				return err
+			}
+			if err := repo.Replace(ctx, current); err != nil {
+				return err
+			}
+		} else if err := repo.Update(ctx, &changes); err != nil {
+			return err
+		}
+
+		var err error
+		saved, err = repo.FindByID(ctx, changes.ID)
+		return err
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(*saved), nil
+}
+
+func (s *TodoServer) Delete(ctx context.Context, req *todov1.DeleteRequest) (*emptypb.Empty, error) {
+	policy := repository.ChildrenBlock
+	switch req.GetChildren() {
+	case todov1.ChildPolicy_CHILD_POLICY_REPARENT:
+		policy = repository.ChildrenReparent
+	case todov1.ChildPolicy_CHILD_POLICY_CASCADE:
+		policy = repository.ChildrenCascade
+	}
+
+	err := s.Todos.Transaction(ctx, func(repo repository.TodoRepository) error {
+		return repository.DeleteTodo(ctx, repo, uint(req.GetId()), uint(req.GetVersion()), policy)
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Watch sends todo events until the client goes away. When the server shuts
+// down or the client falls too far behind, the call ends with UNAVAILABLE and
+// the client can resume from the last event id it received.
+func (s *TodoServer) Watch(req *todov1.WatchRequest, stream grpc.ServerStreamingServer[todov1.TodoEvent]) error {
+	ctx := stream.Context()
+	filter := events.Filter{
+		Readable:  repository.ReadableTodos(ctx, s.Todos),
+		ProjectID: optionalID(req.ProjectId),
+		Tags:      req.GetTags(),
+		AllTags:   req.GetAllTags(),
+	}
+	followed, ok := s.Stream.Follow(ctx, filter, uint(req.GetAfterEventId()), req.AfterEventId != nil)
+	if !ok {
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+
+	for event := range followed {
+		if err := stream.Send(eventToProto(event)); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Unavailable, "event stream ended; resume with after_event_id")
+}
+
+// statusError maps repository errors onto gRPC codes, the way errorStatus
+// maps them onto HTTP statuses for the REST API. Anything unrecognised is
+// reported as an invalid argument, matching the REST API's bad requests.
+func statusError(err error) error {
+	code := codes.InvalidArgument
+	switch {
+	case errors.Is(err, repository.ErrTodoNotFound), errors.Is(err, repository.ErrProjectNotFound),
+		errors.Is(err, repository.ErrParentNotFound), errors.Is(err, repository.ErrTagNotFound),
+		errors.Is(err, repository.ErrBlockerNotFound):
+		code = codes.NotFound
+	case errors.Is(err, repository.ErrDuplicateTitle):
+		code = codes.AlreadyExists
+	case errors.Is(err, repository.ErrVersionConflict):
+		code = codes.Aborted
+	case errors.Is(err, repository.ErrParentCycle), errors.Is(err, repository.ErrHasSubtasks),
+		errors.Is(err, repository.ErrDependencyCycle), errors.Is(err, repository.ErrTodoBlocked):
+		code = codes.FailedPrecondition
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(code, err.Error())
+}
+
+// maskFields lists the update_mask paths Update accepts.
+var maskFields = []string{"title", "description", "due_date", "remind_at", "complete", "project_id", "parent_id", "tags", "recurrence", "timezone"}
+
+// applyMask copies the masked fields of changes onto todo, including empty
+// values.
+func applyMask(todo, changes *models.Todo, paths []string) error {
+	for _, path := range paths {
+		switch path {
+		case "title":
+			todo.Title = changes.Title
+		case "description":
+			todo.Description = changes.Description
+		case "due_date":
+			todo.DueDate = changes.DueDate
+		case "remind_at":
+			todo.RemindAt = changes.RemindAt
+		case "complete":
+			todo.Complete = changes.Complete
+		case "project_id":
+			todo.ProjectID = changes.ProjectID
+		case "parent_id":
+			todo.ParentID = changes.ParentID
+		case "tags":
+			todo.Tags = changes.Tags
+			if todo.Tags == nil {
+				todo.Tags = []models.Tag{}
+			}
+		case "recurrence":
+			todo.Recurrence = changes.Recurrence
+		case "timezone":
+			todo.Timezone = changes.Timezone
+		default:
+			return fmt.Errorf("cannot update %q; allowed fields are %v", path, maskFields)
+		}
+	}
+	return nil
+}
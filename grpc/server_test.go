@@ -0,0 +1,214 @@
+package grpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	todov1 "github.com/Xillon/golang-todo-api/proto/todo/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+const apiKey = "test-key"
+
+func withKey(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "x-api-key", apiKey)
+}
+
+func TestTodoServiceCRUD(t *testing.T) {
+	client, router := helpers.SetupGRPC(t, apiKey)
+	ctx := withKey(t.Context())
+
+	created, err := client.Create(ctx, &todov1.CreateRequest{Todo: &todov1.Todo{Title: "Write proto", Description: "v1", Tags: []string{"api"}}})
+	require.NoError(t, err)
+	assert.NotZero(t, created.Id)
+	assert.Equal(t, uint64(1), created.Version)
+	assert.Equal(t, []string{"api"}, created.Tags)
+
+	// The REST API sees the same todo.
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, helpers.WithAPIKey(httptest.NewRequest(http.MethodGet, "/todos/"+strconv.FormatUint(created.Id, 10), nil)))
+	require.Equal(t, http.StatusOK, w.Code)
+	var rest struct{ Todo models.Todo }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rest))
+	assert.Equal(t, "Write proto", rest.Todo.Title)
+
+	updated, err := client.Update(ctx, &todov1.UpdateRequest{
+		Todo:       &todov1.Todo{Id: created.Id, Version: created.Version, Description: "", Complete: true},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"description", "complete"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Write proto", updated.Title)
+	assert.Empty(t, updated.Description)
+	assert.True(t, updated.Complete)
+	assert.Equal(t, uint64(2), updated.Version)
+
+	_, err = client.Update(ctx, &todov1.UpdateRequest{Todo: &todov1.Todo{Id: created.Id, Version: created.Version, Title: "Stale"}})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = client.Update(ctx, &todov1.UpdateRequest{
+		Todo:       &todov1.Todo{Id: created.Id},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"version"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	fetched, err := client.Get(ctx, &todov1.GetRequest{Id: created.Id})
+	require.NoError(t, err)
+	assert.Equal(t, updated.Version, fetched.Version)
+
+	_, err = client.Delete(ctx, &todov1.DeleteRequest{Id: created.Id, Version: fetched.Version})
+	require.NoError(t, err)
+	_, err = client.Get(ctx, &todov1.GetRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestTodoServiceValidation(t *testing.T) {
+	client, _ := helpers.SetupGRPC(t, apiKey)
+	ctx := withKey(t.Context())
+
+	_, err := client.Create(ctx, &todov1.CreateRequest{Todo: &todov1.Todo{Title: "Once"}})
+	require.NoError(t, err)
+	_, err = client.Create(ctx, &todov1.CreateRequest{Todo: &todov1.Todo{Title: "Once"}})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	missing := uint64(999)
+	_, err = client.Create(ctx, &todov1.CreateRequest{Todo: &todov1.Todo{Title: "Orphan", ParentId: &missing}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Create(ctx, &todov1.CreateRequest{Todo: &todov1.Todo{Title: "Weekly", Recurrence: "every blue moon"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTodoServiceRequiresAPIKey(t *testing.T) {
+	client, _ := helpers.SetupGRPC(t, apiKey)
+
+	_, err := client.Get(t.Context(), &todov1.GetRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(t.Context(), "x-api-key", "wrong")
+	_, err = client.List(ctx, &todov1.ListRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	watch, err := client.Watch(t.Context(), &todov1.WatchRequest{})
+	require.NoError(t, err)
+	_, err = watch.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestTodoServiceList(t *testing.T) {
+	client, _ := helpers.SetupGRPC(t, apiKey)
+	ctx := withKey(t.Context())
+
+	for i := range 5 {
+		tags := []string{"odd"}
+		if i%2 == 0 {
+			tags = []string{"even"}
+		}
+		_, err := client.Create(ctx, &todov1.CreateRequest{Todo: &todov1.Todo{Title: "Todo " + strconv.Itoa(i), Tags: tags}})
+		require.NoError(t, err)
+	}
+
+	var titles []string
+	token := ""
+	for {
+		page, err := client.List(ctx, &todov1.ListRequest{PageSize: 2, PageToken: token})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), page.TotalSize)
+		for _, todo := range page.Todos {
+			titles = append(titles, todo.Title)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		token = page.NextPageToken
+	}
+	assert.Equal(t, []string{"Todo 0", "Todo 1", "Todo 2", "Todo 3", "Todo 4"}, titles)
+
+	even, err := client.List(ctx, &todov1.ListRequest{Tags: []string{"even"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), even.TotalSize)
+	assert.Empty(t, even.NextPageToken)
+
+	_, err = client.List(ctx, &todov1.ListRequest{PageToken: "bogus"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.List(ctx, &todov1.ListRequest{PageSize: 1000})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Page tokens only continue the listing they were issued for.
+	even, err = client.List(ctx, &todov1.ListRequest{Tags: []string{"even"}, PageSize: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, even.NextPageToken)
+	next, err := client.List(ctx, &todov1.ListRequest{Tags: []string{"even"}, PageSize: 1, PageToken: even.NextPageToken})
+	require.NoError(t, err)
+	assert.Equal(t, "Todo 2", next.Todos[0].Title)
+	_, err = client.List(ctx, &todov1.ListRequest{Tags: []string{"odd"}, PageSize: 1, PageToken: even.NextPageToken})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTodoServiceWatch(t *testing.T) {
+	client, router := helpers.SetupGRPC(t, apiKey)
+	ctx, cancel := context.WithTimeout(withKey(t.Context()), 5*time.Second)
+	defer cancel()
+
+	first, err := client.Create(ctx, &todov1.CreateRequest{Todo: &todov1.Todo{Title: "Before", Tags: []string{"watched"}}})
+	require.NoError(t, err)
+
+	// Resuming from event 0 replays the log, so nothing created after the
+	// call is missed while it subscribes.
+	after := uint64(0)
+	watch, err := client.Watch(ctx, &todov1.WatchRequest{Tags: []string{"watched"}, AfterEventId: &after})
+	require.NoError(t, err)
+
+	_, err = client.Create(ctx, &todov1.CreateRequest{Todo: &todov1.Todo{Title: "Unwatched"}})
+	require.NoError(t, err)
+
+	// Changes made through the REST API are streamed too.
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/todos/"+strconv.FormatUint(first.Id, 10), strings.NewReader(`{"complete":true}`))
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	created, err := watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, models.EventTodoCreated, created.Type)
+	assert.Equal(t, "Before", created.Todo.Title)
+
+	updated, err := watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, models.EventTodoCompleted, updated.Type)
+	assert.True(t, updated.Todo.Complete)
+	assert.Greater(t, updated.Id, created.Id)
+}
+
+func TestTodoServiceChecksAPIKeyScopes(t *testing.T) {
+	client, router := helpers.SetupGRPC(t, "")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, helpers.WithAPIKey(httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(`{"name": "reporting", "scopes": ["todos:read"]}`))))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var issued struct{ Key string }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+
+	_, err := client.List(t.Context(), &todov1.ListRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(t.Context(), "x-api-key", issued.Key)
+	_, err = client.List(ctx, &todov1.ListRequest{})
+	assert.NoError(t, err)
+	_, err = client.Create(ctx, &todov1.CreateRequest{Todo: &todov1.Todo{Title: "Read only"}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/collab"
+	"github.com/Xillon/golang-todo-api/grpc"
+	"github.com/Xillon/golang-todo-api/http"
+	todov1 "github.com/Xillon/golang-todo-api/proto/todo/v1"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// SetupGRPC serves the TodoService over an in-process listener and returns a
+// client for it, together with a REST router over the same database. Every
+// call needs credentials: apiKey, when not empty, is accepted like API_KEY,
+// and so are the keys issued through the router.
+func SetupGRPC(t *testing.T, apiKey string) (todov1.TodoServiceClient, *gin.Engine) {
+	t.Helper()
+
+	db := OpenSQLite(t)
+	log := repository.NewGormEventLog(db)
+	bus := repository.ProvideEventBus(log)
+	todos := repository.ProvideTodoRepository(db, bus)
+	stream := repository.ProvideEventStream(log, bus)
+	cursors := http.NewCursorSigner([]byte("test-cursor-secret"))
+	users, apiKeys := repository.NewGormUserStore(db), repository.NewGormAPIKeyStore(db)
+	router := setupRouter(t, todos, repository.NewGormIdempotencyStore(db), repository.NewGormWebhookStore(db), stream, setupHub(t, collab.NewMemoryBroker(), bus), users, apiKeys, nil)
+
+	server := grpc.NewServer(grpc.ProvideTodoServer(todos, cursors, stream), http.NewAuthenticator(apiKey, users, apiKeys, nil))
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(func() {
+		stream.Close()
+		server.Stop()
+	})
+
+	conn, err := gogrpc.NewClient("passthrough:///bufconn",
+		gogrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		gogrpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial gRPC server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return todov1.NewTodoServiceClient(conn), router
+}
@@ -1,39 +1,158 @@
 package helpers
 
 import (
+	"fmt"
+	nethttp "net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Xillon/golang-todo-api/collab"
+	"github.com/Xillon/golang-todo-api/events"
 	"github.com/Xillon/golang-todo-api/http"
-	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/Xillon/golang-todo-api/worker"
 	"github.com/gin-gonic/gin"
 	sqlite "github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 )
 
-func SetupRouterWithSQLite(t *testing.T) (*gin.Engine, *gorm.DB) {
+// OpenSQLite opens a migrated in-memory database private to the calling test.
+func OpenSQLite(t *testing.T) *gorm.DB {
 	t.Helper()
 
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", name)), &gorm.Config{TranslateError: true})
 	if err != nil {
 		t.Fatalf("failed to open sqlite: %v", err)
 	}
 
-	if err := db.AutoMigrate(&models.Todo{}); err != nil {
+	if err := repository.AutoMigrate(db); err != nil {
 		t.Fatalf("failed to migrate: %v", err)
 	}
 
-	if err := db.Exec("DELETE FROM todos").Error; err != nil {
-		t.Fatalf("failed to reset todos table: %v", err)
+	// The in-memory database is dropped once its last connection is closed.
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return db
+}
+
+func SetupRouterWithSQLite(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+
+	db := OpenSQLite(t)
+	return SetupRouterWithCollab(t, db, collab.NewMemoryBroker()), db
+}
+
+func SetupRouterWithMemory(t *testing.T) (*gin.Engine, *repository.MemoryTodoRepository) {
+	t.Helper()
+
+	repo := repository.NewMemoryTodoRepository()
+	log := repository.NewMemoryEventLog()
+	bus := repository.ProvideEventBus(log)
+	todos := repository.NewPolicyTodoRepository(repository.NewRecurringTodoRepository(repository.NewHistoryTodoRepository(repository.NewEventTodoRepository(repo, bus))))
+	return setupRouter(t, todos, repository.NewMemoryIdempotencyStore(), repository.NewMemoryWebhookStore(), repository.ProvideEventStream(log, bus), setupHub(t, collab.NewMemoryBroker(), bus), repository.NewMemoryUserStore(), repository.NewMemoryAPIKeyStore(), nil), repo
+}
+
+// SetupRouterWithWebhooks returns a router backed by SQLite whose todo events
+// are queued on the returned dispatcher, which posts them with client. The
+// dispatcher only sends when the test calls RunOnce.
+func SetupRouterWithWebhooks(t *testing.T, client *nethttp.Client) (*gin.Engine, *worker.WebhookDispatcher) {
+	t.Helper()
+
+	db := OpenSQLite(t)
+	log := repository.NewGormEventLog(db)
+	bus := repository.ProvideEventBus(log)
+	webhooks := repository.NewGormWebhookStore(db)
+	dispatcher := worker.NewWebhookDispatcher(webhooks, client, time.Second, 3)
+	bus.Subscribe(dispatcher.Enqueue)
+	todos := repository.ProvideTodoRepository(db, bus)
+	return setupRouter(t, todos, repository.NewGormIdempotencyStore(db), webhooks, repository.ProvideEventStream(log, bus), setupHub(t, collab.NewMemoryBroker(), bus), repository.NewGormUserStore(db), repository.NewGormAPIKeyStore(db), nil), dispatcher
+}
+
+// SetupRouterWithCollab returns a router over db whose collaboration hub
+// talks to other instances through broker. Routers sharing db and broker
+// behave like several instances of the API.
+func SetupRouterWithCollab(t *testing.T, db *gorm.DB, broker collab.Broker) *gin.Engine {
+	t.Helper()
+
+	log := repository.NewGormEventLog(db)
+	bus := repository.ProvideEventBus(log)
+	todos := repository.ProvideTodoRepository(db, bus)
+	return setupRouter(t, todos, repository.NewGormIdempotencyStore(db), repository.NewGormWebhookStore(db), repository.ProvideEventStream(log, bus), setupHub(t, broker, bus), repository.NewGormUserStore(db), repository.NewGormAPIKeyStore(db), nil)
+}
+
+// SetupRouterWithJWT returns a router backed by SQLite that also accepts the
+// JWTs verifier accepts.
+func SetupRouterWithJWT(t *testing.T, verifier *http.JWTVerifier) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+
+	db := OpenSQLite(t)
+	log := repository.NewGormEventLog(db)
+	bus := repository.ProvideEventBus(log)
+	todos := repository.ProvideTodoRepository(db, bus)
+	return setupRouter(t, todos, repository.NewGormIdempotencyStore(db), repository.NewGormWebhookStore(db), repository.ProvideEventStream(log, bus), setupHub(t, collab.NewMemoryBroker(), bus), repository.NewGormUserStore(db), repository.NewGormAPIKeyStore(db), verifier), db
+}
+
+func setupHub(t *testing.T, broker collab.Broker, bus *events.Bus) *collab.Hub {
+	t.Helper()
+
+	hub, err := collab.ProvideHub(broker, bus)
+	if err != nil {
+		t.Fatalf("failed to start collaboration hub: %v", err)
+	}
+	t.Cleanup(hub.Close)
+	return hub
+}
+
+// testPasswordCost keeps bcrypt fast in tests.
+const testPasswordCost = 4
+
+// TestAPIKey is the admin key the test routers are configured with, like
+// API_KEY in production.
+const TestAPIKey = "test-api-key"
+
+// WithAPIKey authenticates req with TestAPIKey and returns it.
+func WithAPIKey(req *nethttp.Request) *nethttp.Request {
+	req.Header.Set("X-API-Key", TestAPIKey)
+	return req
+}
+
+func setupRouter(t *testing.T, todos repository.TodoRepository, keys repository.IdempotencyStore, webhookStore repository.WebhookStore, events *events.Stream, hub *collab.Hub, users repository.UserStore, apiKeyStore repository.APIKeyStore, verifier *http.JWTVerifier) *gin.Engine {
+	t.Helper()
+
+	cursors := http.NewCursorSigner([]byte("test-cursor-secret"))
+	handler := http.ProvideTodoHandler(todos, cursors)
+	shares := http.ProvideShareHandler(todos, users)
+	auth := http.NewAuthHandler(users, time.Hour, testPasswordCost)
+	authenticator := http.NewAuthenticator(TestAPIKey, users, apiKeyStore, verifier)
+	apiKeys := http.ProvideAPIKeyHandler(apiKeyStore)
+	webhooks := http.ProvideWebhookHandler(webhookStore)
+	collab := http.ProvideCollabHandler(todos, hub)
+	stream := http.ProvideEventStream(events, todos)
+	graphql, err := http.ProvideGraphQLHandler(todos, cursors, events)
+	if err != nil {
+		t.Fatalf("failed to build GraphQL schema: %v", err)
 	}
-	handler := http.ProvideTodoHandler(db)
 	gin.SetMode(gin.TestMode)
 
 	router := gin.New()
-	router.POST("/todos", handler.AddTodos)
-	router.PATCH("/todos", handler.UpdateTodos)
-	router.GET("/todos", handler.GetTodos)
-	router.DELETE("/todos/:id", handler.DeleteTodoById)
-
-	return router, db
+	http.RegisterRoutes(router, http.Handlers{
+		Todos:         handler,
+		Shares:        shares,
+		Auth:          auth,
+		Authenticator: authenticator,
+		APIKeys:       apiKeys,
+		Webhooks:      webhooks,
+		Events:        stream,
+		Collab:        collab,
+		GraphQL:       graphql,
+		Idempotency:   http.NewIdempotency(keys, time.Hour),
+	})
 
+	return router
 }
@@ -0,0 +1,173 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler lets admins issue, rotate and revoke API keys.
+type APIKeyHandler struct {
+	Keys repository.APIKeyStore
+}
+
+func ProvideAPIKeyHandler(keys repository.APIKeyStore) *APIKeyHandler {
+	return &APIKeyHandler{Keys: keys}
+}
+
+type apiKeyRequest struct {
+	Name      string     `json:"name" example:"reporting"`
+	Scopes    []string   `json:"scopes" example:"todos:read"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type rotateAPIKeyRequest struct {
+	// GracePeriodSeconds keeps the old key working for a while.
+	GracePeriodSeconds int `json:"grace_period_seconds"`
+}
+
+// GetAPIKeys godoc
+// @Summary      List API keys
+// @Description  Revoked and expired keys are listed too. Only the prefix of each key is shown.
+// @Tags         api-keys
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key with the admin scope"
+// @Success      200  {object}  map[string][]models.APIKey
+// @Failure      403  {object}  map[string]string
+// @Router       /api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	keys, err := h.Keys.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// AddAPIKey godoc
+// @Summary      Create an API key
+// @Description  scopes is a subset of todos:read, todos:write (which implies todos:read) and admin. The key is only returned in this response.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string         true  "API key with the admin scope"
+// @Param        request    body    apiKeyRequest  true  "Name, scopes and optional expiry"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /api-keys [post]
+func (h *APIKeyHandler) AddAPIKey(c *gin.Context) {
+	var request apiKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, secret, err := NewAPIKey(request.Name, request.Scopes, request.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.Keys.CreateAPIKey(c.Request.Context(), key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"api_key": key, "key": secret})
+}
+
+// GetAPIKeyById godoc
+// @Summary      Get API key by ID
+// @Tags         api-keys
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key with the admin scope"
+// @Param        id         path    int     true  "API key ID"
+// @Success      200  {object}  map[string]models.APIKey
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api-keys/{id} [get]
+func (h *APIKeyHandler) GetAPIKeyById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.Keys.FindAPIKey(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_key": key})
+}
+
+// RevokeAPIKeyById godoc
+// @Summary      Revoke an API key
+// @Description  The key stops working at once. It stays listed with its revoked_at time.
+// @Tags         api-keys
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key with the admin scope"
+// @Param        id         path    int     true  "API key ID"
+// @Success      200  {object}  map[string]models.APIKey
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKeyById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	err := h.Keys.RevokeAPIKey(c.Request.Context(), id, time.Now())
+	var key *models.APIKey
+	if err == nil {
+		key, err = h.Keys.FindAPIKey(c.Request.Context(), id)
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_key": key})
+}
+
+// RotateAPIKeyById godoc
+// @Summary      Rotate an API key
+// @Description  Creates a key with the same name, scopes and expiry and returns it; the key is only returned in this response.
+// @Description  The old key expires after grace_period_seconds, immediately by default.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string               true   "API key with the admin scope"
+// @Param        id         path    int                  true   "API key ID"
+// @Param        request    body    rotateAPIKeyRequest  false  "Grace period"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKeyById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var request rotateAPIKeyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	key, secret, err := RotateAPIKey(c.Request.Context(), h.Keys, id, time.Duration(request.GracePeriodSeconds)*time.Second)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"api_key": key, "key": secret})
+}
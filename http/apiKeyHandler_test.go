@@ -0,0 +1,200 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendWithAPIKey sends a JSON request with the given X-API-Key.
+func sendWithAPIKey(t *testing.T, router *gin.Engine, key, method, url, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+type issuedAPIKey struct {
+	APIKey models.APIKey `json:"api_key"`
+	Key    string        `json:"key"`
+}
+
+// issueAPIKey creates a key with the given admin key.
+func issueAPIKey(t *testing.T, router *gin.Engine, admin, body string) issuedAPIKey {
+	t.Helper()
+
+	rec := sendWithAPIKey(t, router, admin, http.MethodPost, "/api-keys", body)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var issued issuedAPIKey
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &issued))
+	require.True(t, strings.HasPrefix(issued.Key, issued.APIKey.Prefix))
+	return issued
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	for name, setup := range map[string]func(t *testing.T) *gin.Engine{
+		"sqlite": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithSQLite(t); return router },
+		"memory": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithMemory(t); return router },
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+
+			// Every request needs a key, so the first admin key has to come
+			// from API_KEY or the apikey command.
+			rec := sendWithAPIKey(t, router, "", http.MethodPost, "/api-keys", `{"name": "ops", "scopes": ["admin"]}`)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			admin := issueAPIKey(t, router, helpers.TestAPIKey, `{"name": "ops", "scopes": ["admin"]}`).Key
+			assert.Equal(t, http.StatusUnauthorized, sendWithAPIKey(t, router, "", http.MethodGet, "/todos", "").Code)
+			assert.Equal(t, http.StatusUnauthorized, sendWithAPIKey(t, router, "tdk_made-up", http.MethodGet, "/todos", "").Code)
+
+			reader := issueAPIKey(t, router, admin, `{"name": "reporting", "scopes": ["todos:read"]}`).Key
+			writer := issueAPIKey(t, router, admin, `{"name": "importer", "scopes": ["todos:write"]}`).Key
+
+			rec = sendWithAPIKey(t, router, writer, http.MethodPost, "/todos", `{"todos": [{"title": "Imported"}]}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			assert.Equal(t, http.StatusOK, sendWithAPIKey(t, router, writer, http.MethodGet, "/todos", "").Code)
+			rec = sendWithAPIKey(t, router, reader, http.MethodGet, "/todos/1/history", "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"actor":"api-key:importer"`)
+
+			// Read-only keys can read, including GraphQL queries over POST,
+			// but not change anything.
+			assert.Equal(t, http.StatusOK, sendWithAPIKey(t, router, reader, http.MethodGet, "/todos", "").Code)
+			rec = sendWithAPIKey(t, router, reader, http.MethodPost, "/todos", `{"todos": [{"title": "Nope"}]}`)
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Contains(t, rec.Body.String(), "todos:write")
+			assert.Equal(t, http.StatusForbidden, sendWithAPIKey(t, router, reader, http.MethodDelete, "/todos/1", "").Code)
+			rec = sendWithAPIKey(t, router, reader, http.MethodPost, "/graphql", `{"query": "{ todos { totalCount } }"}`)
+			assert.JSONEq(t, `{"data": {"todos": {"totalCount": 1}}}`, rec.Body.String())
+			rec = sendWithAPIKey(t, router, reader, http.MethodPost, "/graphql", `{"query": "mutation { addTodos(todos: [{title: \"Nope\"}]) { id } }"}`)
+			var response struct {
+				Errors []struct {
+					Extensions map[string]any `json:"extensions"`
+				} `json:"errors"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			require.Len(t, response.Errors, 1)
+			assert.EqualValues(t, http.StatusForbidden, response.Errors[0].Extensions["status"])
+
+			// Only admin keys manage keys and webhooks.
+			for _, key := range []string{reader, writer} {
+				assert.Equal(t, http.StatusForbidden, sendWithAPIKey(t, router, key, http.MethodGet, "/api-keys", "").Code)
+				assert.Equal(t, http.StatusForbidden, sendWithAPIKey(t, router, key, http.MethodGet, "/webhooks", "").Code)
+			}
+			assert.Equal(t, http.StatusOK, sendWithAPIKey(t, router, admin, http.MethodGet, "/webhooks", "").Code)
+
+			// Admin routes do not accept user tokens at all.
+			token := signUp(t, router, "alice")
+			assert.Equal(t, http.StatusOK, sendAs(t, router, token, http.MethodGet, "/todos", "").Code)
+			assert.Equal(t, http.StatusUnauthorized, sendAs(t, router, token, http.MethodGet, "/api-keys", "").Code)
+		})
+	}
+}
+
+func TestAPIKeysPlaceTodosOfUsers(t *testing.T) {
+	for name, setup := range shareBackends() {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			alice := signUp(t, router, "alice")
+			project := createProjectAs(t, router, alice, "Home")
+			plants := createTodoAs(t, router, alice, `{"title": "Water plants"}`)
+			parent := strings.TrimPrefix(plants, "/todos/")
+
+			// Keys see every user's rows, so what they put in a project or
+			// below a todo belongs to the owner of that project or todo.
+			rec := sendWithAPIKey(t, router, helpers.TestAPIKey, http.MethodPost, "/todos", `{"todos": [{"title": "Sweep", "project_id": `+project+`}, {"title": "Buy soil", "parent_id": `+parent+`}]}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			var created struct {
+				Todos []models.Todo `json:"todos"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+			require.Len(t, created.Todos, 2)
+			for _, todo := range created.Todos {
+				assert.Equal(t, http.StatusOK, sendAs(t, router, alice, http.MethodGet, "/todos/"+strconv.Itoa(int(todo.ID)), "").Code)
+			}
+			rec = sendAs(t, router, alice, http.MethodGet, plants+"/children", "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "Buy soil")
+
+			rec = sendWithAPIKey(t, router, helpers.TestAPIKey, http.MethodPost, "/todos", `{"todos": [{"title": "Nowhere", "project_id": 999}]}`)
+			assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	admin := issueAPIKey(t, router, helpers.TestAPIKey, `{"name": "ops", "scopes": ["admin"]}`).Key
+
+	for _, body := range []string{
+		`{"name": "", "scopes": ["todos:read"]}`,
+		`{"name": "reporting", "scopes": []}`,
+		`{"name": "reporting", "scopes": ["todos:delete"]}`,
+		`{"name": "reporting", "scopes": ["todos:read"], "expires_at": "2000-01-01T00:00:00Z"}`,
+	} {
+		rec := sendWithAPIKey(t, router, admin, http.MethodPost, "/api-keys", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	first := issueAPIKey(t, router, admin, `{"name": "reporting", "scopes": ["todos:read", "todos:read"], "expires_at": "`+expiresAt+`"}`)
+	assert.Equal(t, models.Scopes{models.ScopeTodosRead}, first.APIKey.Scopes)
+	require.Equal(t, http.StatusOK, sendWithAPIKey(t, router, first.Key, http.MethodGet, "/todos", "").Code)
+
+	// Listing shows when keys were last used, never the keys themselves.
+	rec := sendWithAPIKey(t, router, admin, http.MethodGet, "/api-keys", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), first.Key)
+	assert.NotContains(t, rec.Body.String(), `"hash"`)
+	var listed struct {
+		APIKeys []models.APIKey `json:"api_keys"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed.APIKeys, 2)
+	assert.NotNil(t, listed.APIKeys[1].LastUsedAt)
+
+	// Rotating with a grace period keeps both keys working for a while.
+	firstURL := "/api-keys/" + strconv.Itoa(int(first.APIKey.ID))
+	rec = sendWithAPIKey(t, router, admin, http.MethodPost, firstURL+"/rotate", `{"grace_period_seconds": 3600}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var second issuedAPIKey
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &second))
+	assert.Equal(t, "reporting", second.APIKey.Name)
+	assert.Equal(t, first.APIKey.Scopes, second.APIKey.Scopes)
+	assert.Equal(t, http.StatusOK, sendWithAPIKey(t, router, first.Key, http.MethodGet, "/todos", "").Code)
+	assert.Equal(t, http.StatusOK, sendWithAPIKey(t, router, second.Key, http.MethodGet, "/todos", "").Code)
+
+	// Without one the old key stops at once.
+	secondURL := "/api-keys/" + strconv.Itoa(int(second.APIKey.ID))
+	rec = sendWithAPIKey(t, router, admin, http.MethodPost, secondURL+"/rotate", "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var third issuedAPIKey
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &third))
+	assert.Equal(t, http.StatusUnauthorized, sendWithAPIKey(t, router, second.Key, http.MethodGet, "/todos", "").Code)
+	assert.Equal(t, http.StatusOK, sendWithAPIKey(t, router, third.Key, http.MethodGet, "/todos", "").Code)
+	assert.Equal(t, http.StatusConflict, sendWithAPIKey(t, router, admin, http.MethodPost, secondURL+"/rotate", "").Code)
+
+	thirdURL := "/api-keys/" + strconv.Itoa(int(third.APIKey.ID))
+	rec = sendWithAPIKey(t, router, admin, http.MethodDelete, thirdURL, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"revoked_at":"`)
+	assert.Equal(t, http.StatusUnauthorized, sendWithAPIKey(t, router, third.Key, http.MethodGet, "/todos", "").Code)
+	assert.Equal(t, http.StatusNotFound, sendWithAPIKey(t, router, admin, http.MethodDelete, "/api-keys/99", "").Code)
+}
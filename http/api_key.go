@@ -1,20 +1,96 @@
 package http
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
 	"github.com/gin-gonic/gin"
 )
 
 const apiKeyHeader = "X-API-Key"
 
+// apiKeyActor is recorded in todo history for changes made with the shared key.
+const apiKeyActor = "api-key"
+
+// apiKeyPrefix starts every stored API key, so that leaked keys are easy to
+// search for.
+const apiKeyPrefix = "tdk_"
+
+// apiKeyPrefixLength is how much of a key is kept in clear to tell keys apart.
+const apiKeyPrefixLength = len(apiKeyPrefix) + 8
+
 func APIKeyMiddleware(expected string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		provided := c.GetHeader(apiKeyHeader)
-		if provided == "" || provided != expected {
+		if !sameKey(c.GetHeader(apiKeyHeader), expected) {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid api key"})
 			return
 		}
+		c.Request = c.Request.WithContext(repository.WithActor(c.Request.Context(), apiKeyActor))
 		c.Next()
 	}
 }
+
+// sameKey compares a provided key in constant time. An empty key never
+// matches.
+func sameKey(provided, expected string) bool {
+	return provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) == 1
+}
+
+// NewAPIKey returns an API key with a fresh secret, ready to be stored, and
+// the secret itself, which is the only copy.
+func NewAPIKey(name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	name, err := models.NormalizeAPIKeyName(name)
+	if err != nil {
+		return nil, "", err
+	}
+	normalized, err := models.NormalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", fmt.Errorf("expires_at must be in the future")
+	}
+
+	raw := make([]byte, 32)
+	rand.Read(raw)
+	secret := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	key := &models.APIKey{
+		Name:      name,
+		Prefix:    secret[:apiKeyPrefixLength],
+		Hash:      hashToken(secret),
+		Scopes:    normalized,
+		ExpiresAt: expiresAt,
+	}
+	return key, secret, nil
+}
+
+// RotateAPIKey replaces the key id by a new one with the same name, scopes
+// and expiry. The old key keeps working for the grace period, so that
+// clients can switch over, and the new secret is returned.
+func RotateAPIKey(ctx context.Context, keys repository.APIKeyStore, id uint, grace time.Duration) (*models.APIKey, string, error) {
+	if grace < 0 {
+		return nil, "", fmt.Errorf("grace period must not be negative")
+	}
+	current, err := keys.FindAPIKey(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if !current.Active(time.Now()) {
+		return nil, "", repository.ErrAPIKeyInactive
+	}
+	replacement, secret, err := NewAPIKey(current.Name, current.Scopes, current.ExpiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := keys.RotateAPIKey(ctx, id, replacement, time.Now().Add(grace)); err != nil {
+		return nil, "", err
+	}
+	return replacement, secret, nil
+}
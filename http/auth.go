@@ -0,0 +1,296 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const bearerPrefix = "Bearer "
+
+// principalKey stores the Principal of a request in the gin context.
+const principalKey = "principal"
+
+// Credential is a way for a request to prove who it is made by.
+type Credential int
+
+const (
+	// CredentialAPIKey is the API_KEY or a stored API key, sent as X-API-Key.
+	CredentialAPIKey Credential = iota
+	// CredentialLoginToken is a token handed out by POST /auth/login.
+	CredentialLoginToken
+	// CredentialJWT is a JWT signed by one of the configured keys.
+	CredentialJWT
+)
+
+func (c Credential) String() string {
+	switch c {
+	case CredentialAPIKey:
+		return "api_key"
+	case CredentialLoginToken:
+		return "login_token"
+	case CredentialJWT:
+		return "jwt"
+	}
+	return "unknown"
+}
+
+// Principal is who an authenticated request is made by.
+type Principal struct {
+	Credential Credential
+	// Subject is the username, or the name of the API key.
+	Subject string
+	// User is the user the request is scoped to; nil for API keys.
+	User *models.User
+	// Scopes are what an API key, or the scope claim of a JWT, allows.
+	// Users logging in with a password may do anything to their own todos.
+	// Only API keys are ever granted ScopeAdmin.
+	Scopes models.Scopes
+	// APIKey is the stored key the request was made with; nil for API_KEY
+	// and other credentials.
+	APIKey *models.APIKey
+	// Claims are the claims of a JWT; nil for other credentials.
+	Claims *JWTClaims
+}
+
+// Allows reports whether the principal has scope.
+func (p *Principal) Allows(scope string) bool {
+	switch {
+	case p.Credential == CredentialAPIKey:
+		return p.Scopes.Allows(scope)
+	case scope == models.ScopeAdmin:
+		return false
+	case p.Credential == CredentialJWT:
+		return p.Scopes.Allows(scope)
+	}
+	return true
+}
+
+// CurrentPrincipal returns who the request was authenticated as.
+func CurrentPrincipal(c *gin.Context) (*Principal, bool) {
+	principal, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	return principal.(*Principal), true
+}
+
+// Authenticator checks the credentials of requests. Route groups pick the
+// credentials they accept with Require.
+type Authenticator struct {
+	apiKey  string
+	users   repository.UserStore
+	apiKeys repository.APIKeyStore
+	jwt     *JWTVerifier
+}
+
+// NewAuthenticator returns an Authenticator. apiKey is an admin key kept
+// outside the database; when it is empty only stored keys are accepted. A
+// nil verifier rejects every JWT.
+func NewAuthenticator(apiKey string, users repository.UserStore, apiKeys repository.APIKeyStore, verifier *JWTVerifier) *Authenticator {
+	return &Authenticator{apiKey: apiKey, users: users, apiKeys: apiKeys, jwt: verifier}
+}
+
+// ProvideAuthenticator reads the admin key from API_KEY.
+func ProvideAuthenticator(users repository.UserStore, apiKeys repository.APIKeyStore, verifier *JWTVerifier) *Authenticator {
+	apiKey := os.Getenv("API_KEY")
+	if apiKey == "" {
+		log.Println("warning: API_KEY not set; only stored API keys are accepted, create the first one with `apikey create --scope admin`")
+	}
+	return NewAuthenticator(apiKey, users, apiKeys, verifier)
+}
+
+// Require admits requests carrying one of the accepted credentials. Bearer
+// tokens with the three dot separated parts of a JWT are verified as JWTs,
+// other bearer tokens are looked up as login tokens.
+//
+// Login tokens and JWTs scope the request to their user. A JWT's subject is
+// the username; its user is created on first sight without a password, and
+// a subject naming a user who registered with a password is refused. The API
+// keys see every user's todos, as far as their scopes allow. Requests without
+// credentials are always rejected.
+func (a *Authenticator) Require(accepted ...Credential) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var principal *Principal
+		var err error
+		if header := c.GetHeader("Authorization"); header != "" {
+			token, ok := strings.CutPrefix(header, bearerPrefix)
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization must be a Bearer token"})
+				return
+			}
+			credential := CredentialLoginToken
+			if strings.Count(token, ".") == 2 {
+				credential = CredentialJWT
+			}
+			if !slices.Contains(accepted, credential) || (credential == CredentialJWT && a.jwt == nil) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("%s credentials are not accepted here", credential)})
+				return
+			}
+			if credential == CredentialJWT {
+				principal, err = a.verifyJWT(c.Request.Context(), token)
+			} else {
+				principal, err = a.findLoginToken(c.Request.Context(), token)
+			}
+		} else if slices.Contains(accepted, CredentialAPIKey) {
+			principal, err = a.CheckAPIKey(c.Request.Context(), c.GetHeader(apiKeyHeader))
+		} else {
+			err = errors.New("a bearer token is required")
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(principalKey, principal)
+		ctx := repository.WithActor(c.Request.Context(), principal.Subject)
+		if principal.User != nil {
+			ctx = repository.WithOwner(ctx, principal.User.ID)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// apiKeyTouchInterval limits how often the last use of a key is written.
+const apiKeyTouchInterval = time.Minute
+
+var errInvalidAPIKey = errors.New("missing or invalid credentials")
+
+// CheckAPIKey returns the principal of an API key, or errInvalidAPIKey when
+// the key is missing or unknown.
+func (a *Authenticator) CheckAPIKey(ctx context.Context, provided string) (*Principal, error) {
+	if provided == "" {
+		return nil, errInvalidAPIKey
+	}
+	if a.apiKey != "" && sameKey(provided, a.apiKey) {
+		return &Principal{Credential: CredentialAPIKey, Subject: apiKeyActor, Scopes: models.Scopes{models.ScopeAdmin}}, nil
+	}
+
+	now := time.Now()
+	key, err := a.apiKeys.FindActiveAPIKey(ctx, hashToken(provided), now)
+	if err != nil {
+		return nil, errInvalidAPIKey
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := a.apiKeys.TouchAPIKey(ctx, key.ID, now); err != nil {
+			log.Printf("failed to record the use of api key %d: %v", key.ID, err)
+		}
+	}
+	return &Principal{Credential: CredentialAPIKey, Subject: apiKeyActor + ":" + key.Name, Scopes: key.Scopes, APIKey: key}, nil
+}
+
+// scopeError is returned when a principal lacks the scope an action needs.
+type scopeError struct {
+	scope string
+}
+
+func (e scopeError) Error() string {
+	return fmt.Sprintf("the %s scope is required", e.scope)
+}
+
+// allowed reports whether the request may act with scope. Requests without
+// a principal may do nothing.
+func allowed(c *gin.Context, scope string) bool {
+	principal, ok := CurrentPrincipal(c)
+	return ok && principal.Allows(scope)
+}
+
+// RequireScope rejects requests lacking scope with 403.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !allowed(c, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": scopeError{scope}.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireTodoScope requires models.ScopeTodosRead for GET and HEAD requests
+// and models.ScopeTodosWrite for everything else.
+func RequireTodoScope() gin.HandlerFunc {
+	read, write := RequireScope(models.ScopeTodosRead), RequireScope(models.ScopeTodosWrite)
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			read(c)
+		} else {
+			write(c)
+		}
+	}
+}
+
+func (a *Authenticator) findLoginToken(ctx context.Context, token string) (*Principal, error) {
+	found, err := a.users.FindToken(ctx, hashToken(token), time.Now())
+	if err != nil {
+		return nil, repository.ErrTokenNotFound
+	}
+	return &Principal{Credential: CredentialLoginToken, Subject: found.User.Username, User: &found.User}, nil
+}
+
+func (a *Authenticator) verifyJWT(ctx context.Context, token string) (*Principal, error) {
+	claims, err := a.jwt.Verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT: %w", err)
+	}
+	username, err := models.NormalizeUsername(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT subject: %w", err)
+	}
+
+	user, err := a.users.FindUserByName(ctx, username)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		user = &models.User{Username: username}
+		err = a.users.CreateUser(ctx, user)
+		if errors.Is(err, repository.ErrDuplicateUser) {
+			// Another request created the user first.
+			user, err = a.users.FindUserByName(ctx, username)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.PasswordHash != "" {
+		return nil, errors.New("the JWT subject belongs to a user who logs in with a password")
+	}
+	return &Principal{Credential: CredentialJWT, Subject: username, User: user, Scopes: todoScopes(claims), Claims: claims}, nil
+}
+
+// todoScopes picks the todo scopes out of the scope claim. Other scopes,
+// admin included, grant nothing here.
+func todoScopes(claims *JWTClaims) models.Scopes {
+	scopes := models.Scopes{}
+	for _, scope := range claims.Scopes() {
+		if (scope == models.ScopeTodosRead || scope == models.ScopeTodosWrite) && !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// newAuthToken returns a random token to hand out and the hash to store.
+func newAuthToken() (token, hash string) {
+	raw := make([]byte, 32)
+	rand.Read(raw)
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, hashToken(token)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
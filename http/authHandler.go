@@ -0,0 +1,176 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errInvalidCredentials = errors.New("invalid username or password")
+
+// AuthHandler registers users and logs them in and out.
+type AuthHandler struct {
+	Users repository.UserStore
+	// TokenTTL is how long a login token stays valid.
+	TokenTTL time.Duration
+	// Cost is the bcrypt cost passwords are hashed with.
+	Cost int
+	// dummyHash is compared against when the user does not exist, so that
+	// logins take as long for unknown users as for wrong passwords.
+	dummyHash []byte
+}
+
+func NewAuthHandler(users repository.UserStore, tokenTTL time.Duration, cost int) *AuthHandler {
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("not a password"), cost)
+	return &AuthHandler{Users: users, TokenTTL: tokenTTL, Cost: cost, dummyHash: dummyHash}
+}
+
+// ProvideAuthHandler reads the token lifetime from AUTH_TOKEN_TTL_HOURS,
+// defaulting to a week.
+func ProvideAuthHandler(users repository.UserStore) *AuthHandler {
+	hours, err := strconv.Atoi(os.Getenv("AUTH_TOKEN_TTL_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = 7 * 24
+	}
+	return NewAuthHandler(users, time.Duration(hours)*time.Hour, bcrypt.DefaultCost)
+}
+
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Register godoc
+// @Summary      Create a user account
+// @Description  Usernames are unique; passwords need 8 to 72 bytes. Log in afterwards to get a token.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      credentials  true  "Username and password"
+// @Success      201  {object}  map[string]models.User
+// @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /auth/register [post]
+func (h *AuthHandler) Register(c *gin.Context) {
+	var request credentials
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username, err := models.NormalizeUsername(request.Username)
+	if err == nil {
+		err = models.CheckPassword(request.Password)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(request.Password), h.Cost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user := models.User{Username: username, PasswordHash: string(hash)}
+	if err := h.Users.CreateUser(c.Request.Context(), &user); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrDuplicateUser) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"user": user})
+}
+
+// Login godoc
+// @Summary      Log in
+// @Description  Returns a token to send as "Authorization: Bearer <token>". Requests made with it only see the user's own todos, tags and projects.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      credentials  true  "Username and password"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /auth/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
+	var request credentials
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.Users.FindUserByName(c.Request.Context(), strings.TrimSpace(request.Username))
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	hash := h.dummyHash
+	if user != nil && user.PasswordHash != "" {
+		hash = []byte(user.PasswordHash)
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(request.Password)) != nil || user == nil || user.PasswordHash == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidCredentials.Error()})
+		return
+	}
+
+	token, tokenHash := newAuthToken()
+	stored := models.AuthToken{UserID: user.ID, Hash: tokenHash, ExpiresAt: time.Now().Add(h.TokenTTL)}
+	if err := h.Users.CreateToken(c.Request.Context(), &stored); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "expires_at": stored.ExpiresAt, "user": user})
+}
+
+// Logout godoc
+// @Summary      Log out
+// @Description  Revokes the bearer token the request was made with.
+// @Tags         auth
+// @Param        Authorization  header  string  true  "Bearer token"
+// @Success      204
+// @Failure      401  {object}  map[string]string
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if principal, ok := CurrentPrincipal(c); !ok || principal.Credential != CredentialLoginToken {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "log in with a bearer token first"})
+		return
+	}
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), bearerPrefix)
+	if err := h.Users.DeleteToken(c.Request.Context(), hashToken(token)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetCurrentUser godoc
+// @Summary      Get the logged in user
+// @Description  Works with login tokens and JWTs; a JWT's subject is shown as the username.
+// @Tags         auth
+// @Produce      json
+// @Param        Authorization  header  string  true  "Bearer token"
+// @Success      200  {object}  map[string]models.User
+// @Failure      401  {object}  map[string]string
+// @Router       /auth/me [get]
+func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
+	principal, ok := CurrentPrincipal(c)
+	if !ok || principal.User == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "log in with a bearer token first"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": principal.User})
+}
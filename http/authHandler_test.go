@@ -0,0 +1,196 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendAs sends a JSON request with the given bearer token.
+func sendAs(t *testing.T, router *gin.Engine, token, method, url, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// signUp registers a user and returns a login token for them.
+func signUp(t *testing.T, router *gin.Engine, username string) string {
+	t.Helper()
+
+	credentials := `{"username": "` + username + `", "password": "correct horse"}`
+	rec := sendAs(t, router, "", http.MethodPost, "/auth/register", credentials)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec = sendAs(t, router, "", http.MethodPost, "/auth/login", credentials)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.NotEmpty(t, body.Token)
+	return body.Token
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+
+	rec := sendAs(t, router, "", http.MethodPost, "/auth/register", `{"username": " alice ", "password": "correct horse"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"username":"alice"`)
+	assert.NotContains(t, rec.Body.String(), "password")
+
+	rec = sendAs(t, router, "", http.MethodPost, "/auth/register", `{"username": "alice", "password": "another one"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = sendAs(t, router, "", http.MethodPost, "/auth/register", `{"username": "bob", "password": "short"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = sendAs(t, router, "", http.MethodPost, "/auth/login", `{"username": "alice", "password": "wrong horse"}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = sendAs(t, router, "", http.MethodPost, "/auth/login", `{"username": "nobody", "password": "correct horse"}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = sendAs(t, router, "", http.MethodPost, "/auth/login", `{"username": "alice", "password": "correct horse"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var login struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &login))
+
+	rec = sendAs(t, router, login.Token, http.MethodGet, "/auth/me", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"username":"alice"`)
+	assert.Equal(t, http.StatusUnauthorized, sendAs(t, router, "bogus", http.MethodGet, "/todos", "").Code)
+
+	rec = sendAs(t, router, login.Token, http.MethodPost, "/auth/logout", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, http.StatusUnauthorized, sendAs(t, router, login.Token, http.MethodGet, "/auth/me", "").Code)
+}
+
+func TestRequestsWithoutCredentialsAreRejected(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	signUp(t, router, "alice")
+
+	for _, request := range []struct{ method, url, body string }{
+		{http.MethodGet, "/todos", ""},
+		{http.MethodGet, "/trash", ""},
+		{http.MethodPost, "/todos", `{"todos": [{"title": "Planted"}]}`},
+		{http.MethodPost, "/api-keys", `{"name": "ops", "scopes": ["admin"]}`},
+	} {
+		rec := sendAs(t, router, "", request.method, request.url, request.body)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, request.method+" "+request.url)
+	}
+}
+
+func TestUsersOnlySeeTheirOwnTodos(t *testing.T) {
+	for name, setup := range map[string]func(t *testing.T) *gin.Engine{
+		"sqlite": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithSQLite(t); return router },
+		"memory": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithMemory(t); return router },
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			alice, bob := signUp(t, router, "alice"), signUp(t, router, "bob")
+
+			rec := sendAs(t, router, alice, http.MethodPost, "/projects", `{"name": "Home"}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			var created struct {
+				Project models.Project `json:"project"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+			project := strconv.Itoa(int(created.Project.ID))
+
+			rec = sendAs(t, router, alice, http.MethodPost, "/todos", `{"todos": [{"title": "Water plants", "tags": ["chores"], "project_id": `+project+`}, {"title": "Pay rent"}]}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			var todos struct {
+				Todos []models.Todo `json:"todos"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &todos))
+			plants := "/todos/" + strconv.Itoa(int(todos.Todos[0].ID))
+			assert.NotZero(t, todos.Todos[0].OwnerID)
+
+			// Bob sees none of it and may reuse the names.
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, bob, http.MethodGet, plants, "").Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, bob, http.MethodPatch, plants, `{"complete": true}`).Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, bob, http.MethodDelete, plants, "").Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, bob, http.MethodGet, plants+"/history", "").Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, bob, http.MethodGet, "/projects/"+project, "").Code)
+			rec = sendAs(t, router, bob, http.MethodPost, "/todos", `{"todos": [{"title": "Sneak in", "project_id": `+project+`}]}`)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			rec = sendAs(t, router, bob, http.MethodPost, "/todos", `{"todos": [{"title": "Adopt", "parent_id": `+strconv.Itoa(int(todos.Todos[0].ID))+`}]}`)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+
+			rec = sendAs(t, router, bob, http.MethodPost, "/todos", `{"todos": [{"title": "Pay rent", "tags": ["chores"]}]}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			rec = sendAs(t, router, bob, http.MethodPost, "/projects", `{"name": "Home"}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+			rec = sendAs(t, router, bob, http.MethodGet, "/todos", "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"total":1`)
+			assert.NotContains(t, rec.Body.String(), "Water plants")
+
+			rec = sendAs(t, router, alice, http.MethodGet, "/tags", "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, 1, strings.Count(rec.Body.String(), `"name":"chores"`))
+
+			// The API key is not scoped to a user.
+			assert.Equal(t, []string{"Water plants", "Pay rent", "Pay rent"}, listTitles(t, router, "/todos"))
+
+			// Nobody can plant a todo in alice's list by naming her as the owner.
+			owner := strconv.Itoa(int(todos.Todos[0].OwnerID))
+			rec = sendAs(t, router, bob, http.MethodPost, "/todos", `{"todos": [{"title": "Planted by bob", "owner_id": `+owner+`}]}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			rec = sendWithAPIKey(t, router, helpers.TestAPIKey, http.MethodPost, "/todos", `{"todos": [{"title": "Planted by a key", "owner_id": `+owner+`}]}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			assert.NotContains(t, rec.Body.String(), `"owner_id"`)
+			rec = sendAs(t, router, alice, http.MethodGet, "/todos", "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.NotContains(t, rec.Body.String(), "Planted")
+
+			// Changes are recorded under the user's name.
+			rec = sendAs(t, router, alice, http.MethodGet, plants+"/history", "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"actor":"alice"`)
+		})
+	}
+}
+
+func TestIdempotencyKeysAreScopedToUsers(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	alice, bob := signUp(t, router, "alice"), signUp(t, router, "bob")
+
+	send := func(token string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/todos", strings.NewReader(`{"todos": [{"title": "Same key"}]}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Idempotency-Key", "shared-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send(alice)
+	require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+	second := send(bob)
+	require.Equal(t, http.StatusCreated, second.Code, second.Body.String())
+	assert.Empty(t, second.Header().Get("Idempotent-Replayed"))
+	assert.NotEqual(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", send(alice).Header().Get("Idempotent-Replayed"))
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	batchModeAtomic  = "atomic"
+	batchModePartial = "partial"
+)
+
+// batchResult reports the outcome of a single item in a partial batch.
+type batchResult struct {
+	Index  int    `json:"index"`
+	Status int    `json:"status"`
+	ID     uint   `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// runBatch applies fn to every todo of a batch request and writes the response.
+// fn receives the index of the item it should process in todos.
+//
+// In the default atomic mode all items share one transaction: the first failure
+// rolls back the whole batch and the response names the failing index. With
+// ?mode=partial every item commits on its own and the response carries one
+// batchResult per item, using 207 Multi-Status when some of them failed.
+func (h *TodoHandler) runBatch(c *gin.Context, todos []models.Todo, okStatus int, fn func(repo repository.TodoRepository, i int) error) {
+	ctx := c.Request.Context()
+
+	switch mode := c.DefaultQuery("mode", batchModeAtomic); mode {
+	case batchModeAtomic:
+		failed := -1
+		err := h.Todos.Transaction(ctx, func(repo repository.TodoRepository) error {
+			for i := range todos {
+				if err := fn(repo, i); err != nil {
+					failed = i
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error(), "index": failed})
+			return
+		}
+		c.JSON(okStatus, gin.H{"todos": todos})
+
+	case batchModePartial:
+		status := okStatus
+		results := make([]batchResult, len(todos))
+		for i := range todos {
+			err := h.Todos.Transaction(ctx, func(repo repository.TodoRepository) error {
+				return fn(repo, i)
+			})
+			results[i] = batchResult{Index: i, Status: okStatus, ID: todos[i].ID}
+			if err != nil {
+				status = http.StatusMultiStatus
+				results[i].Status = errorStatus(err)
+				results[i].Error = err.Error()
+			}
+		}
+		c.JSON(status, gin.H{"results": results})
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown batch mode %q", mode)})
+	}
+}
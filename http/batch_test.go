@@ -0,0 +1,105 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddTodosAtomicBatchRollsBack(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	helpers.SeedTodos(t, db, models.Todo{Title: "Existing"})
+
+	jsonPayload, err := json.Marshal(map[string][]models.Todo{
+		"todos": {{Title: "Fresh one"}, {Title: "Fresh two"}, {Title: "Existing"}},
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/todos", bytes.NewReader(jsonPayload))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	var body struct {
+		Index int `json:"index"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, 2, body.Index)
+
+	var count int64
+	db.Model(&models.Todo{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestAddTodosPartialBatchReportsEachItem(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	helpers.SeedTodos(t, db, models.Todo{Title: "Existing"})
+
+	jsonPayload, err := json.Marshal(map[string][]models.Todo{
+		"todos": {{Title: "Fresh one"}, {Title: "Existing"}, {Title: "Fresh two"}},
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/todos?mode=partial", bytes.NewReader(jsonPayload))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+
+	var body struct {
+		Results []struct {
+			Index  int    `json:"index"`
+			Status int    `json:"status"`
+			ID     uint   `json:"id"`
+			Error  string `json:"error"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Results, 3)
+	assert.Equal(t, http.StatusCreated, body.Results[0].Status)
+	assert.NotZero(t, body.Results[0].ID)
+	assert.Equal(t, http.StatusConflict, body.Results[1].Status)
+	assert.NotEmpty(t, body.Results[1].Error)
+	assert.Equal(t, http.StatusCreated, body.Results[2].Status)
+
+	var count int64
+	db.Model(&models.Todo{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestUpdateTodosAtomicBatchRejectsUnknownID(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Keep me"})
+
+	jsonPayload, err := json.Marshal(map[string][]models.Todo{
+		"todos": {{ID: seeded[0].ID, Title: "Renamed"}, {ID: seeded[0].ID + 100, Title: "Ghost"}},
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPatch, "/todos", bytes.NewReader(jsonPayload))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	var todo models.Todo
+	require.NoError(t, db.First(&todo, seeded[0].ID).Error)
+	assert.Equal(t, "Keep me", todo.Title)
+}
@@ -0,0 +1,228 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/collab"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	collabWriteTimeout = 10 * time.Second
+	collabPongTimeout  = 60 * time.Second
+	collabPingInterval = collabPongTimeout * 9 / 10
+	collabMaxMessage   = 1 << 20
+	// collabMaxWatched caps how many todos one connection may watch.
+	collabMaxWatched = 500
+)
+
+// Message types accepted from clients.
+const (
+	collabSubscribe   = "subscribe"
+	collabUnsubscribe = "unsubscribe"
+	collabUpdate      = "update"
+)
+
+// CollabHandler serves the collaboration WebSocket at GET /todos/ws.
+type CollabHandler struct {
+	Todos    repository.TodoRepository
+	Hub      *collab.Hub
+	upgrader websocket.Upgrader
+}
+
+func ProvideCollabHandler(todos repository.TodoRepository, hub *collab.Hub) *CollabHandler {
+	return &CollabHandler{Todos: todos, Hub: hub}
+}
+
+// collabRequest is a message sent by a client.
+type collabRequest struct {
+	Type      string `json:"type"`
+	RequestID string `json:"request_id"`
+	TodoIDs   []uint `json:"todo_ids"`
+	// ContentType selects how Todo is read for updates: a todo as for
+	// PATCH /todos (the default), or a merge patch or JSON patch item as for
+	// PATCH /todos with those content types.
+	ContentType string          `json:"content_type"`
+	Todo        json.RawMessage `json:"todo"`
+	Force       bool            `json:"force"`
+}
+
+// Collaborate godoc
+// @Summary      Collaborate on todos
+// @Description  Upgrades to a WebSocket. Clients send JSON messages of type subscribe or unsubscribe with todo_ids,
+// @Description  and update with a todo (plus content_type and force) that is saved like an item of PATCH /todos.
+// @Description  The server answers with subscribed, unsubscribed, updated or error messages carrying the request_id,
+// @Description  and pushes event messages for changes to watched todos and presence messages listing who views them.
+// @Description  Watchers that lose access to a todo get an unsubscribed message with its todo_id instead of its next event.
+// @Tags         todos
+// @Param        X-API-Key  header  string  true   "API key"
+// @Success      101
+// @Failure      400  {object}  map[string]string
+// @Router       /todos/ws [get]
+func (h *CollabHandler) Collaborate(c *gin.Context) {
+	// Viewers are shown as who they authenticated as, so nobody can pose as
+	// someone else.
+	principal, _ := CurrentPrincipal(c)
+	name := principal.Subject
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already answered the request.
+		return
+	}
+	defer conn.Close()
+
+	// Shares can be taken away while the connection is open, so access is
+	// checked again before every event instead of only on subscribe.
+	client := h.Hub.Join(name, repository.ReadableTodos(c.Request.Context(), h.Todos))
+	defer h.Hub.Leave(client)
+
+	go h.writeMessages(conn, client)
+
+	conn.SetReadLimit(collabMaxMessage)
+	conn.SetReadDeadline(time.Now().Add(collabPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(collabPongTimeout))
+	})
+	for {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var request collabRequest
+		if err := json.Unmarshal(payload, &request); err != nil {
+			h.Hub.Send(client, collab.Message{Type: collab.MessageError, Status: http.StatusBadRequest, Error: err.Error()})
+			continue
+		}
+		h.Hub.Send(client, h.handle(c, client, request))
+	}
+}
+
+// writeMessages writes the client's outbox to the connection and keeps it
+// alive with pings. It closes the connection once the outbox is closed.
+func (h *CollabHandler) writeMessages(conn *websocket.Conn, client *collab.Client) {
+	ticker := time.NewTicker(collabPingInterval)
+	defer ticker.Stop()
+	defer conn.Close()
+
+	for {
+		select {
+		case payload, ok := <-client.Outbox():
+			conn.SetWriteDeadline(time.Now().Add(collabWriteTimeout))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(collabWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handle answers one client message.
+func (h *CollabHandler) handle(c *gin.Context, client *collab.Client, request collabRequest) collab.Message {
+	reply := collab.Message{RequestID: request.RequestID}
+
+	var err error
+	switch request.Type {
+	case collabSubscribe:
+		reply.Type = collab.MessageSubscribed
+		reply.Todos, err = h.subscribe(c, client, request.TodoIDs)
+	case collabUnsubscribe:
+		reply.Type = collab.MessageUnsubscribed
+		h.Hub.Unwatch(client, request.TodoIDs)
+	case collabUpdate:
+		reply.Type = collab.MessageUpdated
+		if !allowed(c, models.ScopeTodosWrite) {
+			err = scopeError{models.ScopeTodosWrite}
+			break
+		}
+		reply.Todo, err = h.update(c, request)
+		if reply.Todo != nil {
+			reply.TodoID = reply.Todo.ID
+		}
+	default:
+		err = fmt.Errorf("unknown message type %q", request.Type)
+	}
+
+	if err != nil {
+		return collab.Message{Type: collab.MessageError, RequestID: request.RequestID, Status: errorStatus(err), Error: err.Error()}
+	}
+	return reply
+}
+
+// subscribe loads the requested todos and starts watching them. Nothing is
+// watched unless all of them exist.
+func (h *CollabHandler) subscribe(c *gin.Context, client *collab.Client, ids []uint) ([]models.Todo, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("todo_ids must not be empty")
+	}
+	if len(ids) > collabMaxWatched {
+		return nil, fmt.Errorf("at most %d todos can be watched at once", collabMaxWatched)
+	}
+
+	todos := make([]models.Todo, len(ids))
+	for i, id := range ids {
+		todo, err := h.Todos.FindByID(c.Request.Context(), id)
+		if err != nil {
+			return nil, err
+		}
+		todos[i] = *todo
+	}
+	h.Hub.Watch(client, ids)
+	return todos, nil
+}
+
+// update saves a todo the way one item of PATCH /todos is saved, including
+// its version check, and returns the stored todo.
+func (h *CollabHandler) update(c *gin.Context, request collabRequest) (*models.Todo, error) {
+	ctx := c.Request.Context()
+	if request.Force {
+		ctx = repository.AllowBlockedCompletion(ctx)
+	}
+
+	var saved *models.Todo
+	switch contentType := request.ContentType; {
+	case contentType == "" || contentType == "application/json":
+		var todo models.Todo
+		if err := json.Unmarshal(request.Todo, &todo); err != nil {
+			return nil, err
+		}
+		clearServerFields(&todo)
+		err := h.Todos.Transaction(ctx, func(repo repository.TodoRepository) error {
+			if err := repo.Update(ctx, &todo); err != nil {
+				return err
+			}
+			var err error
+			saved, err = repo.FindByID(ctx, todo.ID)
+			return err
+		})
+		return saved, err
+
+	case isPatchContentType(contentType):
+		id, version, patch, err := splitBatchPatch(contentType, request.Todo)
+		if err != nil {
+			return nil, err
+		}
+		err = h.Todos.Transaction(ctx, func(repo repository.TodoRepository) error {
+			saved, err = patchTodo(ctx, repo, id, version, patch)
+			return err
+		})
+		return saved, err
+
+	default:
+		return nil, fmt.Errorf("unsupported content_type %q", contentType)
+	}
+}
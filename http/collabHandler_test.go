@@ -0,0 +1,301 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/collab"
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collabConn is a collaboration connection that keeps the messages skipped
+// while waiting for another type, since replies and broadcasts interleave.
+type collabConn struct {
+	*websocket.Conn
+	skipped []collab.Message
+}
+
+// dialCollab opens the collaboration WebSocket as the token's user.
+func dialCollab(t *testing.T, server *httptest.Server, token string) *collabConn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/todos/ws"
+	conn, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer " + token}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	t.Cleanup(func() { conn.Close() })
+	return &collabConn{Conn: conn}
+}
+
+// createTodosAs creates todos for the token's user.
+func createTodosAs(t *testing.T, router *gin.Engine, token, body string) []models.Todo {
+	t.Helper()
+
+	rec := sendAs(t, router, token, http.MethodPost, "/todos", body)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	return created.Todos
+}
+
+// nextMessage reads messages until one of the given type arrives.
+func nextMessage(t *testing.T, conn *collabConn, messageType string) collab.Message {
+	t.Helper()
+
+	for i, message := range conn.skipped {
+		if message.Type == messageType {
+			conn.skipped = append(conn.skipped[:i], conn.skipped[i+1:]...)
+			return message
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var message collab.Message
+		require.NoError(t, conn.ReadJSON(&message), "waiting for %s", messageType)
+		if message.Type == messageType {
+			return message
+		}
+		conn.skipped = append(conn.skipped, message)
+	}
+}
+
+// nextPresence reads presence messages until todo is viewed by exactly viewers.
+func nextPresence(t *testing.T, conn *collabConn, todo uint, viewers ...string) {
+	t.Helper()
+
+	for {
+		message := nextMessage(t, conn, collab.MessagePresence)
+		if message.TodoID == todo && assert.ObjectsAreEqual(viewers, message.Viewers) {
+			return
+		}
+	}
+}
+
+func subscribe(t *testing.T, conn *collabConn, ids ...uint) []models.Todo {
+	t.Helper()
+
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "subscribe", "request_id": "sub", "todo_ids": ids}))
+	message := nextMessage(t, conn, collab.MessageSubscribed)
+	assert.Equal(t, "sub", message.RequestID)
+	return message.Todos
+}
+
+func TestCollaborationBroadcastsEditsAndPresence(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	aliceToken, bobToken := signUp(t, router, "alice"), signUp(t, router, "bob")
+	todos := createTodosAs(t, router, aliceToken, `{"todos": [{"title": "Draft"}, {"title": "Unwatched"}]}`)
+	id := todos[0].ID
+	share := "/todos/" + strconv.Itoa(int(id)) + "/shares/bob"
+	require.Equal(t, http.StatusOK, sendAs(t, router, aliceToken, http.MethodPut, share, `{"role": "editor"}`).Code)
+
+	alice := dialCollab(t, server, aliceToken)
+	snapshot := subscribe(t, alice, id)
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, "Draft", snapshot[0].Title)
+	nextPresence(t, alice, id, "alice")
+
+	bob := dialCollab(t, server, bobToken)
+	subscribe(t, bob, id)
+	nextPresence(t, alice, id, "alice", "bob")
+
+	require.NoError(t, bob.WriteJSON(map[string]any{
+		"type": "update", "request_id": "edit-1",
+		"todo": map[string]any{"id": id, "version": snapshot[0].Version, "title": "Final"},
+	}))
+	updated := nextMessage(t, bob, collab.MessageUpdated)
+	assert.Equal(t, "edit-1", updated.RequestID)
+	require.NotNil(t, updated.Todo)
+	assert.Equal(t, "Final", updated.Todo.Title)
+	assert.Equal(t, snapshot[0].Version+1, updated.Todo.Version)
+
+	event := nextMessage(t, alice, collab.MessageEvent)
+	require.NotNil(t, event.Event)
+	assert.Equal(t, models.EventTodoUpdated, event.Event.Type)
+	assert.Equal(t, "Final", event.Event.Todo.Title)
+
+	// Edits made over HTTP reach watchers too; unwatched todos do not.
+	rec := sendPatch(t, router, "/todos", "application/json", `{"todos": [{"id": `+strconv.Itoa(int(todos[1].ID))+`, "title": "Still unwatched"}]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = sendPatch(t, router, "/todos", "application/merge-patch+json", `{"todos": [{"id": `+strconv.Itoa(int(id))+`, "complete": true}]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	event = nextMessage(t, alice, collab.MessageEvent)
+	assert.Equal(t, models.EventTodoCompleted, event.Event.Type)
+	assert.Equal(t, id, event.TodoID)
+
+	bob.Close()
+	nextPresence(t, alice, id, "alice")
+}
+
+func TestCollaborationPresenceNamesComeFromCredentials(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	token := signUp(t, router, "bob")
+	id := createTodosAs(t, router, token, `{"todos": [{"title": "Draft"}]}`)[0].ID
+
+	// Asking for another name does not make bob show up as alice.
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/todos/ws?name=alice"
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer " + token}})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	bob := &collabConn{Conn: conn}
+	subscribe(t, bob, id)
+	nextPresence(t, bob, id, "bob")
+
+	// API keys show up under the name history records them with.
+	conn, _, err = websocket.DefaultDialer.Dial(url, http.Header{"X-API-Key": {helpers.TestAPIKey}})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	subscribe(t, &collabConn{Conn: conn}, id)
+	nextPresence(t, bob, id, "api-key", "bob")
+}
+
+func TestCollaborationStopsWhenTheShareIsRevoked(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	aliceToken, bobToken := signUp(t, router, "alice"), signUp(t, router, "bob")
+	id := createTodosAs(t, router, aliceToken, `{"todos": [{"title": "Draft"}]}`)[0].ID
+	todo := "/todos/" + strconv.Itoa(int(id))
+	require.Equal(t, http.StatusOK, sendAs(t, router, aliceToken, http.MethodPut, todo+"/shares/bob", `{"role": "viewer"}`).Code)
+
+	alice := dialCollab(t, server, aliceToken)
+	subscribe(t, alice, id)
+	bob := dialCollab(t, server, bobToken)
+	subscribe(t, bob, id)
+	nextPresence(t, alice, id, "alice", "bob")
+
+	require.Equal(t, http.StatusOK, sendAs(t, router, aliceToken, http.MethodPatch, todo, `{"title": "Shared"}`).Code)
+	assert.Equal(t, "Shared", nextMessage(t, bob, collab.MessageEvent).Event.Todo.Title)
+	assert.Equal(t, "Shared", nextMessage(t, alice, collab.MessageEvent).Event.Todo.Title)
+
+	// After the share is gone bob is unsubscribed instead of seeing edits.
+	require.Equal(t, http.StatusOK, sendAs(t, router, aliceToken, http.MethodDelete, todo+"/shares/bob", "").Code)
+	require.Equal(t, http.StatusOK, sendAs(t, router, aliceToken, http.MethodPatch, todo, `{"title": "Private"}`).Code)
+	unsubscribed := nextMessage(t, bob, collab.MessageUnsubscribed)
+	assert.Equal(t, id, unsubscribed.TodoID)
+	nextPresence(t, alice, id, "alice")
+	assert.Equal(t, "Private", nextMessage(t, alice, collab.MessageEvent).Event.Todo.Title)
+	for _, message := range bob.skipped {
+		assert.NotEqual(t, collab.MessageEvent, message.Type)
+	}
+}
+
+func TestCollaborationUpdatesAreValidated(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	token := signUp(t, router, "alice")
+	todos := createTodosAs(t, router, token, `{"todos": [{"title": "Draft"}, {"title": "Taken"}]}`)
+	id := todos[0].ID
+	conn := dialCollab(t, server, token)
+
+	for name, tc := range map[string]struct {
+		message map[string]any
+		status  int
+	}{
+		"stale version": {
+			message: map[string]any{"type": "update", "todo": map[string]any{"id": id, "version": todos[0].Version + 5, "title": "Lost"}},
+			status:  http.StatusConflict,
+		},
+		"duplicate title": {
+			message: map[string]any{"type": "update", "todo": map[string]any{"id": id, "title": "Taken"}},
+			status:  http.StatusConflict,
+		},
+		"failed patch test": {
+			message: map[string]any{"type": "update", "content_type": "application/json-patch+json", "todo": map[string]any{"id": id, "patch": []map[string]any{
+				{"op": "test", "path": "/title", "value": "Other"},
+				{"op": "replace", "path": "/title", "value": "Lost"},
+			}}},
+			status: http.StatusConflict,
+		},
+		"unknown todo": {
+			message: map[string]any{"type": "subscribe", "todo_ids": []uint{id, 9999}},
+			status:  http.StatusNotFound,
+		},
+		"unknown content type": {
+			message: map[string]any{"type": "update", "content_type": "text/plain", "todo": map[string]any{"id": id}},
+			status:  http.StatusBadRequest,
+		},
+		"unknown type": {
+			message: map[string]any{"type": "shout"},
+			status:  http.StatusBadRequest,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.message["request_id"] = name
+			require.NoError(t, conn.WriteJSON(tc.message))
+			reply := nextMessage(t, conn, collab.MessageError)
+			assert.Equal(t, name, reply.RequestID)
+			assert.Equal(t, tc.status, reply.Status, reply.Error)
+		})
+	}
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{not json")))
+	reply := nextMessage(t, conn, collab.MessageError)
+	assert.Equal(t, http.StatusBadRequest, reply.Status)
+
+	rec := serve(t, router, http.MethodGet, "/todos/"+strconv.Itoa(int(id)))
+	assert.Contains(t, rec.Body.String(), `"title":"Draft"`)
+
+	// A merge patch goes through once it is valid.
+	require.NoError(t, conn.WriteJSON(map[string]any{
+		"type": "update", "request_id": "ok", "content_type": "application/merge-patch+json",
+		"todo": map[string]any{"id": id, "version": todos[0].Version, "description": "Merged"},
+	}))
+	updated := nextMessage(t, conn, collab.MessageUpdated)
+	assert.Equal(t, "Merged", updated.Todo.Description)
+	assert.Equal(t, "Draft", updated.Todo.Title)
+}
+
+func TestCollaborationAcrossInstances(t *testing.T) {
+	db := helpers.OpenSQLite(t)
+	broker := collab.NewMemoryBroker()
+	router := helpers.SetupRouterWithCollab(t, db, broker)
+	first := httptest.NewServer(router)
+	t.Cleanup(first.Close)
+	second := httptest.NewServer(helpers.SetupRouterWithCollab(t, db, broker))
+	t.Cleanup(second.Close)
+
+	aliceToken, bobToken := signUp(t, router, "alice"), signUp(t, router, "bob")
+	shared := createTodoAs(t, router, aliceToken, `{"title": "Shared"}`)
+	require.Equal(t, http.StatusOK, sendAs(t, router, aliceToken, http.MethodPut, shared+"/shares/bob", `{"role": "editor"}`).Code)
+	id, err := strconv.Atoi(strings.TrimPrefix(shared, "/todos/"))
+	require.NoError(t, err)
+	conn := dialCollab(t, first, aliceToken)
+	todos := subscribe(t, conn, uint(id))
+	nextPresence(t, conn, todos[0].ID, "alice")
+
+	other := dialCollab(t, second, bobToken)
+	subscribe(t, other, todos[0].ID)
+	nextPresence(t, conn, todos[0].ID, "alice", "bob")
+	nextPresence(t, other, todos[0].ID, "alice", "bob")
+
+	require.NoError(t, other.WriteJSON(map[string]any{
+		"type": "update", "todo": map[string]any{"id": todos[0].ID, "title": "Shared edit"},
+	}))
+	event := nextMessage(t, conn, collab.MessageEvent)
+	assert.Equal(t, "Shared edit", event.Event.Todo.Title)
+	event = nextMessage(t, other, collab.MessageEvent)
+	assert.Equal(t, "Shared edit", event.Event.Todo.Title)
+	nextMessage(t, other, collab.MessageUpdated)
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/repository"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// ErrCursorFilters is returned for cursors that were issued for a listing
+// with other filters.
+var ErrCursorFilters = errors.New("cursor was issued for different filters")
+
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+// pageCursor is the decoded form of the opaque next_cursor/prev_cursor values.
+type pageCursor struct {
+	Direction string    `json:"d"`
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"i"`
+	// Filters is the filterHash of the listing the cursor was issued for.
+	Filters string `json:"f,omitempty"`
+}
+
+func (p pageCursor) position() *repository.TodoPosition {
+	return &repository.TodoPosition{CreatedAt: p.CreatedAt, ID: p.ID}
+}
+
+// CursorSigner encodes pagination cursors as base64 JSON followed by an
+// HMAC-SHA256 signature so clients cannot forge or tamper with positions.
+type CursorSigner struct {
+	secret []byte
+}
+
+func NewCursorSigner(secret []byte) *CursorSigner {
+	return &CursorSigner{secret: secret}
+}
+
+// ProvideCursorSigner reads the signing key from CURSOR_SECRET. Without it a
+// random key is generated, so cursors stop working when the server restarts.
+func ProvideCursorSigner() (*CursorSigner, error) {
+	if secret := os.Getenv("CURSOR_SECRET"); secret != "" {
+		return NewCursorSigner([]byte(secret)), nil
+	}
+
+	log.Println("warning: CURSOR_SECRET not set; pagination cursors will not survive a restart")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return NewCursorSigner(secret), nil
+}
+
+// EncodePosition returns a signed token for paging forwards from position.
+func (s *CursorSigner) EncodePosition(position repository.TodoPosition) string {
+	return s.encode(pageCursor{Direction: cursorNext, CreatedAt: position.CreatedAt, ID: position.ID})
+}
+
+// DecodePosition returns the position of a cursor made by this signer,
+// whichever direction it was made for.
+func (s *CursorSigner) DecodePosition(raw string) (repository.TodoPosition, error) {
+	cursor, err := s.decode(raw)
+	if err != nil {
+		return repository.TodoPosition{}, err
+	}
+	return *cursor.position(), nil
+}
+
+// EncodeListPosition returns a signed token for paging forwards from
+// position through the todos matching query.
+func (s *CursorSigner) EncodeListPosition(position repository.TodoPosition, query repository.TodoQuery) string {
+	return s.encode(pageCursor{Direction: cursorNext, CreatedAt: position.CreatedAt, ID: position.ID, Filters: filterHash(query)})
+}
+
+// DecodeListPosition returns the position of a token made by
+// EncodeListPosition, or ErrCursorFilters when it was made for other filters
+// than query's.
+func (s *CursorSigner) DecodeListPosition(raw string, query repository.TodoQuery) (repository.TodoPosition, error) {
+	cursor, err := s.decode(raw)
+	if err != nil {
+		return repository.TodoPosition{}, err
+	}
+	if cursor.Filters != filterHash(query) {
+		return repository.TodoPosition{}, ErrCursorFilters
+	}
+	return *cursor.position(), nil
+}
+
+// filterHash identifies the filters of query, so that a cursor only continues
+// the listing it was issued for.
+func filterHash(query repository.TodoQuery) string {
+	payload, _ := json.Marshal(query)
+	sum := sha256.Sum256(payload)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+func (s *CursorSigner) encode(cursor pageCursor) string {
+	payload, _ := json.Marshal(cursor)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded))
+}
+
+func (s *CursorSigner) decode(raw string) (pageCursor, error) {
+	var cursor pageCursor
+
+	encoded, signature, ok := strings.Cut(raw, ".")
+	if !ok {
+		return cursor, errInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(encoded)) {
+		return cursor, errInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return cursor, errInvalidCursor
+	}
+	if cursor.Direction != cursorNext && cursor.Direction != cursorPrev {
+		return cursor, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+func (s *CursorSigner) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,154 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cursorPage struct {
+	Todos      []models.Todo `json:"todos"`
+	Pagination struct {
+		Limit      int     `json:"limit"`
+		NextCursor *string `json:"next_cursor"`
+		PrevCursor *string `json:"prev_cursor"`
+	} `json:"pagination"`
+}
+
+func getCursorPage(t *testing.T, router *gin.Engine, cursor string) cursorPage {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, "/todos?limit=2&cursor="+url.QueryEscape(cursor), nil)
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var page cursorPage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	return page
+}
+
+func pageTitles(page cursorPage) []string {
+	titles := make([]string, 0, len(page.Todos))
+	for _, todo := range page.Todos {
+		titles = append(titles, todo.Title)
+	}
+	return titles
+}
+
+func TestGetTodosCursorPaginationIsStable(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	base := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	helpers.SeedTodos(t, db,
+		models.Todo{Title: "First", CreatedAt: base},
+		models.Todo{Title: "Second", CreatedAt: base.Add(time.Minute)},
+		models.Todo{Title: "Third", CreatedAt: base.Add(2 * time.Minute)},
+		models.Todo{Title: "Fourth", CreatedAt: base.Add(3 * time.Minute)},
+	)
+
+	first := getCursorPage(t, router, "")
+	assert.Equal(t, []string{"First", "Second"}, pageTitles(first))
+	assert.Nil(t, first.Pagination.PrevCursor)
+	require.NotNil(t, first.Pagination.NextCursor)
+
+	// A todo inserted between requests must neither shift nor repeat rows.
+	helpers.SeedTodos(t, db, models.Todo{Title: "Fifth", CreatedAt: base.Add(4 * time.Minute)})
+
+	second := getCursorPage(t, router, *first.Pagination.NextCursor)
+	assert.Equal(t, []string{"Third", "Fourth"}, pageTitles(second))
+	require.NotNil(t, second.Pagination.NextCursor)
+	require.NotNil(t, second.Pagination.PrevCursor)
+
+	third := getCursorPage(t, router, *second.Pagination.NextCursor)
+	assert.Equal(t, []string{"Fifth"}, pageTitles(third))
+	assert.Nil(t, third.Pagination.NextCursor)
+
+	back := getCursorPage(t, router, *second.Pagination.PrevCursor)
+	assert.Equal(t, []string{"First", "Second"}, pageTitles(back))
+	assert.Nil(t, back.Pagination.PrevCursor)
+	require.NotNil(t, back.Pagination.NextCursor)
+	assert.Equal(t, []string{"Third", "Fourth"}, pageTitles(getCursorPage(t, router, *back.Pagination.NextCursor)))
+}
+
+func TestGetTodosRejectsTamperedCursor(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	helpers.SeedTodos(t, db, models.Todo{Title: "One"}, models.Todo{Title: "Two"}, models.Todo{Title: "Three"})
+
+	next := getCursorPage(t, router, "").Pagination.NextCursor
+	require.NotNil(t, next)
+
+	tampered := []byte(*next)
+	tampered[0] ^= 1
+
+	for _, query := range []string{"cursor=" + url.QueryEscape(string(tampered)), "cursor=&sort=title"} {
+		req, err := http.NewRequest(http.MethodGet, "/todos?"+query, nil)
+		require.NoError(t, err)
+		helpers.WithAPIKey(req)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestGetTodosCursorKeepsItsFilters(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	helpers.SeedTodos(t, db, models.Todo{Title: "One"}, models.Todo{Title: "Two"}, models.Todo{Title: "Three"})
+
+	next := getCursorPage(t, router, "").Pagination.NextCursor
+	require.NotNil(t, next)
+
+	for query, status := range map[string]int{
+		"limit=1":                  http.StatusOK,
+		"complete=false":           http.StatusBadRequest,
+		"q=T":                      http.StatusBadRequest,
+		"tag=work&tag_match=all":   http.StatusBadRequest,
+		"created_after=2000-01-01": http.StatusBadRequest,
+	} {
+		req, err := http.NewRequest(http.MethodGet, "/todos?"+query+"&cursor="+url.QueryEscape(*next), nil)
+		require.NoError(t, err)
+		helpers.WithAPIKey(req)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, status, rec.Code, query)
+		if status == http.StatusBadRequest {
+			assert.Contains(t, rec.Body.String(), "different filters", query)
+		} else {
+			assert.NotContains(t, rec.Body.String(), `"total"`, query)
+		}
+	}
+}
+
+func TestGetTodosCapsTheLimit(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	todos := make([]models.Todo, 101)
+	for i := range todos {
+		todos[i].Title = "Todo " + strconv.Itoa(i)
+	}
+	helpers.SeedTodos(t, db, todos...)
+
+	for _, query := range []string{"limit=1000000&cursor=", "limit=1000000&page=1"} {
+		req, err := http.NewRequest(http.MethodGet, "/todos?"+query, nil)
+		require.NoError(t, err)
+		helpers.WithAPIKey(req)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, query)
+
+		var page cursorPage
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		assert.Len(t, page.Todos, 100, query)
+		assert.Equal(t, 100, page.Pagination.Limit, query)
+	}
+}
@@ -0,0 +1,169 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTodoDependencies godoc
+// @Summary      List the todos a todo is blocked by
+// @Tags         dependencies
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Todo ID"
+// @Success      200  {object}  map[string][]models.Todo
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /todos/{id}/dependencies [get]
+func (h *TodoHandler) GetTodoDependencies(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	blockers, err := repository.ListBlockers(c.Request.Context(), h.Todos, id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"todos": blockers})
+}
+
+// AddTodoDependency godoc
+// @Summary      Block a todo by another todo
+// @Description  The todo cannot be completed until the blocker is. Dependencies that would form a cycle are rejected.
+// @Tags         dependencies
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Todo ID"
+// @Param        request    body    object  true "Blocking todo, e.g. {\"blocker_id\": 2}"
+// @Success      201  {object}  map[string]models.Todo
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /todos/{id}/dependencies [post]
+func (h *TodoHandler) AddTodoDependency(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		BlockerID uint `json:"blocker_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.changeDependency(c, http.StatusCreated, id, func(repo repository.TodoRepository) error {
+		return repo.AddDependency(c.Request.Context(), id, request.BlockerID)
+	})
+}
+
+// RemoveTodoDependency godoc
+// @Summary      Stop a todo from waiting for another todo
+// @Tags         dependencies
+// @Produce      json
+// @Param        X-API-Key   header  string  true  "API key"
+// @Param        id          path    int     true "Todo ID"
+// @Param        blocker_id  path    int     true "Blocking todo ID"
+// @Success      200  {object}  map[string]models.Todo
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /todos/{id}/dependencies/{blocker_id} [delete]
+func (h *TodoHandler) RemoveTodoDependency(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	blockerID, err := strconv.ParseUint(c.Param("blocker_id"), 10, 64)
+	if err != nil || blockerID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "blocker_id must be a positive integer"})
+		return
+	}
+
+	h.changeDependency(c, http.StatusOK, id, func(repo repository.TodoRepository) error {
+		return repo.RemoveDependency(c.Request.Context(), id, uint(blockerID))
+	})
+}
+
+// changeDependency runs a dependency change and responds with the todo.
+func (h *TodoHandler) changeDependency(c *gin.Context, status int, id uint, change func(repo repository.TodoRepository) error) {
+	var todo *models.Todo
+	err := h.Todos.Transaction(c.Request.Context(), func(repo repository.TodoRepository) error {
+		if err := change(repo); err != nil {
+			return err
+		}
+		var err error
+		todo, err = repo.FindByID(c.Request.Context(), id)
+		return err
+	})
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(status, gin.H{"todo": todo})
+}
+
+// GetTodoOrder godoc
+// @Summary      List todos in dependency order
+// @Description  Returns every todo matching the filters of GET /todos, unpaginated, with each todo after the listed todos
+// @Description  that block it. The sort parameters decide the order among todos that are free to go.
+// @Tags         dependencies
+// @Produce      json
+// @Param        X-API-Key   header  string    true  "API key"
+// @Param        complete    query   bool      false "Only complete or incomplete todos"
+// @Param        project_id  query   int       false "Only todos of this project"
+// @Param        tag         query   []string  false "Tag names to filter by"  collectionFormat(multi)
+// @Param        sort        query   []string  false "Sort fields used as a tie-break"  collectionFormat(multi)
+// @Success      200  {object}  map[string][]models.Todo
+// @Failure      400  {object}  map[string]string
+// @Router       /todos/order [get]
+func (h *TodoHandler) GetTodoOrder(c *gin.Context) {
+	query, err := parseTodoFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.Limit = -1
+
+	todos, _, err := h.Todos.List(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ordered, err := repository.OrderByDependencies(todos)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"todos": ordered})
+}
+
+// parseForce reads the force parameter of the todo writes. When it is set,
+// todos may be completed while their blockers are still open. An invalid
+// value is answered with 400.
+func parseForce(c *gin.Context) bool {
+	raw, ok := c.GetQuery("force")
+	if !ok {
+		return true
+	}
+	force, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "force must be true or false"})
+		return false
+	}
+	if force {
+		c.Request = c.Request.WithContext(repository.AllowBlockedCompletion(c.Request.Context()))
+	}
+	return true
+}
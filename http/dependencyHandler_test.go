@@ -0,0 +1,65 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependenciesBlockCompletion(t *testing.T) {
+	for name, setup := range map[string]func(t *testing.T) *gin.Engine{
+		"sqlite": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithSQLite(t); return router },
+		"memory": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithMemory(t); return router },
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			todos := createTodos(t, router, `{"todos": [{"title": "Release"}, {"title": "Build"}, {"title": "Test"}]}`)
+			release, build, test := strconv.Itoa(int(todos[0].ID)), strconv.Itoa(int(todos[1].ID)), strconv.Itoa(int(todos[2].ID))
+
+			// Release waits for Test, which waits for Build.
+			rec := sendWithKey(t, router, http.MethodPost, "/todos/"+release+"/dependencies", "", `{"blocker_id": `+test+`}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			var body struct {
+				Todo models.Todo `json:"todo"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, []uint{todos[2].ID}, body.Todo.BlockedBy)
+			assert.True(t, body.Todo.Blocked)
+			rec = sendWithKey(t, router, http.MethodPost, "/todos/"+test+"/dependencies", "", `{"blocker_id": `+build+`}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+			for blocker, status := range map[string]int{release: http.StatusConflict, build: http.StatusConflict, "999": http.StatusNotFound} {
+				rec = sendWithKey(t, router, http.MethodPost, "/todos/"+build+"/dependencies", "", `{"blocker_id": `+blocker+`}`)
+				assert.Equal(t, status, rec.Code, blocker)
+			}
+
+			assert.Equal(t, []string{"Build", "Test", "Release"}, listTitles(t, router, "/todos/order"))
+			// Build is filtered out, so it no longer holds Test back.
+			assert.Equal(t, []string{"Test", "Release"}, listTitles(t, router, "/todos/order?sort=title&title=e"))
+			assert.Equal(t, []string{"Test"}, listTitles(t, router, "/todos/"+release+"/dependencies"))
+
+			rec = sendPatch(t, router, "/todos/"+test, "application/json", `{"complete": true}`)
+			assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+			rec = sendPatch(t, router, "/todos/"+test+"?force=true", "application/json", `{"complete": true}`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), `"warning"`)
+
+			rec = sendPatch(t, router, "/todos/"+build, "application/json", `{"complete": true}`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			rec = serve(t, router, http.MethodGet, "/todos/"+test)
+			assert.Contains(t, rec.Body.String(), `"blocked":false`)
+
+			rec = serve(t, router, http.MethodDelete, "/todos/"+release+"/dependencies/"+test)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.NotContains(t, rec.Body.String(), "blocked_by")
+			assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodDelete, "/todos/"+release+"/dependencies/"+test).Code)
+		})
+	}
+}
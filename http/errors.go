@@ -0,0 +1,36 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Xillon/golang-todo-api/policy"
+	"github.com/Xillon/golang-todo-api/repository"
+)
+
+// errorStatus maps repository and patch errors onto HTTP status codes. Anything the
+// repository does not recognise is reported as a bad request, matching the
+// handlers' historical behaviour.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrTodoNotFound), errors.Is(err, repository.ErrRevisionNotFound),
+		errors.Is(err, repository.ErrTagNotFound), errors.Is(err, repository.ErrProjectNotFound),
+		errors.Is(err, repository.ErrParentNotFound), errors.Is(err, repository.ErrBlockerNotFound),
+		errors.Is(err, repository.ErrDependencyNotFound), errors.Is(err, repository.ErrWebhookNotFound),
+		errors.Is(err, repository.ErrDeliveryNotFound), errors.Is(err, repository.ErrAPIKeyNotFound),
+		errors.Is(err, repository.ErrShareNotFound), errors.Is(err, repository.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrDuplicateTitle), errors.Is(err, repository.ErrDuplicateTag),
+		errors.Is(err, repository.ErrDuplicateProject), errors.Is(err, repository.ErrProjectNotEmpty),
+		errors.Is(err, repository.ErrParentCycle), errors.Is(err, repository.ErrHasSubtasks),
+		errors.Is(err, repository.ErrDependencyCycle), errors.Is(err, repository.ErrTodoBlocked),
+		errors.Is(err, repository.ErrVersionConflict), errors.Is(err, errPatchTestFailed),
+		errors.Is(err, repository.ErrAPIKeyInactive):
+		return http.StatusConflict
+	case errors.Is(err, errPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.As(err, new(scopeError)), errors.As(err, new(*policy.DeniedError)), errors.Is(err, policy.ErrNestingShared):
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
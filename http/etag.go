@@ -0,0 +1,47 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+var errPreconditionFailed = errors.New("If-Match does not match the current version of the todo")
+
+// etag formats a todo version as a strong entity tag.
+func etag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header value names
+// the given version, either directly or through "*".
+func etagMatches(header string, version uint) bool {
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == etag(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchedVersion enforces the If-Match header against the stored todo. It
+// returns the version the following write must be conditional on, or zero
+// when the request carried no If-Match header.
+func matchedVersion(c *gin.Context, repo repository.TodoRepository, id uint) (uint, error) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return 0, nil
+	}
+
+	current, err := repo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		return 0, err
+	}
+	if !etagMatches(header, current.Version) {
+		return 0, errPreconditionFailed
+	}
+	return current.Version, nil
+}
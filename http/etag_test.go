@@ -0,0 +1,102 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendIfMatch(t *testing.T, router *gin.Engine, method, url, ifMatch, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", ifMatch)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGetTodoByIDReturnsETag(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Tagged"})
+	url := "/todos/" + strconv.Itoa(int(seeded[0].ID))
+
+	rec := serve(t, router, http.MethodGet, url)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("If-None-Match", `"1"`)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	rec = sendPatch(t, router, url, "application/json", `{"complete": true}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+}
+
+func TestIfMatchRejectsStaleWrites(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Contended"})
+	url := "/todos/" + strconv.Itoa(int(seeded[0].ID))
+
+	rec := sendIfMatch(t, router, http.MethodPatch, url, `"1"`, `{"description": "first"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = sendIfMatch(t, router, http.MethodPatch, url, `"1"`, `{"description": "second"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	rec = sendIfMatch(t, router, http.MethodPut, url, `"1"`, `{"title": "Contended"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	rec = sendIfMatch(t, router, http.MethodDelete, url, `"1"`, "")
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	var todo models.Todo
+	require.NoError(t, db.First(&todo, seeded[0].ID).Error)
+	assert.Equal(t, "first", todo.Description)
+	assert.Equal(t, uint(2), todo.Version)
+
+	rec = sendIfMatch(t, router, http.MethodDelete, url, `"0", "2"`, "")
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestBatchPatchFailsItemsWithStaleVersion(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Fresh"}, models.Todo{Title: "Stale"})
+	require.NoError(t, db.Model(&seeded[1]).Update("version", 3).Error)
+
+	rec := sendPatch(t, router, "/todos?mode=partial", "application/merge-patch+json", `{"todos": [
+		{"id": `+strconv.Itoa(int(seeded[0].ID))+`, "version": 1, "complete": true},
+		{"id": `+strconv.Itoa(int(seeded[1].ID))+`, "version": 1, "complete": true}
+	]}`)
+	require.Equal(t, http.StatusMultiStatus, rec.Code, rec.Body.String())
+
+	var body struct {
+		Results []struct {
+			Status int `json:"status"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Results, 2)
+	assert.Equal(t, http.StatusOK, body.Results[0].Status)
+	assert.Equal(t, http.StatusConflict, body.Results[1].Status)
+
+	rec = sendPatch(t, router, "/todos", "application/json", `{"todos": [
+		{"id": `+strconv.Itoa(int(seeded[1].ID))+`, "version": 2, "description": "lost update"}
+	]}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
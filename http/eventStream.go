@@ -0,0 +1,153 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/events"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const eventHeartbeat = 15 * time.Second
+
+// EventStream serves todo events to the clients of GET /todos/events. Live
+// events come from the bus; events a client missed while disconnected are
+// replayed from the event log.
+type EventStream struct {
+	stream *events.Stream
+	todos  repository.TodoRepository
+}
+
+func ProvideEventStream(stream *events.Stream, todos repository.TodoRepository) *EventStream {
+	return &EventStream{stream: stream, todos: todos}
+}
+
+// Close ends every open stream and refuses new ones. It is meant to run when
+// the server shuts down, since streams would otherwise keep it waiting.
+func (s *EventStream) Close() {
+	s.stream.Close()
+}
+
+// StreamTodoEvents godoc
+// @Summary      Stream todo changes
+// @Description  Server-Sent Events stream of todo.created, todo.updated, todo.completed and todo.deleted events.
+// @Description  Each event carries its log id as the SSE id and the event JSON as data; a comment is sent every 15 seconds to keep the connection open.
+// @Description  Reconnecting with a Last-Event-ID header (or last_event_id parameter) first replays the logged events after that id.
+// @Tags         todos
+// @Produce      text/event-stream
+// @Param        X-API-Key      header  string    false  "API key"
+// @Param        Last-Event-ID  header  int       false  "Id of the last event received"
+// @Param        last_event_id  query   int       false  "Id of the last event received, for clients that cannot set headers"
+// @Param        project_id     query   int       false  "Only events of todos in this project"
+// @Param        tag            query   []string  false  "Only events of todos carrying one of these tags"  collectionFormat(multi)
+// @Param        tag_match      query   string    false  "Require any or all of the tags"  Enums(any, all)  default(any)
+// @Success      200  {string}  string  "event stream"
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /todos/events [get]
+func (s *EventStream) StreamTodoEvents(c *gin.Context) {
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Readable = repository.ReadableTodos(c.Request.Context(), s.todos)
+	lastID, resume, err := parseLastEventID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	followed, ok := s.stream.Follow(ctx, filter, lastID, resume)
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+		return
+	}
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, open := <-followed:
+			if !open {
+				return
+			}
+			writeTodoEvent(c, event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			io.WriteString(c.Writer, ": keep-alive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeTodoEvent(c *gin.Context, event models.TodoEvent) {
+	var id string
+	if event.ID != 0 {
+		id = strconv.FormatUint(uint64(event.ID), 10)
+	}
+	c.Render(-1, sse.Event{Id: id, Event: event.Type, Data: event})
+}
+
+// parseEventFilter reads the project_id, tag and tag_match parameters.
+func parseEventFilter(c *gin.Context) (events.Filter, error) {
+	var filter events.Filter
+	if raw, ok := c.GetQuery("project_id"); ok {
+		projectID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || projectID == 0 {
+			return filter, fmt.Errorf("project_id must be a positive integer")
+		}
+		id := uint(projectID)
+		filter.ProjectID = &id
+	}
+
+	for _, name := range c.QueryArray("tag") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(filter.Tags, name) {
+			filter.Tags = append(filter.Tags, name)
+		}
+	}
+	switch c.DefaultQuery("tag_match", "any") {
+	case "any":
+	case "all":
+		filter.AllTags = true
+	default:
+		return filter, fmt.Errorf("tag_match must be any or all")
+	}
+	return filter, nil
+}
+
+// parseLastEventID reads the id a client resumes from, and whether it asked
+// to resume at all; 0 replays the whole log. The header set by browsers on
+// reconnect wins over the query parameter.
+func parseLastEventID(c *gin.Context) (uint, bool, error) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw == "" {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("Last-Event-ID must be a non-negative integer")
+	}
+	return uint(id), true, nil
+}
@@ -0,0 +1,188 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamedEvent struct {
+	id    string
+	event string
+	data  models.TodoEvent
+}
+
+// openEventStream connects to GET /todos/events. The stream is subscribed to
+// live events once the response headers have arrived.
+func openEventStream(t *testing.T, server *httptest.Server, query, lastEventID string) *bufio.Scanner {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/todos/events"+query, nil)
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"))
+	return bufio.NewScanner(resp.Body)
+}
+
+// readEvents reads n events off a stream, skipping comments.
+func readEvents(t *testing.T, stream *bufio.Scanner, n int) []streamedEvent {
+	t.Helper()
+
+	var events []streamedEvent
+	var current streamedEvent
+	for len(events) < n && stream.Scan() {
+		line := stream.Text()
+		switch {
+		case line == "":
+			if current.event != "" {
+				events = append(events, current)
+			}
+			current = streamedEvent{}
+		case strings.HasPrefix(line, "id:"):
+			current.id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "event:"):
+			current.event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &current.data))
+		}
+	}
+	require.Len(t, events, n, "stream ended early: %v", stream.Err())
+	return events
+}
+
+func eventSummary(events []streamedEvent) []string {
+	var summary []string
+	for _, event := range events {
+		summary = append(summary, event.event+" "+event.data.Todo.Title)
+	}
+	return summary
+}
+
+func TestTodoEventStream(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	projectID := createProject(t, router, "Dashboard")
+
+	live := openEventStream(t, server, "?project_id="+projectID, "")
+	createTodos(t, router, `{"todos": [{"title": "Elsewhere"}, {"title": "Chart", "project_id": `+projectID+`}]}`)
+	todos := createTodos(t, router, `{"todos": [{"title": "Table", "project_id": `+projectID+`, "tags": [{"name": "urgent"}]}]}`)
+	id := strconv.Itoa(int(todos[0].ID))
+	rec := sendPatch(t, router, "/todos/"+id, "application/json", `{"complete": true}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, "/todos/"+id).Code)
+
+	events := readEvents(t, live, 4)
+	assert.Equal(t, []string{"todo.created Chart", "todo.created Table", "todo.completed Table", "todo.deleted Table"}, eventSummary(events))
+	for _, event := range events {
+		assert.Equal(t, strconv.Itoa(int(event.data.ID)), event.id)
+		assert.Equal(t, event.event, event.data.Type)
+	}
+
+	// A client reconnecting with Last-Event-ID gets what it missed from the
+	// log, then carries on with live events.
+	resumed := openEventStream(t, server, "?project_id="+projectID, events[1].id)
+	createTodos(t, router, `{"todos": [{"title": "Legend", "project_id": `+projectID+`}]}`)
+	assert.Equal(t, []string{"todo.completed Table", "todo.deleted Table", "todo.created Legend"}, eventSummary(readEvents(t, resumed, 3)))
+
+	// Zero replays the whole log; tags filter like they do on GET /todos.
+	tagged := openEventStream(t, server, "?tag=urgent&last_event_id=0", "")
+	assert.Equal(t, []string{"todo.created Table", "todo.completed Table", "todo.deleted Table"}, eventSummary(readEvents(t, tagged, 3)))
+}
+
+func TestTodoEventStreamValidation(t *testing.T) {
+	router, _ := helpers.SetupRouterWithMemory(t)
+
+	for _, url := range []string{"/todos/events?project_id=x", "/todos/events?tag_match=some", "/todos/events?last_event_id=-1"} {
+		assert.Equal(t, http.StatusBadRequest, serve(t, router, http.MethodGet, url).Code, url)
+	}
+}
+
+// openEventStreamAs connects to GET /todos/events as the token's user.
+func openEventStreamAs(t *testing.T, server *httptest.Server, token, query string) *bufio.Scanner {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/todos/events"+query, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	return bufio.NewScanner(resp.Body)
+}
+
+func TestTodoEventStreamFollowsSharedTodos(t *testing.T) {
+	for name, setup := range shareBackends() {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			server := httptest.NewServer(router)
+			t.Cleanup(server.Close)
+			alice, bob, carol := signUp(t, router, "alice"), signUp(t, router, "bob"), signUp(t, router, "carol")
+
+			project := createProjectAs(t, router, alice, "Home")
+			createTodoAs(t, router, alice, `{"title": "Private"}`)
+			plants := createTodoAs(t, router, alice, `{"title": "Water plants"}`)
+			createTodoAs(t, router, alice, `{"title": "Sweep", "project_id": `+project+`}`)
+			require.Equal(t, http.StatusOK, sendAs(t, router, alice, http.MethodPut, plants+"/shares/bob", `{"role": "viewer"}`).Code)
+			require.Equal(t, http.StatusOK, sendAs(t, router, alice, http.MethodPut, "/projects/"+project+"/shares/bob", `{"role": "viewer"}`).Code)
+
+			// Bob follows the todos shared with him, directly or through the
+			// project, but not alice's other todos.
+			stream := openEventStreamAs(t, server, bob, "?last_event_id=0")
+			assert.Equal(t, []string{"todo.created Water plants", "todo.created Sweep"}, eventSummary(readEvents(t, stream, 2)))
+			require.Equal(t, http.StatusOK, sendAs(t, router, alice, http.MethodPatch, plants, `{"complete": true}`).Code)
+			assert.Equal(t, []string{"todo.completed Water plants"}, eventSummary(readEvents(t, stream, 1)))
+
+			// Carol only follows her own.
+			createTodoAs(t, router, carol, `{"title": "Carol's own"}`)
+			stream = openEventStreamAs(t, server, carol, "?last_event_id=0")
+			assert.Equal(t, []string{"todo.created Carol's own"}, eventSummary(readEvents(t, stream, 1)))
+
+			// GraphQL subscriptions apply the same check.
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			t.Cleanup(cancel)
+			body := `{"query": "subscription { todoEvents(after: \"0\") { type todo { title } } }"}`
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/graphql", strings.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+bob)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "text/event-stream")
+			resp, err := server.Client().Do(req)
+			require.NoError(t, err)
+			t.Cleanup(func() { resp.Body.Close() })
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			subscription := bufio.NewScanner(resp.Body)
+			for subscription.Scan() {
+				if data, ok := strings.CutPrefix(subscription.Text(), "data:"); ok {
+					assert.JSONEq(t, `{"data": {"todoEvents": {"type": "todo.created", "todo": {"title": "Water plants"}}}}`, data)
+					break
+				}
+			}
+			require.NoError(t, subscription.Err())
+		})
+	}
+}
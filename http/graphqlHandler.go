@@ -0,0 +1,124 @@
+package http
+
+import (
+	_ "embed"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/events"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var graphqlSchema string
+
+// graphqlMaxDepth bounds how deeply queries may nest related todos.
+const graphqlMaxDepth = 12
+
+// GraphQLHandler serves /graphql.
+type GraphQLHandler struct {
+	schema *graphql.Schema
+}
+
+func ProvideGraphQLHandler(todos repository.TodoRepository, cursors *CursorSigner, stream *events.Stream) (*GraphQLHandler, error) {
+	resolver := &graphqlResolver{todos: todos, cursors: cursors, stream: stream}
+	schema, err := graphql.ParseSchema(graphqlSchema, resolver, graphql.UseStringDescriptions(), graphql.MaxDepth(graphqlMaxDepth))
+	if err != nil {
+		return nil, err
+	}
+	return &GraphQLHandler{schema: schema}, nil
+}
+
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// ServeGraphQL godoc
+// @Summary      GraphQL endpoint
+// @Description  Runs a GraphQL query, mutation or subscription over todos, tags and projects; see http/schema.graphql.
+// @Description  Send a JSON body with query, operationName and variables; GET takes them as parameters but cannot run mutations.
+// @Description  Subscriptions, and any operation requested with Accept: text/event-stream, are answered as Server-Sent Events:
+// @Description  a next event per result followed by a complete event.
+// @Tags         graphql
+// @Accept       json
+// @Produce      json,text/event-stream
+// @Param        X-API-Key      header  string  true   "API key"
+// @Param        request        body    object  false  "query, operationName and variables"
+// @Param        query          query   string  false  "Query, for GET requests"
+// @Param        operationName  query   string  false  "Operation to run, for GET requests"
+// @Param        variables      query   string  false  "JSON object of variables, for GET requests"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Router       /graphql [get]
+// @Router       /graphql [post]
+func (h *GraphQLHandler) ServeGraphQL(c *gin.Context) {
+	var request graphqlRequest
+	ctx := c.Request.Context()
+	if c.Request.Method == http.MethodGet {
+		request.Query = c.Query("query")
+		request.OperationName = c.Query("operationName")
+		if raw := c.Query("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &request.Variables); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "variables must be a JSON object"})
+				return
+			}
+		}
+		ctx = withReadOnly(ctx, errMutationOverGet)
+	} else if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if !allowed(c, models.ScopeTodosWrite) {
+		ctx = withReadOnly(ctx, scopeError{models.ScopeTodosWrite})
+	}
+	if strings.TrimSpace(request.Query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+
+	if !strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		c.JSON(http.StatusOK, h.schema.Exec(ctx, request.Query, request.OperationName, request.Variables))
+		return
+	}
+
+	responses, err := h.schema.Subscribe(ctx, request.Query, request.OperationName, request.Variables)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case response, open := <-responses:
+			if !open {
+				c.Render(-1, sse.Event{Event: "complete", Data: ""})
+				c.Writer.Flush()
+				return
+			}
+			c.Render(-1, sse.Event{Event: "next", Data: response})
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			io.WriteString(c.Writer, ": keep-alive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
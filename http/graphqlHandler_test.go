@@ -0,0 +1,288 @@
+package http_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// runGraphQL posts an operation to /graphql and decodes its data into out.
+func runGraphQL(t *testing.T, router *gin.Engine, query string, variables map[string]any, out any) graphqlResponse {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response graphqlResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	if out != nil && len(response.Data) > 0 && string(response.Data) != "null" {
+		require.NoError(t, json.Unmarshal(response.Data, out), string(response.Data))
+	}
+	return response
+}
+
+type graphqlTodo struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Version  int     `json:"version"`
+	Complete bool    `json:"complete"`
+	DueDate  *string `json:"dueDate"`
+	Project  *struct {
+		Name string `json:"name"`
+	} `json:"project"`
+	Tags []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+	Subtasks []struct {
+		Title string `json:"title"`
+	} `json:"subtasks"`
+	BlockedBy []struct {
+		Title string `json:"title"`
+	} `json:"blockedBy"`
+}
+
+type graphqlConnection struct {
+	Nodes    []graphqlTodo `json:"nodes"`
+	PageInfo struct {
+		HasNextPage     bool   `json:"hasNextPage"`
+		HasPreviousPage bool   `json:"hasPreviousPage"`
+		StartCursor     string `json:"startCursor"`
+		EndCursor       string `json:"endCursor"`
+	} `json:"pageInfo"`
+	TotalCount int `json:"totalCount"`
+}
+
+func connectionTitles(connection graphqlConnection) []string {
+	var titles []string
+	for _, todo := range connection.Nodes {
+		titles = append(titles, todo.Title)
+	}
+	return titles
+}
+
+func TestGraphQLQueriesTodosWithRelatedData(t *testing.T) {
+	for name, setup := range map[string]func(t *testing.T) *gin.Engine{
+		"sqlite": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithSQLite(t); return router },
+		"memory": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithMemory(t); return router },
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			projectID := createProject(t, router, "Launch")
+
+			var created struct {
+				AddTodos []graphqlTodo `json:"addTodos"`
+			}
+			response := runGraphQL(t, router, `mutation($todos: [NewTodo!]!) { addTodos(todos: $todos) { id title } }`, map[string]any{
+				"todos": []map[string]any{
+					{"title": "Plan", "projectId": projectID, "tags": []string{"urgent"}},
+					{"title": "Build", "projectId": projectID},
+					{"title": "Ship", "projectId": projectID, "tags": []string{"urgent", "release"}},
+					{"title": "Elsewhere"},
+				},
+			}, &created)
+			require.Empty(t, response.Errors)
+			require.Len(t, created.AddTodos, 4)
+			plan, build, ship := created.AddTodos[0].ID, created.AddTodos[1].ID, created.AddTodos[2].ID
+
+			rec := sendWithKey(t, router, http.MethodPost, "/todos/"+ship+"/dependencies", "", `{"blocker_id": `+build+`}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			rec = sendPatch(t, router, "/todos", "application/json", `{"todos": [{"id": `+build+`, "parent_id": `+plan+`}]}`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var result struct {
+				Todos graphqlConnection `json:"todos"`
+				Todo  graphqlTodo       `json:"todo"`
+			}
+			response = runGraphQL(t, router, `query($project: ID!, $ship: ID!) {
+				todos(filter: {projectId: $project, tags: ["urgent"]}) {
+					nodes { title project { name } tags { name } subtasks { title } }
+					totalCount
+				}
+				todo(id: $ship) { title blockedBy { title } }
+			}`, map[string]any{"project": projectID, "ship": ship}, &result)
+			require.Empty(t, response.Errors)
+
+			assert.Equal(t, []string{"Plan", "Ship"}, connectionTitles(result.Todos))
+			assert.Equal(t, 2, result.Todos.TotalCount)
+			assert.Equal(t, "Launch", result.Todos.Nodes[0].Project.Name)
+			require.Len(t, result.Todos.Nodes[0].Subtasks, 1)
+			assert.Equal(t, "Build", result.Todos.Nodes[0].Subtasks[0].Title)
+			assert.Len(t, result.Todos.Nodes[1].Tags, 2)
+			require.Len(t, result.Todo.BlockedBy, 1)
+			assert.Equal(t, "Build", result.Todo.BlockedBy[0].Title)
+
+			var missing struct {
+				Todo *graphqlTodo `json:"todo"`
+			}
+			response = runGraphQL(t, router, `{ todo(id: "999") { title } }`, nil, &missing)
+			assert.Empty(t, response.Errors)
+			assert.Nil(t, missing.Todo)
+		})
+	}
+}
+
+func TestGraphQLPaginatesTodoConnections(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	for _, title := range []string{"One", "Two", "Three", "Four", "Five"} {
+		createTodos(t, router, `{"todos": [{"title": "`+title+`"}]}`)
+	}
+
+	const query = `query($first: Int, $after: String, $last: Int, $before: String) {
+		todos(first: $first, after: $after, last: $last, before: $before) {
+			nodes { title }
+			pageInfo { hasNextPage hasPreviousPage startCursor endCursor }
+			totalCount
+		}
+	}`
+	page := func(variables map[string]any) graphqlConnection {
+		t.Helper()
+		var result struct {
+			Todos graphqlConnection `json:"todos"`
+		}
+		response := runGraphQL(t, router, query, variables, &result)
+		require.Empty(t, response.Errors)
+		return result.Todos
+	}
+
+	first := page(map[string]any{"first": 2})
+	assert.Equal(t, []string{"One", "Two"}, connectionTitles(first))
+	assert.True(t, first.PageInfo.HasNextPage)
+	assert.False(t, first.PageInfo.HasPreviousPage)
+	assert.Equal(t, 5, first.TotalCount)
+
+	second := page(map[string]any{"first": 2, "after": first.PageInfo.EndCursor})
+	assert.Equal(t, []string{"Three", "Four"}, connectionTitles(second))
+	assert.True(t, second.PageInfo.HasPreviousPage)
+
+	back := page(map[string]any{"last": 2, "before": second.PageInfo.StartCursor})
+	assert.Equal(t, []string{"One", "Two"}, connectionTitles(back))
+	assert.False(t, back.PageInfo.HasPreviousPage)
+	assert.True(t, back.PageInfo.HasNextPage)
+
+	last := page(map[string]any{"last": 2})
+	assert.Equal(t, []string{"Four", "Five"}, connectionTitles(last))
+	assert.True(t, last.PageInfo.HasPreviousPage)
+
+	for _, variables := range []map[string]any{{"first": 1, "last": 1}, {"first": 101}, {"after": "forged"}} {
+		response := runGraphQL(t, router, query, variables, nil)
+		assert.NotEmpty(t, response.Errors, "%v", variables)
+	}
+}
+
+func TestGraphQLMutationsShareRESTValidation(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	todos := createTodos(t, router, `{"todos": [{"title": "Draft", "due_date": "2030-01-01T00:00:00Z"}, {"title": "Taken"}]}`)
+	id := strconv.Itoa(int(todos[0].ID))
+
+	const update = `mutation($todos: [TodoChanges!]!) { updateTodos(todos: $todos) { id title complete dueDate version } }`
+	var result struct {
+		UpdateTodos []graphqlTodo `json:"updateTodos"`
+	}
+	response := runGraphQL(t, router, update, map[string]any{
+		"todos": []map[string]any{{"id": id, "version": todos[0].Version, "complete": true, "dueDate": nil}},
+	}, &result)
+	require.Empty(t, response.Errors)
+	require.Len(t, result.UpdateTodos, 1)
+	assert.True(t, result.UpdateTodos[0].Complete)
+	assert.Nil(t, result.UpdateTodos[0].DueDate, "null clears the due date")
+	assert.Equal(t, "Draft", result.UpdateTodos[0].Title, "fields left out stay")
+
+	// The batch is atomic: the stale second item keeps the first from applying.
+	response = runGraphQL(t, router, update, map[string]any{
+		"todos": []map[string]any{
+			{"id": id, "complete": false},
+			{"id": id, "version": todos[0].Version, "title": "Lost"},
+		},
+	}, nil)
+	require.Len(t, response.Errors, 1)
+	assert.EqualValues(t, http.StatusConflict, response.Errors[0].Extensions["status"])
+	assert.EqualValues(t, 1, response.Errors[0].Extensions["index"])
+	rec := serve(t, router, http.MethodGet, "/todos/"+id)
+	assert.Contains(t, rec.Body.String(), `"complete":true`)
+
+	response = runGraphQL(t, router, update, map[string]any{"todos": []map[string]any{{"id": id, "title": "Taken"}}}, nil)
+	require.Len(t, response.Errors, 1)
+	assert.EqualValues(t, http.StatusConflict, response.Errors[0].Extensions["status"])
+
+	var deleted struct {
+		DeleteTodo string `json:"deleteTodo"`
+	}
+	response = runGraphQL(t, router, `mutation($id: ID!) { deleteTodo(id: $id) }`, map[string]any{"id": id}, &deleted)
+	require.Empty(t, response.Errors)
+	assert.Equal(t, id, deleted.DeleteTodo)
+	response = runGraphQL(t, router, `mutation($id: ID!) { deleteTodo(id: $id) }`, map[string]any{"id": id}, nil)
+	require.Len(t, response.Errors, 1)
+	assert.EqualValues(t, http.StatusNotFound, response.Errors[0].Extensions["status"])
+
+	// Mutations cannot be smuggled into GET requests.
+	rec = serve(t, router, http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { addTodos(todos: [{title: "Sneaky"}]) { id } }`))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "mutations must be sent with POST")
+	rec = serve(t, router, http.MethodGet, "/graphql?query="+url.QueryEscape(`{ todos { totalCount } }`))
+	assert.JSONEq(t, `{"data": {"todos": {"totalCount": 1}}}`, rec.Body.String())
+}
+
+func TestGraphQLSubscriptionStreamsTodoEvents(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	t.Cleanup(cancel)
+	body := `{"query": "subscription { todoEvents(tags: [\"urgent\"]) { type todo { title tags { name } } } }"}`
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/graphql", strings.NewReader(body))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"))
+
+	createTodos(t, router, `{"todos": [{"title": "Quiet"}, {"title": "Loud", "tags": ["urgent"]}]}`)
+
+	stream := bufio.NewScanner(resp.Body)
+	var event, data string
+	for stream.Scan() {
+		line := stream.Text()
+		if value, ok := strings.CutPrefix(line, "event:"); ok {
+			event = strings.TrimSpace(value)
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data = value
+			break
+		}
+	}
+	require.NoError(t, stream.Err())
+	assert.Equal(t, "next", event)
+	assert.JSONEq(t, `{"data": {"todoEvents": {"type": "todo.created", "todo": {"title": "Loud", "tags": [{"name": "urgent"}]}}}}`, data)
+}
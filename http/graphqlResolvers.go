@@ -0,0 +1,894 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/events"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const defaultConnectionSize = 10
+
+var errMutationOverGet = errors.New("mutations must be sent with POST")
+
+// graphqlResolver is the root of the GraphQL schema. Queries and mutations go
+// through the same repository as the REST handlers, so they share validation,
+// version checks, history and events.
+type graphqlResolver struct {
+	todos   repository.TodoRepository
+	cursors *CursorSigner
+	stream  *events.Stream
+}
+
+// graphqlError carries the HTTP status the REST API would have answered with,
+// and the position of the failing item in a batch, as error extensions.
+type graphqlError struct {
+	err   error
+	index *int
+}
+
+func (e graphqlError) Error() string {
+	return e.err.Error()
+}
+
+func (e graphqlError) Unwrap() error {
+	return e.err
+}
+
+func (e graphqlError) Extensions() map[string]any {
+	extensions := map[string]any{"status": errorStatus(e.err)}
+	if e.index != nil {
+		extensions["index"] = *e.index
+	}
+	return extensions
+}
+
+func resolverError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return graphqlError{err: err}
+}
+
+type readOnlyKey struct{}
+
+// withReadOnly marks GraphQL requests that must not change anything, such as
+// those that arrived over GET, with the error mutations fail with.
+func withReadOnly(ctx context.Context, reason error) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, reason)
+}
+
+// readOnly returns why mutations are refused, or nil if they are allowed.
+func readOnly(ctx context.Context) error {
+	reason, _ := ctx.Value(readOnlyKey{}).(error)
+	return reason
+}
+
+func parseGraphQLID(id graphql.ID) (uint, error) {
+	parsed, err := strconv.ParseUint(string(id), 10, 64)
+	if err != nil || parsed == 0 {
+		return 0, graphqlError{err: fmt.Errorf("id %q must be a positive integer", id)}
+	}
+	return uint(parsed), nil
+}
+
+func parseOptionalID(id *graphql.ID) (*uint, error) {
+	if id == nil {
+		return nil, nil
+	}
+	parsed, err := parseGraphQLID(*id)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func formatID(id uint) graphql.ID {
+	return graphql.ID(strconv.FormatUint(uint64(id), 10))
+}
+
+func optionalTime(t *graphql.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
+}
+
+func graphqlTime(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// Queries
+
+func (r *graphqlResolver) Todo(ctx context.Context, args struct{ ID graphql.ID }) (*todoResolver, error) {
+	id, err := parseGraphQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	todo, err := r.todos.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrTodoNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	return r.todo(*todo), nil
+}
+
+type todoFilterInput struct {
+	Complete      *bool
+	DueBefore     *graphql.Time
+	DueAfter      *graphql.Time
+	RemindBefore  *graphql.Time
+	CreatedBefore *graphql.Time
+	CreatedAfter  *graphql.Time
+	UpdatedBefore *graphql.Time
+	UpdatedAfter  *graphql.Time
+	Search        *string
+	Title         *string
+	Description   *string
+	Tags          *[]string
+	AllTags       *bool
+	ProjectID     *graphql.ID
+	ParentID      *graphql.ID
+}
+
+// query turns the filter into the TodoQuery GET /todos would build from the
+// matching parameters.
+func (f *todoFilterInput) query() (repository.TodoQuery, error) {
+	var query repository.TodoQuery
+	if f == nil {
+		return query, nil
+	}
+
+	query.Complete = f.Complete
+	query.DueBefore = optionalTime(f.DueBefore)
+	query.DueAfter = optionalTime(f.DueAfter)
+	query.RemindBefore = optionalTime(f.RemindBefore)
+	query.CreatedBefore = optionalTime(f.CreatedBefore)
+	query.CreatedAfter = optionalTime(f.CreatedAfter)
+	query.UpdatedBefore = optionalTime(f.UpdatedBefore)
+	query.UpdatedAfter = optionalTime(f.UpdatedAfter)
+	query.Search = stringValue(f.Search)
+	query.Title = stringValue(f.Title)
+	query.Description = stringValue(f.Description)
+	if f.Tags != nil {
+		for _, name := range *f.Tags {
+			if name = strings.TrimSpace(name); name != "" {
+				query.Tags = append(query.Tags, name)
+			}
+		}
+	}
+	query.AllTags = f.AllTags != nil && *f.AllTags
+
+	var err error
+	if query.ProjectID, err = parseOptionalID(f.ProjectID); err != nil {
+		return query, err
+	}
+	if query.ParentID, err = parseOptionalID(f.ParentID); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
+type connectionArgs struct {
+	First  *int32
+	After  *string
+	Last   *int32
+	Before *string
+}
+
+type todoConnectionArgs struct {
+	Filter *todoFilterInput
+	connectionArgs
+}
+
+func (r *graphqlResolver) Todos(ctx context.Context, args todoConnectionArgs) (*todoConnectionResolver, error) {
+	query, err := args.Filter.query()
+	if err != nil {
+		return nil, err
+	}
+	return r.connection(ctx, query, args.connectionArgs)
+}
+
+func (r *graphqlResolver) Tag(ctx context.Context, args struct{ ID graphql.ID }) (*tagResolver, error) {
+	id, err := parseGraphQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := r.todos.FindTag(ctx, id)
+	if errors.Is(err, repository.ErrTagNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	return &tagResolver{root: r, tag: *tag}, nil
+}
+
+func (r *graphqlResolver) Tags(ctx context.Context) ([]*tagResolver, error) {
+	tags, err := r.todos.ListTags(ctx)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	resolvers := make([]*tagResolver, len(tags))
+	for i, tag := range tags {
+		resolvers[i] = &tagResolver{root: r, tag: tag}
+	}
+	return resolvers, nil
+}
+
+func (r *graphqlResolver) Project(ctx context.Context, args struct{ ID graphql.ID }) (*projectResolver, error) {
+	id, err := parseGraphQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	project, err := r.todos.FindProject(ctx, id)
+	if errors.Is(err, repository.ErrProjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	return &projectResolver{root: r, project: *project}, nil
+}
+
+func (r *graphqlResolver) Projects(ctx context.Context) ([]*projectResolver, error) {
+	projects, err := r.todos.ListProjects(ctx)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	resolvers := make([]*projectResolver, len(projects))
+	for i, project := range projects {
+		resolvers[i] = &projectResolver{root: r, project: project}
+	}
+	return resolvers, nil
+}
+
+// connection pages through the todos matching query in creation order, with
+// the same signed cursors as GET /todos?cursor=.
+func (r *graphqlResolver) connection(ctx context.Context, query repository.TodoQuery, args connectionArgs) (*todoConnectionResolver, error) {
+	if args.First != nil && args.Last != nil {
+		return nil, graphqlError{err: errors.New("first and last cannot be combined")}
+	}
+	size, backwards := args.First, false
+	if args.Last != nil {
+		size, backwards = args.Last, true
+	}
+	limit := defaultConnectionSize
+	if size != nil {
+		limit = int(*size)
+	}
+	if limit < 0 || limit > repository.MaxPageSize {
+		return nil, graphqlError{err: fmt.Errorf("first and last must be between 0 and %d", repository.MaxPageSize)}
+	}
+
+	raw := args.After
+	if backwards {
+		raw = args.Before
+	}
+	var position *repository.TodoPosition
+	if raw != nil {
+		decoded, err := r.cursors.DecodePosition(*raw)
+		if err != nil {
+			return nil, graphqlError{err: err}
+		}
+		position = &decoded
+	}
+
+	todos, more, total, err := repository.ListPage(ctx, r.todos, query, position, backwards, limit)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+
+	connection := &todoConnectionResolver{root: r, todos: todos, total: total}
+	if backwards {
+		connection.hasPrevious, connection.hasNext = more, position != nil
+	} else {
+		connection.hasNext, connection.hasPrevious = more, position != nil
+	}
+	return connection, nil
+}
+
+// Mutations
+
+type newTodoInput struct {
+	Title       string
+	Description *string
+	DueDate     *graphql.Time
+	RemindAt    *graphql.Time
+	Complete    *bool
+	ProjectID   *graphql.ID
+	ParentID    *graphql.ID
+	Tags        *[]string
+	Recurrence  *string
+	Timezone    *string
+}
+
+func (in newTodoInput) todo() (models.Todo, error) {
+	todo := models.Todo{
+		Title:       in.Title,
+		Description: stringValue(in.Description),
+		DueDate:     optionalTime(in.DueDate),
+		RemindAt:    optionalTime(in.RemindAt),
+		Complete:    in.Complete != nil && *in.Complete,
+		Recurrence:  stringValue(in.Recurrence),
+		Timezone:    stringValue(in.Timezone),
+	}
+	if in.Tags != nil {
+		todo.Tags = make([]models.Tag, len(*in.Tags))
+		for i, name := range *in.Tags {
+			todo.Tags[i] = models.Tag{Name: name}
+		}
+	}
+
+	var err error
+	if todo.ProjectID, err = parseOptionalID(in.ProjectID); err != nil {
+		return todo, err
+	}
+	todo.ParentID, err = parseOptionalID(in.ParentID)
+	return todo, err
+}
+
+func (r *graphqlResolver) AddTodos(ctx context.Context, args struct{ Todos []newTodoInput }) ([]*todoResolver, error) {
+	if err := readOnly(ctx); err != nil {
+		return nil, graphqlError{err: err}
+	}
+
+	todos := make([]models.Todo, len(args.Todos))
+	for i, in := range args.Todos {
+		todo, err := in.todo()
+		if err != nil {
+			return nil, graphqlError{err: err, index: &i}
+		}
+		todos[i] = todo
+	}
+
+	err := r.batch(ctx, len(todos), func(repo repository.TodoRepository, i int) error {
+		return repo.Create(ctx, &todos[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.todoList(todos), nil
+}
+
+type todoChangesInput struct {
+	ID          graphql.ID
+	Version     *int32
+	Title       graphql.NullString
+	Description graphql.NullString
+	DueDate     graphql.NullTime
+	RemindAt    graphql.NullTime
+	Complete    graphql.NullBool
+	ProjectID   graphql.NullID
+	ParentID    graphql.NullID
+	Tags        *[]string
+	Recurrence  graphql.NullString
+	Timezone    graphql.NullString
+}
+
+// patch turns the changes into the merge patch PATCH /todos would receive.
+func (in todoChangesInput) patch() (uint, uint, todoPatch, error) {
+	id, err := parseGraphQLID(in.ID)
+	if err != nil {
+		return 0, 0, todoPatch{}, err
+	}
+	var version uint
+	if in.Version != nil {
+		version = uint(*in.Version)
+	}
+
+	document := map[string]any{}
+	for name, value := range map[string]graphql.NullString{"title": in.Title, "description": in.Description, "recurrence": in.Recurrence, "timezone": in.Timezone} {
+		if value.Set {
+			document[name] = value.Value
+		}
+	}
+	for name, value := range map[string]graphql.NullTime{"due_date": in.DueDate, "remind_at": in.RemindAt} {
+		if value.Set {
+			document[name] = optionalTime(value.Value)
+		}
+	}
+	if in.Complete.Set {
+		document["complete"] = in.Complete.Value
+	}
+	for name, value := range map[string]graphql.NullID{"project_id": in.ProjectID, "parent_id": in.ParentID} {
+		if !value.Set {
+			continue
+		}
+		if document[name], err = parseOptionalID(value.Value); err != nil {
+			return 0, 0, todoPatch{}, err
+		}
+	}
+	if in.Tags != nil {
+		document["tags"] = *in.Tags
+	}
+
+	raw, err := json.Marshal(document)
+	if err != nil {
+		return 0, 0, todoPatch{}, err
+	}
+	return id, version, todoPatch{contentType: mergePatchContentType, document: raw}, nil
+}
+
+func (r *graphqlResolver) UpdateTodos(ctx context.Context, args struct {
+	Todos []todoChangesInput
+	Force bool
+}) ([]*todoResolver, error) {
+	if err := readOnly(ctx); err != nil {
+		return nil, graphqlError{err: err}
+	}
+	if args.Force {
+		ctx = repository.AllowBlockedCompletion(ctx)
+	}
+
+	ids := make([]uint, len(args.Todos))
+	versions := make([]uint, len(args.Todos))
+	patches := make([]todoPatch, len(args.Todos))
+	for i, in := range args.Todos {
+		var err error
+		if ids[i], versions[i], patches[i], err = in.patch(); err != nil {
+			return nil, graphqlError{err: err, index: &i}
+		}
+	}
+
+	todos := make([]models.Todo, len(args.Todos))
+	err := r.batch(ctx, len(todos), func(repo repository.TodoRepository, i int) error {
+		saved, err := patchTodo(ctx, repo, ids[i], versions[i], patches[i])
+		if err != nil {
+			return err
+		}
+		todos[i] = *saved
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.todoList(todos), nil
+}
+
+func (r *graphqlResolver) DeleteTodo(ctx context.Context, args struct {
+	ID       graphql.ID
+	Version  *int32
+	Children string
+}) (graphql.ID, error) {
+	if err := readOnly(ctx); err != nil {
+		return "", graphqlError{err: err}
+	}
+	id, err := parseGraphQLID(args.ID)
+	if err != nil {
+		return "", err
+	}
+	var version uint
+	if args.Version != nil {
+		version = uint(*args.Version)
+	}
+	policy := repository.ChildPolicy(strings.ToLower(args.Children))
+
+	err = r.todos.Transaction(ctx, func(repo repository.TodoRepository) error {
+		return repository.DeleteTodo(ctx, repo, id, version, policy)
+	})
+	if err != nil {
+		return "", resolverError(err)
+	}
+	return args.ID, nil
+}
+
+// batch runs fn for every item of a mutation in one transaction, like the
+// atomic mode of the REST batch endpoints.
+func (r *graphqlResolver) batch(ctx context.Context, n int, fn func(repo repository.TodoRepository, i int) error) error {
+	failed := -1
+	err := r.todos.Transaction(ctx, func(repo repository.TodoRepository) error {
+		for i := range n {
+			if err := fn(repo, i); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return graphqlError{err: err, index: &failed}
+	}
+	return nil
+}
+
+// Subscriptions
+
+func (r *graphqlResolver) TodoEvents(ctx context.Context, args struct {
+	ProjectID *graphql.ID
+	Tags      *[]string
+	AllTags   bool
+	After     *graphql.ID
+}) (<-chan *todoEventResolver, error) {
+	filter := events.Filter{Readable: repository.ReadableTodos(ctx, r.todos)}
+	var err error
+	if filter.ProjectID, err = parseOptionalID(args.ProjectID); err != nil {
+		return nil, err
+	}
+	if args.Tags != nil {
+		filter.Tags = *args.Tags
+	}
+	filter.AllTags = args.AllTags
+
+	var lastID uint
+	if args.After != nil {
+		// Unlike the other ids, 0 is allowed: it replays the whole log.
+		after, err := strconv.ParseUint(string(*args.After), 10, 64)
+		if err != nil {
+			return nil, graphqlError{err: errors.New("after must be an event id")}
+		}
+		lastID = uint(after)
+	}
+
+	followed, ok := r.stream.Follow(ctx, filter, lastID, args.After != nil)
+	if !ok {
+		return nil, errors.New("server is shutting down")
+	}
+	resolvers := make(chan *todoEventResolver)
+	go func() {
+		defer close(resolvers)
+		for event := range followed {
+			select {
+			case resolvers <- &todoEventResolver{root: r, event: event}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return resolvers, nil
+}
+
+// Types
+
+func (r *graphqlResolver) todo(todo models.Todo) *todoResolver {
+	return &todoResolver{root: r, todo: todo}
+}
+
+func (r *graphqlResolver) todoList(todos []models.Todo) []*todoResolver {
+	resolvers := make([]*todoResolver, len(todos))
+	for i, todo := range todos {
+		resolvers[i] = r.todo(todo)
+	}
+	return resolvers
+}
+
+type todoResolver struct {
+	root *graphqlResolver
+	todo models.Todo
+}
+
+func (t *todoResolver) ID() graphql.ID {
+	return formatID(t.todo.ID)
+}
+
+func (t *todoResolver) Title() string {
+	return t.todo.Title
+}
+
+func (t *todoResolver) Description() string {
+	return t.todo.Description
+}
+
+func (t *todoResolver) DueDate() *graphql.Time {
+	return graphqlTime(t.todo.DueDate)
+}
+
+func (t *todoResolver) RemindAt() *graphql.Time {
+	return graphqlTime(t.todo.RemindAt)
+}
+
+func (t *todoResolver) Complete() bool {
+	return t.todo.Complete
+}
+
+func (t *todoResolver) Project(ctx context.Context) (*projectResolver, error) {
+	if t.todo.ProjectID == nil {
+		return nil, nil
+	}
+	project, err := t.root.todos.FindProject(ctx, *t.todo.ProjectID)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	return &projectResolver{root: t.root, project: *project}, nil
+}
+
+func (t *todoResolver) Parent(ctx context.Context) (*todoResolver, error) {
+	if t.todo.ParentID == nil {
+		return nil, nil
+	}
+	parent, err := t.root.todos.FindByID(ctx, *t.todo.ParentID)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	return t.root.todo(*parent), nil
+}
+
+func (t *todoResolver) Subtasks(ctx context.Context) ([]*todoResolver, error) {
+	children, _, err := t.root.todos.List(ctx, repository.TodoQuery{ParentID: &t.todo.ID, Limit: -1})
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	return t.root.todoList(children), nil
+}
+
+func (t *todoResolver) Tags() []*tagResolver {
+	resolvers := make([]*tagResolver, len(t.todo.Tags))
+	for i, tag := range t.todo.Tags {
+		resolvers[i] = &tagResolver{root: t.root, tag: tag}
+	}
+	return resolvers
+}
+
+func (t *todoResolver) Recurrence() *string {
+	return optionalString(t.todo.Recurrence)
+}
+
+func (t *todoResolver) Timezone() *string {
+	return optionalString(t.todo.Timezone)
+}
+
+func (t *todoResolver) SeriesID() *graphql.ID {
+	if t.todo.SeriesID == nil {
+		return nil
+	}
+	id := formatID(*t.todo.SeriesID)
+	return &id
+}
+
+func (t *todoResolver) Blocked() bool {
+	return t.todo.Blocked
+}
+
+func (t *todoResolver) BlockedBy(ctx context.Context) ([]*todoResolver, error) {
+	blockers := make([]*todoResolver, 0, len(t.todo.BlockedBy))
+	for _, id := range t.todo.BlockedBy {
+		blocker, err := t.root.todos.FindByID(ctx, id)
+		if err != nil {
+			return nil, resolverError(err)
+		}
+		blockers = append(blockers, t.root.todo(*blocker))
+	}
+	return blockers, nil
+}
+
+func (t *todoResolver) Version() int32 {
+	return int32(t.todo.Version)
+}
+
+func (t *todoResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: t.todo.CreatedAt}
+}
+
+func (t *todoResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: t.todo.UpdatedAt}
+}
+
+func (t *todoResolver) History(ctx context.Context) ([]*revisionResolver, error) {
+	revisions, err := t.root.todos.ListRevisions(ctx, t.todo.ID)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	resolvers := make([]*revisionResolver, len(revisions))
+	for i, revision := range revisions {
+		resolvers[i] = &revisionResolver{revision: revision}
+	}
+	return resolvers, nil
+}
+
+type todoConnectionResolver struct {
+	root        *graphqlResolver
+	todos       []models.Todo
+	total       int64
+	hasNext     bool
+	hasPrevious bool
+}
+
+func (c *todoConnectionResolver) cursor(todo models.Todo) string {
+	return c.root.cursors.EncodePosition(repository.TodoPosition{CreatedAt: todo.CreatedAt, ID: todo.ID})
+}
+
+func (c *todoConnectionResolver) Edges() []*todoEdgeResolver {
+	edges := make([]*todoEdgeResolver, len(c.todos))
+	for i, todo := range c.todos {
+		edges[i] = &todoEdgeResolver{cursor: c.cursor(todo), node: c.root.todo(todo)}
+	}
+	return edges
+}
+
+func (c *todoConnectionResolver) Nodes() []*todoResolver {
+	return c.root.todoList(c.todos)
+}
+
+func (c *todoConnectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{hasNext: c.hasNext, hasPrevious: c.hasPrevious}
+	if len(c.todos) > 0 {
+		start, end := c.cursor(c.todos[0]), c.cursor(c.todos[len(c.todos)-1])
+		info.start, info.end = &start, &end
+	}
+	return info
+}
+
+func (c *todoConnectionResolver) TotalCount() int32 {
+	return int32(c.total)
+}
+
+type todoEdgeResolver struct {
+	cursor string
+	node   *todoResolver
+}
+
+func (e *todoEdgeResolver) Cursor() string {
+	return e.cursor
+}
+
+func (e *todoEdgeResolver) Node() *todoResolver {
+	return e.node
+}
+
+type pageInfoResolver struct {
+	hasNext     bool
+	hasPrevious bool
+	start       *string
+	end         *string
+}
+
+func (p *pageInfoResolver) HasNextPage() bool {
+	return p.hasNext
+}
+
+func (p *pageInfoResolver) HasPreviousPage() bool {
+	return p.hasPrevious
+}
+
+func (p *pageInfoResolver) StartCursor() *string {
+	return p.start
+}
+
+func (p *pageInfoResolver) EndCursor() *string {
+	return p.end
+}
+
+type tagResolver struct {
+	root *graphqlResolver
+	tag  models.Tag
+}
+
+func (t *tagResolver) ID() graphql.ID {
+	return formatID(t.tag.ID)
+}
+
+func (t *tagResolver) Name() string {
+	return t.tag.Name
+}
+
+func (t *tagResolver) Colour() *string {
+	return optionalString(t.tag.Colour)
+}
+
+func (t *tagResolver) Todos(ctx context.Context, args connectionArgs) (*todoConnectionResolver, error) {
+	return t.root.connection(ctx, repository.TodoQuery{Tags: []string{t.tag.Name}}, args)
+}
+
+type projectResolver struct {
+	root    *graphqlResolver
+	project models.Project
+}
+
+func (p *projectResolver) ID() graphql.ID {
+	return formatID(p.project.ID)
+}
+
+func (p *projectResolver) Name() string {
+	return p.project.Name
+}
+
+func (p *projectResolver) Description() string {
+	return p.project.Description
+}
+
+func (p *projectResolver) Todos(ctx context.Context, args todoConnectionArgs) (*todoConnectionResolver, error) {
+	query, err := args.Filter.query()
+	if err != nil {
+		return nil, err
+	}
+	query.ProjectID = &p.project.ID
+	return p.root.connection(ctx, query, args.connectionArgs)
+}
+
+type revisionResolver struct {
+	revision models.TodoRevision
+}
+
+func (r *revisionResolver) Revision() int32 {
+	return int32(r.revision.Revision)
+}
+
+func (r *revisionResolver) Action() string {
+	return r.revision.Action
+}
+
+func (r *revisionResolver) Actor() string {
+	return r.revision.Actor
+}
+
+func (r *revisionResolver) RevertedTo() *int32 {
+	if r.revision.RevertedTo == nil {
+		return nil
+	}
+	revertedTo := int32(*r.revision.RevertedTo)
+	return &revertedTo
+}
+
+func (r *revisionResolver) Changes() jsonScalar {
+	return jsonScalar{value: r.revision.Changes}
+}
+
+func (r *revisionResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.revision.CreatedAt}
+}
+
+type todoEventResolver struct {
+	root  *graphqlResolver
+	event models.TodoEvent
+}
+
+func (e *todoEventResolver) ID() graphql.ID {
+	return formatID(e.event.ID)
+}
+
+func (e *todoEventResolver) Type() string {
+	return e.event.Type
+}
+
+func (e *todoEventResolver) OccurredAt() graphql.Time {
+	return graphql.Time{Time: e.event.OccurredAt}
+}
+
+func (e *todoEventResolver) Todo() *todoResolver {
+	return e.root.todo(e.event.Todo)
+}
+
+// jsonScalar is the JSON scalar of the schema. It is only ever returned.
+type jsonScalar struct {
+	value any
+}
+
+func (jsonScalar) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (j *jsonScalar) UnmarshalGraphQL(input any) error {
+	j.value = input
+	return nil
+}
+
+func (j jsonScalar) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.value)
+}
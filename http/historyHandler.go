@@ -0,0 +1,75 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTodoHistory godoc
+// @Summary      List the revisions of a todo
+// @Description  Returns every recorded change of the todo, oldest first, with the actor, a field-level diff and a snapshot.
+// @Description  History stays available after the todo is trashed or purged.
+// @Tags         history
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Todo ID"
+// @Success      200  {object}  map[string][]models.TodoRevision
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /todos/{id}/history [get]
+func (h *TodoHandler) GetTodoHistory(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.Todos.ListRevisions(c.Request.Context(), id)
+	if err != nil && !errors.Is(err, repository.ErrTodoNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(revisions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": repository.ErrTodoNotFound.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// RevertTodo godoc
+// @Summary      Revert a todo to an earlier revision
+// @Description  Restores the editable fields to their state after the given revision. The revert is recorded as a new revision.
+// @Tags         history
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Todo ID"
+// @Param        revision   query   int     true "Revision number"
+// @Success      200  {object}  map[string]models.Todo
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /todos/{id}/revert [post]
+func (h *TodoHandler) RevertTodo(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	revision, err := strconv.ParseUint(c.Query("revision"), 10, 64)
+	if err != nil || revision == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "revision must be a positive integer"})
+		return
+	}
+
+	todo, err := repository.RevertTodo(c.Request.Context(), h.Todos, id, uint(revision))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"todo": todo})
+}
@@ -0,0 +1,76 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoHistoryRecordsEveryChangeAndReverts(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+
+	req, err := http.NewRequest(http.MethodPost, "/todos", bytes.NewReader([]byte(`{"todos": [{"title": "Draft", "description": "v1"}]}`)))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var created struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	url := "/todos/" + strconv.Itoa(int(created.Todos[0].ID))
+
+	require.Equal(t, http.StatusOK, sendPatch(t, router, url, "application/json", `{"title": "Final", "complete": true}`).Code)
+	require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, url).Code)
+	require.Equal(t, http.StatusOK, serve(t, router, http.MethodPost, url+"/restore").Code)
+
+	rec = serve(t, router, http.MethodPost, url+"/revert?revision=1")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var reverted struct {
+		Todo models.Todo `json:"todo"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reverted))
+	assert.Equal(t, "Draft", reverted.Todo.Title)
+	assert.False(t, reverted.Todo.Complete)
+
+	rec = serve(t, router, http.MethodGet, url+"/history")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var history struct {
+		Revisions []models.TodoRevision `json:"revisions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &history))
+	require.Len(t, history.Revisions, 5)
+
+	actions := make([]string, 0, len(history.Revisions))
+	for i, revision := range history.Revisions {
+		assert.Equal(t, uint(i+1), revision.Revision)
+		assert.Equal(t, "api-key", revision.Actor)
+		actions = append(actions, revision.Action)
+	}
+	assert.Equal(t, []string{"create", "update", "delete", "restore", "revert"}, actions)
+	assert.Equal(t, models.FieldChange{From: "Draft", To: "Final"}, history.Revisions[1].Changes["title"])
+	assert.Equal(t, models.FieldChange{From: true, To: false}, history.Revisions[4].Changes["complete"])
+	require.NotNil(t, history.Revisions[4].RevertedTo)
+	assert.Equal(t, uint(1), *history.Revisions[4].RevertedTo)
+}
+
+func TestRevertTodoValidatesRevision(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "No history"})
+	url := "/todos/" + strconv.Itoa(int(seeded[0].ID))
+
+	assert.Equal(t, http.StatusBadRequest, serve(t, router, http.MethodPost, url+"/revert").Code)
+	assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodPost, url+"/revert?revision=3").Code)
+	assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodGet, url+"/history").Code)
+}
@@ -0,0 +1,199 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotentReplayHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored with an idempotency key and
+// sent again when the response is replayed.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Idempotency makes mutating requests safe to retry. The first request sent
+// with a given Idempotency-Key is handled normally and its response stored for
+// the TTL; retries with the same method, URL and body get that response back
+// without running the handler again.
+type Idempotency struct {
+	store repository.IdempotencyStore
+	ttl   time.Duration
+}
+
+func NewIdempotency(store repository.IdempotencyStore, ttl time.Duration) *Idempotency {
+	return &Idempotency{store: store, ttl: ttl}
+}
+
+// ProvideIdempotency reads the key lifetime from IDEMPOTENCY_TTL_HOURS,
+// defaulting to 24 hours.
+func ProvideIdempotency(store repository.IdempotencyStore) *Idempotency {
+	hours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = 24
+	}
+	return NewIdempotency(store, time.Duration(hours)*time.Hour)
+}
+
+// Middleware applies the Idempotency-Key handling to POST, PUT, PATCH and
+// DELETE requests. Requests without the header pass straight through.
+func (i *Idempotency) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key = scopedIdempotencyKey(c, key)
+		now := time.Now()
+		reserved := &models.IdempotencyKey{
+			Key:         key,
+			Fingerprint: requestFingerprint(c.Request, body),
+			ExpiresAt:   now.Add(i.ttl),
+		}
+		existing, err := i.store.Reserve(c.Request.Context(), reserved)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if existing != nil {
+			replayIdempotent(c, existing, reserved.Fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		// Server errors and panics are not remembered so that a retry gets a
+		// fresh attempt.
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				i.release(c, key)
+				panic(recovered)
+			}
+		}()
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			i.release(c, key)
+			return
+		}
+
+		reserved.StatusCode = recorder.Status()
+		reserved.Header = map[string][]string{}
+		for _, name := range replayedHeaders {
+			if values := recorder.Header().Values(name); len(values) > 0 {
+				reserved.Header[name] = values
+			}
+		}
+		reserved.Body = recorder.body.Bytes()
+		if err := i.store.Complete(c.Request.Context(), reserved); err != nil {
+			log.Printf("failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// release frees a reserved key whose response is not stored.
+func (i *Idempotency) release(c *gin.Context, key string) {
+	if err := i.store.Release(c.Request.Context(), key); err != nil {
+		log.Printf("failed to release idempotency key: %v", err)
+	}
+}
+
+// scopedIdempotencyKey keeps the keys of different principals apart, so that
+// neither a user nor an API key can replay a response sent to someone else.
+// The key is hashed to stay within the stored length.
+func scopedIdempotencyKey(c *gin.Context, key string) string {
+	scope := "anonymous"
+	if principal, ok := CurrentPrincipal(c); ok {
+		switch {
+		case principal.User != nil:
+			scope = fmt.Sprintf("user:%d", principal.User.ID)
+		case principal.APIKey != nil:
+			scope = fmt.Sprintf("api-key:%d", principal.APIKey.ID)
+		default:
+			scope = principal.Subject
+		}
+	}
+	sum := sha256.Sum256([]byte(key))
+	return scope + ":" + hex.EncodeToString(sum[:])
+}
+
+// replayIdempotent answers a request whose key was already used.
+func replayIdempotent(c *gin.Context, existing *models.IdempotencyKey, fingerprint string) {
+	switch {
+	case existing.Fingerprint != fingerprint:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+	case !existing.Completed():
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still being processed"})
+	default:
+		for name, values := range existing.Header {
+			for _, value := range values {
+				c.Writer.Header().Add(name, value)
+			}
+		}
+		c.Header(idempotentReplayHeader, "true")
+		c.Writer.WriteHeader(existing.StatusCode)
+		c.Writer.Write(existing.Body)
+		c.Abort()
+	}
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestFingerprint identifies a request by its method, URL and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder copies everything written to the response into a buffer.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,143 @@
+package http_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	todohttp "github.com/Xillon/golang-todo-api/http"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendWithKey(t *testing.T, router *gin.Engine, method, url, key, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyKeyReplaysFirstResponse(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	body := `{"todos": [{"title": "Only once"}]}`
+
+	first := sendWithKey(t, router, http.MethodPost, "/todos", "retry-1", body)
+	require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	retry := sendWithKey(t, router, http.MethodPost, "/todos", "retry-1", body)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	assert.JSONEq(t, first.Body.String(), retry.Body.String())
+
+	var count int64
+	require.NoError(t, db.Model(&models.Todo{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// A new key is a new request, which now hits the duplicate title.
+	rec := sendWithKey(t, router, http.MethodPost, "/todos", "retry-2", body)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestIdempotencyKeyRejectsDifferentRequest(t *testing.T) {
+	router, _ := helpers.SetupRouterWithMemory(t)
+
+	rec := sendWithKey(t, router, http.MethodPost, "/todos", "reused", `{"todos": [{"title": "First"}]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = sendWithKey(t, router, http.MethodPost, "/todos", "reused", `{"todos": [{"title": "Second"}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	rec = sendWithKey(t, router, http.MethodPatch, "/todos", "reused", `{"todos": [{"title": "First"}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestIdempotencyKeyIgnoredOnReads(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	helpers.SeedTodos(t, db, models.Todo{Title: "Listed"})
+
+	rec := sendWithKey(t, router, http.MethodGet, "/todos", "read", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+
+	var count int64
+	require.NoError(t, db.Model(&models.IdempotencyKey{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestIdempotencyKeysAreScopedToThePrincipal(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	admin := issueAPIKey(t, router, helpers.TestAPIKey, `{"name": "ops", "scopes": ["admin"]}`).Key
+	writer := issueAPIKey(t, router, admin, `{"name": "importer", "scopes": ["todos:read", "todos:write"]}`).Key
+	body := `{"todos": [{"title": "Imported"}]}`
+
+	send := func(key string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/todos", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("X-API-Key", key)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "shared")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Another key with the same Idempotency-Key runs its own request, which
+	// here hits the duplicate title instead of replaying the first response.
+	require.Equal(t, http.StatusCreated, send(writer).Code)
+	rec := send(helpers.TestAPIKey)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "true", send(writer).Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotencyKeyDoesNotStoreAPIKeySecrets(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	body := `{"name": "reporting", "scopes": ["todos:read"]}`
+
+	first := sendWithKey(t, router, http.MethodPost, "/api-keys", "issue", body)
+	require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+	retry := sendWithKey(t, router, http.MethodPost, "/api-keys", "issue", body)
+	require.Equal(t, http.StatusCreated, retry.Code, retry.Body.String())
+	assert.Empty(t, retry.Header().Get("Idempotent-Replayed"))
+	assert.NotEqual(t, first.Body.String(), retry.Body.String())
+
+	var count int64
+	require.NoError(t, db.Model(&models.IdempotencyKey{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestIdempotencyKeyIsReleasedWhenTheHandlerPanics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard))
+	calls := 0
+	router.POST("/flaky", todohttp.NewIdempotency(repository.NewMemoryIdempotencyStore(), time.Hour).Middleware(), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("lost the database")
+		}
+		c.JSON(http.StatusCreated, gin.H{"calls": calls})
+	})
+
+	rec := sendWithKey(t, router, http.MethodPost, "/flaky", "panics", `{}`)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	// The retry runs the handler again instead of waiting for the key.
+	rec = sendWithKey(t, router, http.MethodPost, "/flaky", "panics", `{}`)
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, 2, calls)
+}
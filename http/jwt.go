@@ -0,0 +1,287 @@
+package http
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtMethods are the signing algorithms JWTs may use. Each key is only ever
+// tried with the algorithms of its own type, so an RSA public key cannot be
+// passed off as an HMAC secret.
+var jwtMethods = []string{"HS256", "RS256", "EdDSA"}
+
+// JWTConfig says which JWTs a JWTVerifier accepts.
+type JWTConfig struct {
+	// Secret verifies HS256 tokens. Tokens naming a kid are checked against
+	// the key set instead.
+	Secret []byte
+	// JWKSFile is a local JSON Web Key Set holding RSA, Ed25519 and HMAC
+	// keys. It is read again whenever it changes, so keys can be rotated by
+	// adding the new key, switching the issuer over and removing the old one.
+	JWKSFile string
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// Leeway allows for clock skew when checking exp and nbf.
+	Leeway time.Duration
+}
+
+// JWTClaims are the claims of an accepted token. Scope holds space
+// separated scopes, as issued by OAuth 2.0 servers.
+type JWTClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope,omitempty"`
+}
+
+// Scopes returns the scopes granted by the token.
+func (c *JWTClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// JWTVerifier checks the signature and the exp, nbf, iss and aud claims of
+// bearer JWTs.
+type JWTVerifier struct {
+	secret []byte
+	keys   *keySet
+	parser *jwt.Parser
+}
+
+func NewJWTVerifier(config JWTConfig) (*JWTVerifier, error) {
+	if len(config.Secret) == 0 && config.JWKSFile == "" {
+		return nil, errors.New("a JWT secret or JWKS file is required")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(config.Leeway),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	verifier := &JWTVerifier{secret: config.Secret, parser: jwt.NewParser(options...)}
+
+	if config.JWKSFile != "" {
+		verifier.keys = &keySet{path: config.JWKSFile}
+		// A broken key set is reported on startup rather than on the first
+		// request.
+		if err := verifier.keys.refresh(); err != nil {
+			return nil, err
+		}
+	}
+	return verifier, nil
+}
+
+// ProvideJWTVerifier reads the JWT_* variables. It returns nil, which turns
+// JWTs off, when neither JWT_SECRET nor JWT_JWKS_FILE is set.
+func ProvideJWTVerifier() (*JWTVerifier, error) {
+	config := JWTConfig{
+		Secret:   []byte(os.Getenv("JWT_SECRET")),
+		JWKSFile: os.Getenv("JWT_JWKS_FILE"),
+		Issuer:   os.Getenv("JWT_ISSUER"),
+		Audience: os.Getenv("JWT_AUDIENCE"),
+	}
+	if len(config.Secret) == 0 && config.JWKSFile == "" {
+		return nil, nil
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("JWT_LEEWAY_SECONDS")); err == nil && seconds > 0 {
+		config.Leeway = time.Duration(seconds) * time.Second
+	}
+	if config.Issuer == "" || config.Audience == "" {
+		log.Println("warning: JWT_ISSUER or JWT_AUDIENCE not set; any JWT signed by a configured key is accepted")
+	}
+	return NewJWTVerifier(config)
+}
+
+// Verify returns the claims of a valid token.
+func (v *JWTVerifier) Verify(token string) (*JWTClaims, error) {
+	claims := &JWTClaims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.key); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	return claims, nil
+}
+
+// key picks the keys a token may be signed with: the key set entry named by
+// its kid, or else the secret and every key of the right type.
+func (v *JWTVerifier) key(token *jwt.Token) (any, error) {
+	alg := token.Method.Alg()
+	var keys []jwt.VerificationKey
+	if v.keys != nil {
+		if err := v.keys.refresh(); err != nil {
+			log.Printf("failed to reload JWKS file, keeping the previous keys: %v", err)
+		}
+		kid, _ := token.Header["kid"].(string)
+		keys = v.keys.lookup(kid, alg)
+		if kid != "" {
+			if len(keys) == 0 {
+				return nil, fmt.Errorf("unknown key id %q", kid)
+			}
+			return keys[0], nil
+		}
+	}
+	if alg == "HS256" && len(v.secret) > 0 {
+		keys = append(keys, v.secret)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no key for %s tokens", alg)
+	}
+	return jwt.VerificationKeySet{Keys: keys}, nil
+}
+
+// keySet holds the keys of a JWKS file, reloading them when the file's
+// modification time or size changes.
+type keySet struct {
+	path string
+
+	mu      sync.RWMutex
+	modTime time.Time
+	size    int64
+	keys    []jsonWebKey
+}
+
+type jsonWebKey struct {
+	kid string
+	alg string
+	key jwt.VerificationKey
+}
+
+func (s *keySet) refresh() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	s.mu.RLock()
+	current := info.ModTime().Equal(s.modTime) && info.Size() == s.size
+	s.mu.RUnlock()
+	if current {
+		return nil
+	}
+
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.path, err)
+	}
+	s.mu.Lock()
+	s.keys, s.modTime, s.size = keys, info.ModTime(), info.Size()
+	s.mu.Unlock()
+	return nil
+}
+
+// lookup returns the keys usable with alg, narrowed down to kid if set.
+func (s *keySet) lookup(kid, alg string) []jwt.VerificationKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []jwt.VerificationKey
+	for _, key := range s.keys {
+		if (kid == "" || key.kid == kid) && key.alg == alg {
+			keys = append(keys, key.key)
+		}
+	}
+	return keys
+}
+
+// parseJWKS reads the RSA, Ed25519 (OKP) and HMAC (oct) keys of a JSON Web
+// Key Set. Keys meant for encryption are skipped.
+func parseJWKS(raw []byte) ([]jsonWebKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make([]jsonWebKey, 0, len(set.Keys))
+	for i, entry := range set.Keys {
+		if entry.Use != "" && entry.Use != "sig" {
+			continue
+		}
+		key := jsonWebKey{kid: entry.Kid}
+		var err error
+		switch entry.Kty {
+		case "RSA":
+			key.alg = "RS256"
+			key.key, err = rsaKey(entry.N, entry.E)
+		case "OKP":
+			key.alg = "EdDSA"
+			key.key, err = ed25519Key(entry.Crv, entry.X)
+		case "oct":
+			key.alg = "HS256"
+			key.key, err = base64.RawURLEncoding.DecodeString(entry.K)
+		default:
+			err = fmt.Errorf("unsupported key type %q", entry.Kty)
+		}
+		if err == nil && entry.Alg != "" && entry.Alg != key.alg {
+			err = fmt.Errorf("unsupported algorithm %q for a %s key", entry.Alg, entry.Kty)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func rsaKey(n, e string) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, errors.New("invalid exponent")
+	}
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(modulus)}
+	for _, b := range exponent {
+		key.E = key.E<<8 | int(b)
+	}
+	if key.N.BitLen() < 2048 {
+		return nil, errors.New("RSA keys need at least 2048 bits")
+	}
+	return key, nil
+}
+
+func ed25519Key(crv, x string) (ed25519.PublicKey, error) {
+	if crv != "Ed25519" {
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+	key, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 key")
+	}
+	return ed25519.PublicKey(key), nil
+}
@@ -0,0 +1,254 @@
+package http_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	todohttp "github.com/Xillon/golang-todo-api/http"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jwtSecret = []byte("test-jwt-secret-of-32-bytes-long")
+
+func signJWT(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// gatewayClaims returns valid claims for the test issuer and audience,
+// granting both todo scopes.
+func gatewayClaims(subject string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   subject,
+		"scope": "openid todos:read todos:write",
+		"iss":   "https://sso.example.com",
+		"aud":   "todo-api",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nbf":   time.Now().Add(-time.Minute).Unix(),
+	}
+}
+
+func newJWTVerifier(t *testing.T, jwksFile string) *todohttp.JWTVerifier {
+	t.Helper()
+
+	verifier, err := todohttp.NewJWTVerifier(todohttp.JWTConfig{
+		Secret:   jwtSecret,
+		JWKSFile: jwksFile,
+		Issuer:   "https://sso.example.com",
+		Audience: "todo-api",
+	})
+	require.NoError(t, err)
+	return verifier
+}
+
+func writeJWKS(t *testing.T, path string, keys ...map[string]string) {
+	t.Helper()
+
+	raw, err := json.Marshal(map[string]any{"keys": keys})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+	// Make sure the change is noticed even within the file system's
+	// timestamp granularity.
+	later := time.Now().Add(time.Duration(len(keys)) * time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ed25519JWK(kid string, key ed25519.PublicKey) map[string]string {
+	return map[string]string{"kty": "OKP", "crv": "Ed25519", "kid": kid, "x": base64.RawURLEncoding.EncodeToString(key)}
+}
+
+func TestJWTScopesRequestsToTheSubject(t *testing.T) {
+	router, _ := helpers.SetupRouterWithJWT(t, newJWTVerifier(t, ""))
+	alice := signJWT(t, jwt.SigningMethodHS256, jwtSecret, "", gatewayClaims("alice"))
+	bob := signJWT(t, jwt.SigningMethodHS256, jwtSecret, "", gatewayClaims("bob"))
+
+	rec := sendAs(t, router, alice, http.MethodPost, "/todos", `{"todos": [{"title": "From SSO"}]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = sendAs(t, router, alice, http.MethodGet, "/auth/me", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"username":"alice"`)
+
+	// The user is created once and the todos stay theirs.
+	rec = sendAs(t, router, alice, http.MethodGet, "/todos", "")
+	assert.Contains(t, rec.Body.String(), "From SSO")
+	rec = sendAs(t, router, bob, http.MethodGet, "/todos", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "From SSO")
+
+	rec = sendAs(t, router, alice, http.MethodGet, "/todos/1/history", "")
+	assert.Contains(t, rec.Body.String(), `"actor":"alice"`)
+
+	// Users created for a subject cannot log in with a password, and a JWT
+	// cannot take over a user who registered with one.
+	rec = sendAs(t, router, "", http.MethodPost, "/auth/login", `{"username": "alice", "password": ""}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	signUp(t, router, "carol")
+	carol := signJWT(t, jwt.SigningMethodHS256, jwtSecret, "", gatewayClaims("carol"))
+	assert.Equal(t, http.StatusUnauthorized, sendAs(t, router, carol, http.MethodGet, "/todos", "").Code)
+
+	// Logging out only applies to login tokens.
+	assert.Equal(t, http.StatusUnauthorized, sendAs(t, router, alice, http.MethodPost, "/auth/logout", "").Code)
+}
+
+func TestJWTScopeClaimIsEnforced(t *testing.T) {
+	router, _ := helpers.SetupRouterWithJWT(t, newJWTVerifier(t, ""))
+	withScope := func(subject, scope string) string {
+		claims := gatewayClaims(subject)
+		claims["scope"] = scope
+		return signJWT(t, jwt.SigningMethodHS256, jwtSecret, "", claims)
+	}
+	writer := withScope("alice", "todos:write")
+	reader := withScope("alice", "todos:read")
+	other := withScope("alice", "openid profile admin")
+
+	createTodoAs(t, router, writer, `{"title": "From SSO"}`)
+
+	rec := sendAs(t, router, reader, http.MethodGet, "/todos", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "From SSO")
+	rec = sendAs(t, router, reader, http.MethodPost, "/todos", `{"todos": [{"title": "Nope"}]}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "todos:write")
+	rec = sendAs(t, router, reader, http.MethodPost, "/graphql", `{"query": "mutation { addTodos(todos: [{title: \"Nope\"}]) { id } }"}`)
+	assert.Contains(t, rec.Body.String(), "todos:write")
+
+	// Tokens without a todo scope can do nothing with todos.
+	assert.Equal(t, http.StatusForbidden, sendAs(t, router, other, http.MethodGet, "/todos", "").Code)
+	assert.Equal(t, http.StatusForbidden, sendAs(t, router, other, http.MethodPost, "/graphql", `{"query": "{ todos { totalCount } }"}`).Code)
+}
+
+func TestJWTClaimsAreValidated(t *testing.T) {
+	router, _ := helpers.SetupRouterWithJWT(t, newJWTVerifier(t, ""))
+
+	for name, change := range map[string]func(jwt.MapClaims){
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"no expiry":      func(c jwt.MapClaims) { delete(c, "exp") },
+		"not yet valid":  func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Hour).Unix() },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "another-api" },
+		"no subject":     func(c jwt.MapClaims) { delete(c, "sub") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			claims := gatewayClaims("alice")
+			change(claims)
+			token := signJWT(t, jwt.SigningMethodHS256, jwtSecret, "", claims)
+			assert.Equal(t, http.StatusUnauthorized, sendAs(t, router, token, http.MethodGet, "/todos", "").Code)
+		})
+	}
+
+	t.Run("wrong secret", func(t *testing.T) {
+		token := signJWT(t, jwt.SigningMethodHS256, []byte("another-secret-of-32-bytes-long!"), "", gatewayClaims("alice"))
+		assert.Equal(t, http.StatusUnauthorized, sendAs(t, router, token, http.MethodGet, "/todos", "").Code)
+	})
+	t.Run("unsigned", func(t *testing.T) {
+		token := signJWT(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", gatewayClaims("alice"))
+		assert.Equal(t, http.StatusUnauthorized, sendAs(t, router, token, http.MethodGet, "/todos", "").Code)
+	})
+}
+
+func TestJWTKeysRotateWithTheJWKSFile(t *testing.T) {
+	oldRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	jwks := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwks, rsaJWK("2025", &oldRSA.PublicKey), ed25519JWK("ed", edPublic))
+	router, _ := helpers.SetupRouterWithJWT(t, newJWTVerifier(t, jwks))
+
+	old := signJWT(t, jwt.SigningMethodRS256, oldRSA, "2025", gatewayClaims("alice"))
+	ed := signJWT(t, jwt.SigningMethodEdDSA, edPrivate, "ed", gatewayClaims("alice"))
+	next := signJWT(t, jwt.SigningMethodRS256, newRSA, "2026", gatewayClaims("alice"))
+	assert.Equal(t, http.StatusOK, sendAs(t, router, old, http.MethodGet, "/todos", "").Code)
+	assert.Equal(t, http.StatusOK, sendAs(t, router, ed, http.MethodGet, "/todos", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, sendAs(t, router, next, http.MethodGet, "/todos", "").Code)
+
+	// A token must use the algorithm of the key it names.
+	forged := signJWT(t, jwt.SigningMethodHS256, jwtSecret, "2025", gatewayClaims("alice"))
+	assert.Equal(t, http.StatusUnauthorized, sendAs(t, router, forged, http.MethodGet, "/todos", "").Code)
+
+	// Both keys are valid during the switch, then the old one is removed.
+	writeJWKS(t, jwks, rsaJWK("2025", &oldRSA.PublicKey), rsaJWK("2026", &newRSA.PublicKey), ed25519JWK("ed", edPublic))
+	assert.Equal(t, http.StatusOK, sendAs(t, router, old, http.MethodGet, "/todos", "").Code)
+	assert.Equal(t, http.StatusOK, sendAs(t, router, next, http.MethodGet, "/todos", "").Code)
+	writeJWKS(t, jwks, rsaJWK("2026", &newRSA.PublicKey))
+	assert.Equal(t, http.StatusUnauthorized, sendAs(t, router, old, http.MethodGet, "/todos", "").Code)
+	assert.Equal(t, http.StatusOK, sendAs(t, router, next, http.MethodGet, "/todos", "").Code)
+
+	// A broken file keeps the last good keys.
+	require.NoError(t, os.WriteFile(jwks, []byte("{not json"), 0o600))
+	assert.Equal(t, http.StatusOK, sendAs(t, router, next, http.MethodGet, "/todos", "").Code)
+
+	_, err = todohttp.NewJWTVerifier(todohttp.JWTConfig{JWKSFile: jwks})
+	assert.Error(t, err)
+}
+
+func TestRequireOnlyAdmitsTheAcceptedCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator := todohttp.NewAuthenticator("secret-key", repository.NewMemoryUserStore(), repository.NewMemoryAPIKeyStore(), newJWTVerifier(t, ""))
+	router := gin.New()
+	ok := func(c *gin.Context) {
+		principal, _ := todohttp.CurrentPrincipal(c)
+		c.String(http.StatusOK, principal.Credential.String()+":"+principal.Subject)
+	}
+	router.GET("/operator", authenticator.Require(todohttp.CredentialAPIKey), ok)
+	router.GET("/sso", authenticator.Require(todohttp.CredentialJWT), ok)
+
+	token := signJWT(t, jwt.SigningMethodHS256, jwtSecret, "", gatewayClaims("alice"))
+	send := func(url, header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("/operator", "X-API-Key", "secret-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "api_key:api-key", rec.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, send("/operator", "Authorization", "Bearer "+token).Code)
+	assert.Equal(t, http.StatusUnauthorized, send("/operator", "", "").Code)
+
+	rec = send("/sso", "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "jwt:alice", rec.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, send("/sso", "X-API-Key", "secret-key").Code)
+	assert.Equal(t, http.StatusUnauthorized, send("/sso", "Authorization", "Bearer opaque-login-token").Code)
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+var errPatchTestFailed = errors.New("json patch test operation failed")
+
+// todoPatch is an RFC 7396 merge patch or an RFC 6902 JSON patch aimed at a
+// single todo. Unlike plain JSON bodies, explicit false, empty and null values
+// in a patch are applied.
+type todoPatch struct {
+	contentType string
+	document    []byte
+}
+
+func isPatchContentType(contentType string) bool {
+	return contentType == mergePatchContentType || contentType == jsonPatchContentType
+}
+
+// apply patches the JSON representation of current and decodes the result
+// back into a todo, rejecting unknown fields and id changes.
+func (p todoPatch) apply(current *models.Todo) (*models.Todo, error) {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	switch p.contentType {
+	case mergePatchContentType:
+		patched, err = jsonpatch.MergePatch(doc, p.document)
+	case jsonPatchContentType:
+		var operations jsonpatch.Patch
+		operations, err = jsonpatch.DecodePatch(p.document)
+		if err == nil {
+			patched, err = operations.Apply(doc)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported patch content type %q", p.contentType)
+	}
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return nil, errPatchTestFailed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+
+	var todo models.Todo
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&todo); err != nil {
+		return nil, fmt.Errorf("invalid patch result: %w", err)
+	}
+	if todo.ID != current.ID {
+		return nil, errors.New("id cannot be changed")
+	}
+	// A version in the patch acts as a precondition, like the batch version field.
+	if todo.Version != current.Version {
+		return nil, repository.ErrVersionConflict
+	}
+	if todo.Title == "" {
+		return nil, errors.New("title is required")
+	}
+	clearServerFields(&todo)
+	return &todo, nil
+}
+
+// patchTodo applies patch to the stored todo and returns the saved result. A
+// non-zero version must match the stored one.
+func patchTodo(ctx context.Context, repo repository.TodoRepository, id, version uint, patch todoPatch) (*models.Todo, error) {
+	current, err := repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && current.Version != version {
+		return nil, repository.ErrVersionConflict
+	}
+	patched, err := patch.apply(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.Replace(ctx, patched); err != nil {
+		return nil, err
+	}
+	return repo.FindByID(ctx, id)
+}
+
+// splitBatchPatch separates the target id and expected version from one item
+// of a batch patch. Merge patch items carry the id (and optionally version)
+// next to the fields to merge; JSON patch items look like
+// {"id": 1, "version": 3, "patch": [operations...]}.
+func splitBatchPatch(contentType string, item json.RawMessage) (uint, uint, todoPatch, error) {
+	patch := todoPatch{contentType: contentType}
+
+	if contentType == jsonPatchContentType {
+		var op struct {
+			ID      uint            `json:"id"`
+			Version uint            `json:"version"`
+			Patch   json.RawMessage `json:"patch"`
+		}
+		if err := json.Unmarshal(item, &op); err != nil {
+			return 0, 0, patch, err
+		}
+		if op.ID == 0 {
+			return 0, 0, patch, errors.New("id is required")
+		}
+		patch.document = op.Patch
+		return op.ID, op.Version, patch, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(item, &fields); err != nil {
+		return 0, 0, patch, err
+	}
+	var id, version uint
+	if raw, ok := fields["id"]; !ok || json.Unmarshal(raw, &id) != nil || id == 0 {
+		return 0, 0, patch, errors.New("id is required")
+	}
+	if raw, ok := fields["version"]; ok && json.Unmarshal(raw, &version) != nil {
+		return 0, 0, patch, errors.New("version must be a positive integer")
+	}
+	delete(fields, "id")
+	delete(fields, "version")
+
+	document, err := json.Marshal(fields)
+	if err != nil {
+		return 0, 0, patch, err
+	}
+	patch.document = document
+	return id, version, patch, nil
+}
@@ -0,0 +1,98 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendPatch(t *testing.T, router *gin.Engine, url, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPatch, url, strings.NewReader(body))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUpdateTodoByIDMergePatchAppliesZeroValues(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	due := time.Date(2025, 9, 30, 17, 0, 0, 0, time.UTC)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Seed merge", Description: "Clear me", DueDate: &due, Complete: true})
+	require.Len(t, seeded, 1)
+
+	rec := sendPatch(t, router, "/todos/"+strconv.Itoa(int(seeded[0].ID)), "application/merge-patch+json",
+		`{"complete": false, "description": "", "due_date": null}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var todo models.Todo
+	require.NoError(t, db.First(&todo, seeded[0].ID).Error)
+	assert.Equal(t, "Seed merge", todo.Title)
+	assert.False(t, todo.Complete)
+	assert.Empty(t, todo.Description)
+	assert.Nil(t, todo.DueDate)
+}
+
+func TestUpdateTodoByIDJSONPatch(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Seed json patch", Complete: true})
+	require.Len(t, seeded, 1)
+	url := "/todos/" + strconv.Itoa(int(seeded[0].ID))
+
+	rec := sendPatch(t, router, url, "application/json-patch+json",
+		`[{"op": "test", "path": "/complete", "value": false}, {"op": "replace", "path": "/title", "value": "Nope"}]`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = sendPatch(t, router, url, "application/json-patch+json", `[{"op": "replace", "path": "/id", "value": 99}]`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = sendPatch(t, router, url, "application/json-patch+json",
+		`[{"op": "test", "path": "/complete", "value": true}, {"op": "replace", "path": "/complete", "value": false}]`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var todo models.Todo
+	require.NoError(t, db.First(&todo, seeded[0].ID).Error)
+	assert.Equal(t, "Seed json patch", todo.Title)
+	assert.False(t, todo.Complete)
+}
+
+func TestUpdateTodosMergePatchBatch(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db,
+		models.Todo{Title: "Batch one", Complete: true},
+		models.Todo{Title: "Batch two", Description: "Drop me"},
+	)
+
+	payload, err := json.Marshal(map[string][]map[string]any{
+		"todos": {
+			{"id": seeded[0].ID, "complete": false},
+			{"id": seeded[1].ID, "description": nil},
+		},
+	})
+	require.NoError(t, err)
+
+	rec := sendPatch(t, router, "/todos", "application/merge-patch+json", string(payload))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var first, second models.Todo
+	require.NoError(t, db.First(&first, seeded[0].ID).Error)
+	require.NoError(t, db.First(&second, seeded[1].ID).Error)
+	assert.False(t, first.Complete)
+	assert.Empty(t, second.Description)
+
+	rec = sendPatch(t, router, "/todos", "application/merge-patch+json", `{"todos": [{"complete": true}]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
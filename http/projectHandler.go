@@ -0,0 +1,188 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetProjects godoc
+// @Summary      List projects
+// @Tags         projects
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Success      200  {object}  map[string][]models.Project
+// @Router       /projects [get]
+func (h *TodoHandler) GetProjects(c *gin.Context) {
+	projects, err := h.Todos.ListProjects(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"projects": projects})
+}
+
+// AddProject godoc
+// @Summary      Create a project
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string          true  "API key"
+// @Param        request    body    models.Project  true  "Project"
+// @Success      201  {object}  map[string]models.Project
+// @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /projects [post]
+func (h *TodoHandler) AddProject(c *gin.Context) {
+	var project models.Project
+	if err := c.ShouldBindJSON(&project); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	project.ID = 0
+
+	if err := h.Todos.CreateProject(c.Request.Context(), &project); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"project": project})
+}
+
+// GetProjectById godoc
+// @Summary      Get project by ID
+// @Tags         projects
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Project ID"
+// @Success      200  {object}  map[string]models.Project
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /projects/{id} [get]
+func (h *TodoHandler) GetProjectById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	project, err := h.Todos.FindProject(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// UpdateProjectById godoc
+// @Summary      Rename or describe a project
+// @Description  Only the fields present in the body are changed.
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string          true  "API key"
+// @Param        id         path    int             true  "Project ID"
+// @Param        request    body    models.Project  true  "Fields to change"
+// @Success      200  {object}  map[string]models.Project
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /projects/{id} [patch]
+func (h *TodoHandler) UpdateProjectById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, err := h.Todos.FindProject(c.Request.Context(), id)
+	if err == nil {
+		if request.Name != nil {
+			project.Name = *request.Name
+		}
+		if request.Description != nil {
+			project.Description = *request.Description
+		}
+		err = h.Todos.UpdateProject(c.Request.Context(), project)
+	}
+	if err == nil {
+		project, err = h.Todos.FindProject(c.Request.Context(), id)
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// DeleteProjectById godoc
+// @Summary      Delete a project
+// @Description  Only empty projects can be deleted. Move or purge their todos, including trashed ones, first.
+// @Tags         projects
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Project ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /projects/{id} [delete]
+func (h *TodoHandler) DeleteProjectById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Todos.DeleteProject(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Project with id %d deleted successfully", id)})
+}
+
+// GetProjectTodos godoc
+// @Summary      List the todos of a project
+// @Description  Accepts the same filtering, sorting and pagination parameters as GET /todos.
+// @Tags         projects
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Project ID"
+// @Param        page       query   int     false "Page number"  default(1)
+// @Param        limit      query   int     false "Items per page"  default(10) maximum(100)
+// @Param        cursor     query   string  false "Opaque cursor from a previous next_cursor/prev_cursor"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /projects/{id}/todos [get]
+func (h *TodoHandler) GetProjectTodos(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.Todos.FindProject(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := parseTodoFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.ProjectID = &id
+
+	h.listTodos(c, query)
+}
@@ -0,0 +1,122 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createProject(t *testing.T, router *gin.Engine, name string) string {
+	t.Helper()
+
+	rec := sendWithKey(t, router, http.MethodPost, "/projects", "", `{"name": "`+name+`"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var body struct {
+		Project models.Project `json:"project"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return strconv.Itoa(int(body.Project.ID))
+}
+
+func TestTodoTitlesAreUniquePerProject(t *testing.T) {
+	for name, setup := range map[string]func(t *testing.T) *gin.Engine{
+		"sqlite": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithSQLite(t); return router },
+		"memory": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithMemory(t); return router },
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			backend, frontend := createProject(t, router, "Backend"), createProject(t, router, "Frontend")
+
+			todos := createTodos(t, router, `{"todos": [
+				{"title": "Write tests", "project_id": `+backend+`},
+				{"title": "Write tests", "project_id": `+frontend+`},
+				{"title": "Write tests"}
+			]}`)
+			require.Len(t, todos, 3)
+
+			for _, body := range []string{
+				`{"todos": [{"title": "Write tests", "project_id": ` + backend + `}]}`,
+				`{"todos": [{"title": "Write tests"}]}`,
+			} {
+				rec := sendWithKey(t, router, http.MethodPost, "/todos", "", body)
+				assert.Equal(t, http.StatusConflict, rec.Code, body)
+			}
+			rec := sendWithKey(t, router, http.MethodPost, "/todos", "", `{"todos": [{"title": "Orphan", "project_id": 999}]}`)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+
+			// Moving the unassigned todo into a project that already has the title fails.
+			rec = sendPatch(t, router, "/todos/"+strconv.Itoa(int(todos[2].ID)), "application/json", `{"project_id": `+backend+`}`)
+			assert.Equal(t, http.StatusConflict, rec.Code)
+
+			createTodos(t, router, `{"todos": [{"title": "Ship it", "project_id": `+backend+`}]}`)
+			assert.Equal(t, []string{"Write tests", "Ship it"}, listTitles(t, router, "/projects/"+backend+"/todos"))
+			assert.Equal(t, []string{"Write tests"}, listTitles(t, router, "/todos?project_id="+frontend))
+			assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodGet, "/projects/999/todos").Code)
+		})
+	}
+}
+
+func TestProjectCRUD(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	id := createProject(t, router, "Platform")
+	url := "/projects/" + id
+
+	rec := sendWithKey(t, router, http.MethodPost, "/projects", "", `{"name": "Platform"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = sendWithKey(t, router, http.MethodPost, "/projects", "", `{"name": " "}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = sendPatch(t, router, url, "application/json", `{"description": "Shared services"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"name":"Platform"`)
+	assert.Contains(t, rec.Body.String(), `"description":"Shared services"`)
+
+	rec = serve(t, router, http.MethodGet, "/projects")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"Platform"`)
+
+	todos := createTodos(t, router, `{"todos": [{"title": "Blocker", "project_id": `+id+`}]}`)
+	todoURL := "/todos/" + strconv.Itoa(int(todos[0].ID))
+	assert.Equal(t, http.StatusConflict, serve(t, router, http.MethodDelete, url).Code)
+
+	// Trashed todos still keep the project alive until they are purged.
+	require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, todoURL).Code)
+	assert.Equal(t, http.StatusConflict, serve(t, router, http.MethodDelete, url).Code)
+	require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, "/trash/"+strconv.Itoa(int(todos[0].ID))).Code)
+
+	assert.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, url).Code)
+	assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodGet, url).Code)
+}
+
+func TestTodoTitlesAreUniqueInTheDatabase(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	project := createProject(t, router, "Backend")
+	todos := createTodos(t, router, `{"todos": [{"title": "Write tests", "project_id": `+project+`}, {"title": "Write docs"}]}`)
+	require.Len(t, todos, 2)
+
+	// Writers that skip the repository's check, or race it, still cannot
+	// store a title twice.
+	projectID := todos[0].ProjectID
+	scope := *projectID
+	duplicate := models.Todo{Title: "Write tests", ProjectID: projectID, TitleScope: &scope}
+	assert.Error(t, db.Create(&duplicate).Error)
+	unassigned := uint(0)
+	duplicate = models.Todo{Title: "Write docs", TitleScope: &unassigned}
+	assert.Error(t, db.Create(&duplicate).Error)
+
+	// Moving a todo moves its title into the new project's scope.
+	rec := sendPatch(t, router, "/todos/"+strconv.Itoa(int(todos[1].ID)), "application/json", `{"project_id": `+project+`}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var moved models.Todo
+	require.NoError(t, db.First(&moved, todos[1].ID).Error)
+	require.NotNil(t, moved.TitleScope)
+	assert.Equal(t, scope, *moved.TitleScope)
+	assert.NoError(t, db.Create(&models.Todo{Title: "Write docs", TitleScope: &unassigned}).Error)
+}
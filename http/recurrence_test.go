@@ -0,0 +1,104 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTodos(t *testing.T, router *gin.Engine) []models.Todo {
+	t.Helper()
+
+	rec := serve(t, router, http.MethodGet, "/todos?complete=false&sort=due_date")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var body struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body.Todos
+}
+
+func TestCompletingRecurringTodoSchedulesNextOccurrence(t *testing.T) {
+	for name, setup := range map[string]func(t *testing.T) *gin.Engine{
+		"sqlite": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithSQLite(t); return router },
+		"memory": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithMemory(t); return router },
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			london, err := time.LoadLocation("Europe/London")
+			require.NoError(t, err)
+
+			// 09:00 on the Monday before the clocks go back.
+			todos := createTodos(t, router, `{"todos": [{
+				"title": "Take out the bins",
+				"due_date": "2025-10-20T09:00:00+01:00",
+				"recurrence": "rrule:freq=weekly;byday=MO;count=3",
+				"timezone": "Europe/London",
+				"tags": ["home"]
+			}]}`)
+			require.Len(t, todos, 1)
+			assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO;COUNT=3", todos[0].Recurrence)
+
+			wantDue := []time.Time{
+				time.Date(2025, 10, 27, 9, 0, 0, 0, london),
+				time.Date(2025, 11, 3, 9, 0, 0, 0, london),
+			}
+			id := todos[0].ID
+			for i, due := range wantDue {
+				rec := sendWithKey(t, router, http.MethodPatch, "/todos", "", `{"todos": [{"id": `+strconv.Itoa(int(id))+`, "complete": true}]}`)
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+				open := openTodos(t, router)
+				require.Len(t, open, 1)
+				next := open[0]
+				assert.Equal(t, "Take out the bins", next.Title)
+				assert.True(t, due.Equal(*next.DueDate), "got %s, want %s", next.DueDate, due)
+				assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO;COUNT="+strconv.Itoa(2-i), next.Recurrence)
+				assert.Equal(t, []string{"home"}, models.TagNames(next.Tags))
+				require.NotNil(t, next.SeriesID)
+				assert.Equal(t, todos[0].ID, *next.SeriesID)
+				id = next.ID
+			}
+
+			// The third occurrence was the last one.
+			rec := sendPatch(t, router, "/todos/"+strconv.Itoa(int(id)), "application/json", `{"complete": true}`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Empty(t, openTodos(t, router))
+			assert.Len(t, listTitles(t, router, "/todos?title=bins"), 3)
+		})
+	}
+}
+
+func TestRecurrenceFollowsMonthlyWeekdayRules(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+
+	createTodos(t, router, `{"todos": [{"title": "Monthly report", "due_date": "2025-01-31T17:00:00Z", "recurrence": "FREQ=MONTHLY;BYDAY=-1FR"}]}`)
+	rec := sendPatch(t, router, "/todos/1", "application/json", `{"complete": true}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	open := openTodos(t, router)
+	require.Len(t, open, 1)
+	assert.True(t, time.Date(2025, 2, 28, 17, 0, 0, 0, time.UTC).Equal(*open[0].DueDate), open[0].DueDate)
+}
+
+func TestRecurrenceIsValidated(t *testing.T) {
+	router, _ := helpers.SetupRouterWithMemory(t)
+
+	for _, body := range []string{
+		`{"todos": [{"title": "No due date", "recurrence": "FREQ=DAILY"}]}`,
+		`{"todos": [{"title": "Bad rule", "due_date": "2025-01-01T09:00:00Z", "recurrence": "FREQ=SOMETIMES"}]}`,
+		`{"todos": [{"title": "Own start", "due_date": "2025-01-01T09:00:00Z", "recurrence": "DTSTART=20250101T090000Z;FREQ=DAILY"}]}`,
+		`{"todos": [{"title": "Bad zone", "due_date": "2025-01-01T09:00:00Z", "recurrence": "FREQ=DAILY", "timezone": "Mars/Olympus"}]}`,
+	} {
+		rec := sendWithKey(t, router, http.MethodPost, "/todos", "", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+}
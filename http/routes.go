@@ -0,0 +1,97 @@
+package http
+
+import (
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers are what RegisterRoutes serves the API with.
+type Handlers struct {
+	Todos         *TodoHandler
+	Shares        *ShareHandler
+	Auth          *AuthHandler
+	Authenticator *Authenticator
+	APIKeys       *APIKeyHandler
+	Webhooks      *WebhookHandler
+	Events        *EventStream
+	Collab        *CollabHandler
+	GraphQL       *GraphQLHandler
+	Idempotency   *Idempotency
+}
+
+// RegisterRoutes adds the API's routes to r.
+func RegisterRoutes(r gin.IRouter, h Handlers) {
+	r.POST("/auth/register", h.Auth.Register)
+	r.POST("/auth/login", h.Auth.Login)
+
+	idempotency := h.Idempotency.Middleware()
+	secured := r.Group("/", h.Authenticator.Require(CredentialAPIKey, CredentialLoginToken, CredentialJWT))
+	account := secured.Group("/", idempotency)
+	todoRoutes := secured.Group("/", RequireTodoScope(), idempotency)
+	// GraphQL queries are sent with POST too, so mutations check the write
+	// scope themselves.
+	queries := secured.Group("/", RequireScope(models.ScopeTodosRead), idempotency)
+
+	// Webhooks receive the events of every user and API keys grant access to
+	// every todo, so only admin keys may manage them.
+	keys := r.Group("/", h.Authenticator.Require(CredentialAPIKey), RequireScope(models.ScopeAdmin))
+	// Issuing and rotating keys answer with their secret, which must not be
+	// stored for replays.
+	admin := keys.Group("/", idempotency)
+
+	account.POST("/auth/logout", h.Auth.Logout)
+	account.GET("/auth/me", h.Auth.GetCurrentUser)
+
+	todoRoutes.POST("/todos", h.Todos.AddTodos)
+	todoRoutes.PATCH("/todos", h.Todos.UpdateTodos)
+	todoRoutes.GET("/todos", h.Todos.GetTodos)
+	todoRoutes.GET("/todos/:id", h.Todos.GetTodoById)
+	todoRoutes.PUT("/todos/:id", h.Todos.ReplaceTodoById)
+	todoRoutes.PATCH("/todos/:id", h.Todos.UpdateTodoById)
+	todoRoutes.DELETE("/todos/:id", h.Todos.DeleteTodoById)
+	todoRoutes.POST("/todos/:id/restore", h.Todos.RestoreTodoById)
+	todoRoutes.GET("/todos/:id/history", h.Todos.GetTodoHistory)
+	todoRoutes.POST("/todos/:id/revert", h.Todos.RevertTodo)
+	todoRoutes.GET("/todos/:id/children", h.Todos.GetTodoChildren)
+	todoRoutes.GET("/todos/:id/tree", h.Todos.GetTodoTree)
+	todoRoutes.GET("/todos/order", h.Todos.GetTodoOrder)
+	todoRoutes.GET("/todos/events", h.Events.StreamTodoEvents)
+	todoRoutes.GET("/todos/ws", h.Collab.Collaborate)
+	queries.GET("/graphql", h.GraphQL.ServeGraphQL)
+	queries.POST("/graphql", h.GraphQL.ServeGraphQL)
+	todoRoutes.GET("/todos/:id/dependencies", h.Todos.GetTodoDependencies)
+	todoRoutes.POST("/todos/:id/dependencies", h.Todos.AddTodoDependency)
+	todoRoutes.DELETE("/todos/:id/dependencies/:blocker_id", h.Todos.RemoveTodoDependency)
+	todoRoutes.GET("/todos/:id/shares", h.Shares.GetTodoShares)
+	todoRoutes.PUT("/todos/:id/shares/:username", h.Shares.ShareTodo)
+	todoRoutes.DELETE("/todos/:id/shares/:username", h.Shares.UnshareTodo)
+	todoRoutes.GET("/trash", h.Todos.GetTrash)
+	todoRoutes.DELETE("/trash", h.Todos.PurgeTrash)
+	todoRoutes.DELETE("/trash/:id", h.Todos.PurgeTodoById)
+	todoRoutes.GET("/tags", h.Todos.GetTags)
+	todoRoutes.POST("/tags", h.Todos.AddTag)
+	todoRoutes.GET("/tags/:id", h.Todos.GetTagById)
+	todoRoutes.PATCH("/tags/:id", h.Todos.UpdateTagById)
+	todoRoutes.DELETE("/tags/:id", h.Todos.DeleteTagById)
+	todoRoutes.GET("/projects", h.Todos.GetProjects)
+	todoRoutes.POST("/projects", h.Todos.AddProject)
+	todoRoutes.GET("/projects/:id", h.Todos.GetProjectById)
+	todoRoutes.PATCH("/projects/:id", h.Todos.UpdateProjectById)
+	todoRoutes.DELETE("/projects/:id", h.Todos.DeleteProjectById)
+	todoRoutes.GET("/projects/:id/todos", h.Todos.GetProjectTodos)
+	todoRoutes.GET("/projects/:id/shares", h.Shares.GetProjectShares)
+	todoRoutes.PUT("/projects/:id/shares/:username", h.Shares.ShareProject)
+	todoRoutes.DELETE("/projects/:id/shares/:username", h.Shares.UnshareProject)
+	admin.GET("/api-keys", h.APIKeys.GetAPIKeys)
+	keys.POST("/api-keys", h.APIKeys.AddAPIKey)
+	admin.GET("/api-keys/:id", h.APIKeys.GetAPIKeyById)
+	admin.DELETE("/api-keys/:id", h.APIKeys.RevokeAPIKeyById)
+	keys.POST("/api-keys/:id/rotate", h.APIKeys.RotateAPIKeyById)
+	admin.GET("/webhooks", h.Webhooks.GetWebhooks)
+	admin.POST("/webhooks", h.Webhooks.AddWebhook)
+	admin.GET("/webhooks/:id", h.Webhooks.GetWebhookById)
+	admin.PATCH("/webhooks/:id", h.Webhooks.UpdateWebhookById)
+	admin.DELETE("/webhooks/:id", h.Webhooks.DeleteWebhookById)
+	admin.GET("/webhooks/:id/deliveries", h.Webhooks.GetWebhookDeliveries)
+	admin.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", h.Webhooks.RedeliverWebhookDelivery)
+}
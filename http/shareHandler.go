@@ -0,0 +1,217 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/policy"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// ShareHandler shares todos and projects with other users. Whether the
+// request may do so is decided by the repository's policy layer.
+type ShareHandler struct {
+	Todos repository.TodoRepository
+	Users repository.UserStore
+}
+
+func ProvideShareHandler(todos repository.TodoRepository, users repository.UserStore) *ShareHandler {
+	return &ShareHandler{Todos: todos, Users: users}
+}
+
+type shareRequest struct {
+	Role string `json:"role" example:"editor"`
+}
+
+// GetTodoShares godoc
+// @Summary      List who a todo is shared with
+// @Description  Anyone who can see the todo may list its shares. Shares of the todo's project are listed on the project.
+// @Tags         shares
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true  "Todo ID"
+// @Success      200  {object}  map[string][]models.Share
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /todos/{id}/shares [get]
+func (h *ShareHandler) GetTodoShares(c *gin.Context) {
+	h.listShares(c, func(id uint) repository.ShareTarget { return repository.ShareTarget{TodoID: id} })
+}
+
+// ShareTodo godoc
+// @Summary      Share a todo with a user
+// @Description  Gives the user the viewer, editor or owner role on the todo, replacing the role they had.
+// @Description  Viewers may read the todo, editors may also change it and owners may also delete and share it.
+// @Description  Only owners may share.
+// @Tags         shares
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string        true  "API key"
+// @Param        id         path    int           true  "Todo ID"
+// @Param        username   path    string        true  "User to share with"
+// @Param        request    body    shareRequest  true  "Role"
+// @Success      200  {object}  map[string]models.Share
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /todos/{id}/shares/{username} [put]
+func (h *ShareHandler) ShareTodo(c *gin.Context) {
+	h.saveShare(c, func(id uint) repository.ShareTarget { return repository.ShareTarget{TodoID: id} })
+}
+
+// UnshareTodo godoc
+// @Summary      Stop sharing a todo with a user
+// @Description  Owners may remove any share; users may also remove their own.
+// @Tags         shares
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true  "Todo ID"
+// @Param        username   path    string  true  "User the todo is shared with"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /todos/{id}/shares/{username} [delete]
+func (h *ShareHandler) UnshareTodo(c *gin.Context) {
+	h.deleteShare(c, func(id uint) repository.ShareTarget { return repository.ShareTarget{TodoID: id} })
+}
+
+// GetProjectShares godoc
+// @Summary      List who a project is shared with
+// @Tags         shares
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true  "Project ID"
+// @Success      200  {object}  map[string][]models.Share
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /projects/{id}/shares [get]
+func (h *ShareHandler) GetProjectShares(c *gin.Context) {
+	h.listShares(c, func(id uint) repository.ShareTarget { return repository.ShareTarget{ProjectID: id} })
+}
+
+// ShareProject godoc
+// @Summary      Share a project with a user
+// @Description  Gives the user the viewer, editor or owner role on the project and every todo in it.
+// @Description  Editors may also add todos to the project. Only owners may share.
+// @Tags         shares
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string        true  "API key"
+// @Param        id         path    int           true  "Project ID"
+// @Param        username   path    string        true  "User to share with"
+// @Param        request    body    shareRequest  true  "Role"
+// @Success      200  {object}  map[string]models.Share
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /projects/{id}/shares/{username} [put]
+func (h *ShareHandler) ShareProject(c *gin.Context) {
+	h.saveShare(c, func(id uint) repository.ShareTarget { return repository.ShareTarget{ProjectID: id} })
+}
+
+// UnshareProject godoc
+// @Summary      Stop sharing a project with a user
+// @Description  Owners may remove any share; users may also remove their own.
+// @Tags         shares
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true  "Project ID"
+// @Param        username   path    string  true  "User the project is shared with"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /projects/{id}/shares/{username} [delete]
+func (h *ShareHandler) UnshareProject(c *gin.Context) {
+	h.deleteShare(c, func(id uint) repository.ShareTarget { return repository.ShareTarget{ProjectID: id} })
+}
+
+func (h *ShareHandler) listShares(c *gin.Context, target func(id uint) repository.ShareTarget) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	shares, err := h.Todos.ListShares(ctx, target(id))
+	if err == nil {
+		err = h.fillUsernames(ctx, shares)
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shares": shares})
+}
+
+func (h *ShareHandler) saveShare(c *gin.Context, target func(id uint) repository.ShareTarget) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var request shareRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role, err := policy.ParseRole(request.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.Users.FindUserByName(ctx, c.Param("username"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	share := models.Share{UserID: user.ID, Role: string(role)}
+	if t := target(id); t.TodoID != 0 {
+		share.TodoID = &t.TodoID
+	} else {
+		share.ProjectID = &t.ProjectID
+	}
+	if err := h.Todos.SaveShare(ctx, &share); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	share.Username = user.Username
+
+	c.JSON(http.StatusOK, gin.H{"share": share})
+}
+
+func (h *ShareHandler) deleteShare(c *gin.Context, target func(id uint) repository.ShareTarget) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.Users.FindUserByName(ctx, c.Param("username"))
+	if err == nil {
+		err = h.Todos.DeleteShare(ctx, target(id), user.ID)
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "share of " + user.Username + " removed"})
+}
+
+// fillUsernames sets the username of every share.
+func (h *ShareHandler) fillUsernames(ctx context.Context, shares []models.Share) error {
+	for i := range shares {
+		user, err := h.Users.FindUser(ctx, shares[i].UserID)
+		if err != nil {
+			return err
+		}
+		shares[i].Username = user.Username
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTodoAs creates a todo for the token's user and returns its URL.
+func createTodoAs(t *testing.T, router *gin.Engine, token, todo string) string {
+	t.Helper()
+
+	rec := sendAs(t, router, token, http.MethodPost, "/todos", `{"todos": [`+todo+`]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	return "/todos/" + strconv.Itoa(int(created.Todos[0].ID))
+}
+
+// createProjectAs creates a project for the token's user and returns its id.
+func createProjectAs(t *testing.T, router *gin.Engine, token, name string) string {
+	t.Helper()
+
+	rec := sendAs(t, router, token, http.MethodPost, "/projects", `{"name": "`+name+`"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created struct {
+		Project models.Project `json:"project"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	return strconv.Itoa(int(created.Project.ID))
+}
+
+func shareBackends() map[string]func(t *testing.T) *gin.Engine {
+	return map[string]func(t *testing.T) *gin.Engine{
+		"sqlite": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithSQLite(t); return router },
+		"memory": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithMemory(t); return router },
+	}
+}
+
+func TestShareTodoRoles(t *testing.T) {
+	for name, setup := range shareBackends() {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			alice, bob, carol := signUp(t, router, "alice"), signUp(t, router, "bob"), signUp(t, router, "carol")
+			plants := createTodoAs(t, router, alice, `{"title": "Water plants"}`)
+
+			// Sharing is validated before anything is stored.
+			assert.Equal(t, http.StatusBadRequest, sendAs(t, router, alice, http.MethodPut, plants+"/shares/bob", `{"role": "admin"}`).Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, alice, http.MethodPut, plants+"/shares/nobody", `{"role": "viewer"}`).Code)
+			assert.Equal(t, http.StatusBadRequest, sendAs(t, router, alice, http.MethodPut, plants+"/shares/alice", `{"role": "viewer"}`).Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, bob, http.MethodPut, plants+"/shares/bob", `{"role": "owner"}`).Code)
+
+			// Viewers may read but not change the todo.
+			rec := sendAs(t, router, alice, http.MethodPut, plants+"/shares/bob", `{"role": "viewer"}`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), `"username":"bob"`)
+			assert.Equal(t, http.StatusOK, sendAs(t, router, bob, http.MethodGet, plants, "").Code)
+			assert.Equal(t, http.StatusOK, sendAs(t, router, bob, http.MethodGet, plants+"/history", "").Code)
+			assert.Equal(t, http.StatusForbidden, sendAs(t, router, bob, http.MethodPatch, plants, `{"complete": true}`).Code)
+			assert.Equal(t, http.StatusForbidden, sendAs(t, router, bob, http.MethodPut, plants, `{"title": "Mine now"}`).Code)
+			rec = sendAs(t, router, bob, http.MethodGet, "/todos", "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "Water plants")
+
+			// Editors may also change it, but neither delete nor share it.
+			require.Equal(t, http.StatusOK, sendAs(t, router, alice, http.MethodPut, plants+"/shares/bob", `{"role": "editor"}`).Code)
+			rec = sendAs(t, router, bob, http.MethodPatch, plants, `{"complete": true}`)
+			assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, http.StatusForbidden, sendAs(t, router, bob, http.MethodDelete, plants, "").Code)
+			assert.Equal(t, http.StatusForbidden, sendAs(t, router, bob, http.MethodPut, plants+"/shares/carol", `{"role": "viewer"}`).Code)
+
+			// Carol still sees nothing.
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, carol, http.MethodGet, plants, "").Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, carol, http.MethodGet, plants+"/shares", "").Code)
+
+			rec = sendAs(t, router, bob, http.MethodGet, plants+"/shares", "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			var listed struct {
+				Shares []models.Share `json:"shares"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+			require.Len(t, listed.Shares, 1)
+			assert.Equal(t, "bob", listed.Shares[0].Username)
+			assert.Equal(t, "editor", listed.Shares[0].Role)
+
+			// Bob may give up his own share; after that the todo is gone for him.
+			assert.Equal(t, http.StatusForbidden, sendAs(t, router, bob, http.MethodDelete, plants+"/shares/carol", "").Code)
+			assert.Equal(t, http.StatusOK, sendAs(t, router, bob, http.MethodDelete, plants+"/shares/bob", "").Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, bob, http.MethodGet, plants, "").Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, alice, http.MethodDelete, plants+"/shares/bob", "").Code)
+
+			// Shared owners may delete.
+			require.Equal(t, http.StatusOK, sendAs(t, router, alice, http.MethodPut, plants+"/shares/carol", `{"role": "owner"}`).Code)
+			assert.Equal(t, http.StatusOK, sendAs(t, router, carol, http.MethodDelete, plants, "").Code)
+		})
+	}
+}
+
+func TestShareProject(t *testing.T) {
+	for name, setup := range shareBackends() {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			alice, bob := signUp(t, router, "alice"), signUp(t, router, "bob")
+
+			rec := sendAs(t, router, alice, http.MethodPost, "/projects", `{"name": "Home"}`)
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+			var created struct {
+				Project models.Project `json:"project"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+			project := strconv.Itoa(int(created.Project.ID))
+			plants := createTodoAs(t, router, alice, `{"title": "Water plants", "project_id": `+project+`}`)
+			rent := createTodoAs(t, router, alice, `{"title": "Pay rent"}`)
+
+			// Project viewers see its todos, and only those, but add nothing.
+			rec = sendAs(t, router, alice, http.MethodPut, "/projects/"+project+"/shares/bob", `{"role": "viewer"}`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, http.StatusOK, sendAs(t, router, bob, http.MethodGet, "/projects/"+project, "").Code)
+			assert.Equal(t, http.StatusOK, sendAs(t, router, bob, http.MethodGet, plants, "").Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, bob, http.MethodGet, rent, "").Code)
+			assert.Equal(t, http.StatusForbidden, sendAs(t, router, bob, http.MethodPatch, plants, `{"complete": true}`).Code)
+			rec = sendAs(t, router, bob, http.MethodPost, "/todos", `{"todos": [{"title": "Sweep", "project_id": `+project+`}]}`)
+			assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+
+			// Editors add todos to it, which then belong to the project owner.
+			require.Equal(t, http.StatusOK, sendAs(t, router, alice, http.MethodPut, "/projects/"+project+"/shares/bob", `{"role": "editor"}`).Code)
+			sweep := createTodoAs(t, router, bob, `{"title": "Sweep", "project_id": `+project+`}`)
+			rec = sendAs(t, router, alice, http.MethodGet, sweep, "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "Sweep")
+			assert.Equal(t, http.StatusOK, sendAs(t, router, bob, http.MethodPatch, plants, `{"complete": true}`).Code)
+			assert.Equal(t, http.StatusForbidden, sendAs(t, router, bob, http.MethodDelete, "/projects/"+project, "").Code)
+			assert.Equal(t, http.StatusForbidden, sendAs(t, router, bob, http.MethodDelete, sweep, "").Code)
+
+			// Subtasks of alice's todos have to stay in a project bob can edit.
+			createTodoAs(t, router, bob, `{"title": "Buy watering can", "parent_id": `+plants[len("/todos/"):]+`, "project_id": `+project+`}`)
+			rec = sendAs(t, router, bob, http.MethodPost, "/todos", `{"todos": [{"title": "Buy soil", "parent_id": `+plants[len("/todos/"):]+`}]}`)
+			assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+
+			// Mutations from other APIs go through the same checks.
+			require.Equal(t, http.StatusOK, sendAs(t, router, alice, http.MethodPut, "/projects/"+project+"/shares/bob", `{"role": "viewer"}`).Code)
+			rec = sendAs(t, router, bob, http.MethodPost, "/graphql", `{"query": "mutation { updateTodos(todos: [{id: `+sweep[len("/todos/"):]+`, complete: true}]) { id } }"}`)
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "role does not allow")
+
+			// Unsharing the project hides it and its todos again.
+			assert.Equal(t, http.StatusOK, sendAs(t, router, alice, http.MethodDelete, "/projects/"+project+"/shares/bob", "").Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, bob, http.MethodGet, "/projects/"+project, "").Code)
+			assert.Equal(t, http.StatusNotFound, sendAs(t, router, bob, http.MethodGet, plants, "").Code)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTodoChildren godoc
+// @Summary      List the subtasks of a todo
+// @Description  Returns the live direct subtasks of the todo. Use /todos/{id}/tree for every level.
+// @Tags         subtasks
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Todo ID"
+// @Success      200  {object}  map[string][]models.Todo
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /todos/{id}/children [get]
+func (h *TodoHandler) GetTodoChildren(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	children, err := repository.ListChildren(c.Request.Context(), h.Todos, id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"todos": children})
+}
+
+// GetTodoTree godoc
+// @Summary      Get a todo with all of its subtasks
+// @Description  Nests every live subtask below the todo. Each node reports how many of its descendants are complete;
+// @Description  a todo without subtasks is 0 or 100 percent done.
+// @Tags         subtasks
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Todo ID"
+// @Success      200  {object}  map[string]models.TodoNode
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /todos/{id}/tree [get]
+func (h *TodoHandler) GetTodoTree(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	tree, err := repository.TodoTree(c.Request.Context(), h.Todos, id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tree": tree})
+}
+
+// parseChildPolicy reads the children parameter of DELETE /todos/:id,
+// answering 400 when it is not one of the known policies.
+func parseChildPolicy(c *gin.Context) (repository.ChildPolicy, bool) {
+	switch policy := repository.ChildPolicy(c.DefaultQuery("children", string(repository.ChildrenBlock))); policy {
+	case repository.ChildrenBlock, repository.ChildrenReparent, repository.ChildrenCascade:
+		return policy, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "children must be block, reparent or cascade"})
+	return "", false
+}
+
+// parseCompleteChildren reads the complete_children parameter of PUT and
+// PATCH /todos/:id.
+func parseCompleteChildren(c *gin.Context) (bool, bool) {
+	raw, ok := c.GetQuery("complete_children")
+	if !ok {
+		return false, true
+	}
+	cascade, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "complete_children must be true or false"})
+		return false, false
+	}
+	return cascade, true
+}
+
+// completeChildren completes every subtask of a todo the write left complete.
+func completeChildren(ctx context.Context, repo repository.TodoRepository, id uint) error {
+	todo, err := repo.FindByID(ctx, id)
+	if err != nil || !todo.Complete {
+		return err
+	}
+	return repository.CompleteSubtasks(ctx, repo, id)
+}
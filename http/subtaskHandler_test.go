@@ -0,0 +1,104 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func subtaskRouters() map[string]func(t *testing.T) *gin.Engine {
+	return map[string]func(t *testing.T) *gin.Engine{
+		"sqlite": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithSQLite(t); return router },
+		"memory": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithMemory(t); return router },
+	}
+}
+
+// createTree creates Release > (Build > Test, Docs) and returns the ids in
+// that order.
+func createTree(t *testing.T, router *gin.Engine) (release, build, test, docs string) {
+	t.Helper()
+
+	root := createTodos(t, router, `{"todos": [{"title": "Release"}]}`)
+	release = strconv.Itoa(int(root[0].ID))
+	children := createTodos(t, router, `{"todos": [{"title": "Build", "parent_id": `+release+`}, {"title": "Docs", "parent_id": `+release+`, "complete": true}]}`)
+	build, docs = strconv.Itoa(int(children[0].ID)), strconv.Itoa(int(children[1].ID))
+	leaf := createTodos(t, router, `{"todos": [{"title": "Test", "parent_id": `+build+`}]}`)
+	return release, build, strconv.Itoa(int(leaf[0].ID)), docs
+}
+
+func TestSubtasksNestAndReportProgress(t *testing.T) {
+	for name, setup := range subtaskRouters() {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			release, build, test, _ := createTree(t, router)
+
+			assert.Equal(t, []string{"Build", "Docs"}, listTitles(t, router, "/todos/"+release+"/children"))
+			assert.Equal(t, []string{"Test"}, listTitles(t, router, "/todos?parent_id="+build))
+			assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodGet, "/todos/999/children").Code)
+
+			rec := serve(t, router, http.MethodGet, "/todos/"+release+"/tree")
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			var body struct {
+				Tree models.TodoNode `json:"tree"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, models.Progress{Complete: 1, Total: 3, Percent: 33}, body.Tree.Progress)
+			require.Len(t, body.Tree.Children, 2)
+			assert.Equal(t, "Build", body.Tree.Children[0].Title)
+			assert.Equal(t, models.Progress{Complete: 0, Total: 1, Percent: 0}, body.Tree.Children[0].Progress)
+			assert.Equal(t, "Test", body.Tree.Children[0].Children[0].Title)
+			assert.Equal(t, models.Progress{Percent: 100}, body.Tree.Children[1].Progress)
+
+			// A todo cannot end up below itself or its own subtasks.
+			rec = sendPatch(t, router, "/todos/"+release, "application/json", `{"parent_id": `+test+`}`)
+			assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+			rec = sendPatch(t, router, "/todos/"+build, "application/json", `{"parent_id": `+build+`}`)
+			assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+			rec = sendPatch(t, router, "/todos/"+build, "application/json", `{"parent_id": 999}`)
+			assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestDeleteTodoChildPolicies(t *testing.T) {
+	for name, setup := range subtaskRouters() {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			release, build, test, _ := createTree(t, router)
+
+			assert.Equal(t, http.StatusConflict, serve(t, router, http.MethodDelete, "/todos/"+build).Code)
+			assert.Equal(t, http.StatusBadRequest, serve(t, router, http.MethodDelete, "/todos/"+build+"?children=orphan").Code)
+
+			require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, "/todos/"+build+"?children=reparent").Code)
+			assert.Equal(t, []string{"Docs", "Test"}, listTitles(t, router, "/todos/"+release+"/children"))
+
+			require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, "/todos/"+release+"?children=cascade").Code)
+			assert.Empty(t, listTitles(t, router, "/todos"))
+			assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodGet, "/todos/"+test).Code)
+		})
+	}
+}
+
+func TestCompletingTodoCanCompleteChildren(t *testing.T) {
+	for name, setup := range subtaskRouters() {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			release, build, _, _ := createTree(t, router)
+
+			rec := sendPatch(t, router, "/todos/"+build, "application/json", `{"complete": true}`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, []string{"Test"}, listTitles(t, router, "/todos?complete=false&parent_id="+build))
+
+			rec = sendPatch(t, router, "/todos/"+release+"?complete_children=true", "application/json", `{"complete": true}`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Empty(t, listTitles(t, router, "/todos?complete=false"))
+		})
+	}
+}
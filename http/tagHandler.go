@@ -0,0 +1,153 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTags godoc
+// @Summary      List tags
+// @Tags         tags
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Success      200  {object}  map[string][]models.Tag
+// @Router       /tags [get]
+func (h *TodoHandler) GetTags(c *gin.Context) {
+	tags, err := h.Todos.ListTags(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// AddTag godoc
+// @Summary      Create a tag
+// @Description  Tags can also be created on the fly by naming them in a todo payload.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string      true  "API key"
+// @Param        request    body    models.Tag  true  "Tag"
+// @Success      201  {object}  map[string]models.Tag
+// @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /tags [post]
+func (h *TodoHandler) AddTag(c *gin.Context) {
+	var tag models.Tag
+	if err := c.ShouldBindJSON(&tag); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tag.ID = 0
+
+	if err := h.Todos.CreateTag(c.Request.Context(), &tag); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"tag": tag})
+}
+
+// GetTagById godoc
+// @Summary      Get tag by ID
+// @Tags         tags
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Tag ID"
+// @Success      200  {object}  map[string]models.Tag
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /tags/{id} [get]
+func (h *TodoHandler) GetTagById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	tag, err := h.Todos.FindTag(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tag": tag})
+}
+
+// UpdateTagById godoc
+// @Summary      Rename or recolour a tag
+// @Description  Only the fields present in the body are changed; an empty colour removes it.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string      true  "API key"
+// @Param        id         path    int         true  "Tag ID"
+// @Param        request    body    models.Tag  true  "Fields to change"
+// @Success      200  {object}  map[string]models.Tag
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /tags/{id} [patch]
+func (h *TodoHandler) UpdateTagById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Name   *string `json:"name"`
+		Colour *string `json:"colour"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.Todos.FindTag(c.Request.Context(), id)
+	if err == nil {
+		if request.Name != nil {
+			tag.Name = *request.Name
+		}
+		if request.Colour != nil {
+			tag.Colour = *request.Colour
+		}
+		err = h.Todos.UpdateTag(c.Request.Context(), tag)
+	}
+	if err == nil {
+		tag, err = h.Todos.FindTag(c.Request.Context(), id)
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tag": tag})
+}
+
+// DeleteTagById godoc
+// @Summary      Delete a tag
+// @Description  The tag is removed from every todo carrying it.
+// @Tags         tags
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Tag ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /tags/{id} [delete]
+func (h *TodoHandler) DeleteTagById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Todos.DeleteTag(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Tag with id %d deleted successfully", id)})
+}
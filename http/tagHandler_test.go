@@ -0,0 +1,102 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTodos(t *testing.T, router *gin.Engine, body string) []models.Todo {
+	t.Helper()
+
+	rec := sendWithKey(t, router, http.MethodPost, "/todos", "", body)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	return created.Todos
+}
+
+func TestTodosCarryInlineTags(t *testing.T) {
+	for name, setup := range map[string]func(t *testing.T) *gin.Engine{
+		"sqlite": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithSQLite(t); return router },
+		"memory": func(t *testing.T) *gin.Engine { router, _ := helpers.SetupRouterWithMemory(t); return router },
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := setup(t)
+			todos := createTodos(t, router, `{"todos": [
+				{"title": "Release notes", "tags": ["work", {"name": "urgent", "colour": "#FF0000"}]},
+				{"title": "Water plants", "tags": ["home"]},
+				{"title": "Expenses", "tags": ["work"]}
+			]}`)
+			require.Len(t, todos, 3)
+			assert.Equal(t, []string{"urgent", "work"}, models.TagNames(todos[0].Tags))
+
+			assert.Equal(t, []string{"Release notes", "Water plants", "Expenses"}, listTitles(t, router, "/todos?tag=work&tag=home"))
+			assert.Equal(t, []string{"Release notes"}, listTitles(t, router, "/todos?tag=work&tag=urgent&tag_match=all"))
+			assert.Empty(t, listTitles(t, router, "/todos?tag=missing"))
+
+			url := "/todos/" + strconv.Itoa(int(todos[0].ID))
+			rec := sendPatch(t, router, url, "application/json", `{"complete": true}`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), `"urgent"`)
+
+			rec = sendPatch(t, router, url, "application/merge-patch+json", `{"tags": ["home"]}`)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, []string{"Release notes", "Water plants"}, listTitles(t, router, "/todos?tag=home"))
+
+			rec = sendWithKey(t, router, http.MethodPost, "/todos", "", `{"todos": [{"title": "Bad tag", "tags": [{"name": "x", "colour": "red"}]}]}`)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestTagCRUD(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+
+	rec := sendWithKey(t, router, http.MethodPost, "/tags", "", `{"name": "work", "colour": "#1e90ff"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created struct {
+		Tag models.Tag `json:"tag"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	url := "/tags/" + strconv.Itoa(int(created.Tag.ID))
+
+	rec = sendWithKey(t, router, http.MethodPost, "/tags", "", `{"name": "work"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	todos := createTodos(t, router, `{"todos": [{"title": "Tagged", "tags": [{"id": `+strconv.Itoa(int(created.Tag.ID))+`}]}]}`)
+	assert.Equal(t, []string{"work"}, models.TagNames(todos[0].Tags))
+
+	rec = sendPatch(t, router, url, "application/json", `{"name": "office", "colour": ""}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, http.StatusOK, serve(t, router, http.MethodGet, url).Code)
+	assert.Equal(t, []string{"Tagged"}, listTitles(t, router, "/todos?tag=office"))
+
+	rec = serve(t, router, http.MethodGet, "/tags")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.Contains(rec.Body.String(), `"office"`))
+
+	require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, url).Code)
+	assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodGet, url).Code)
+	assert.Empty(t, listTitles(t, router, "/todos?tag=office"))
+
+	// Tagged todos can still be trashed and purged.
+	todos = createTodos(t, router, `{"todos": [{"title": "Purged", "tags": ["gone"]}]}`)
+	id := strconv.Itoa(int(todos[0].ID))
+	require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, "/todos/"+id).Code)
+	require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, "/trash/"+id).Code)
+	var links int64
+	require.NoError(t, db.Table("todo_tags").Count(&links).Error)
+	assert.Zero(t, links)
+}
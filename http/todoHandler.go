@@ -1,33 +1,42 @@
 package http
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type TodoHandler struct {
-	DB *gorm.DB
+	Todos   repository.TodoRepository
+	Cursors *CursorSigner
 }
 
-func ProvideTodoHandler(db *gorm.DB) *TodoHandler {
-	return &TodoHandler{DB: db}
+func ProvideTodoHandler(todos repository.TodoRepository, cursors *CursorSigner) *TodoHandler {
+	return &TodoHandler{Todos: todos, Cursors: cursors}
 }
 
 // AddTodos godoc
 // @Summary      Add a list of todos
-// @Description  Creates one or more todos
+// @Description  Creates one or more todos. By default the batch is atomic: either every todo is created or none is.
+// @Description  With mode=partial each todo is committed on its own and a per-item result array is returned.
 // @Tags         todos
 // @Accept       json
 // @Produce      json
 // @Param        X-API-Key  header  string  true  "API key"
+// @Param        Idempotency-Key  header  string  false "Key that makes retries of this request replay the first response"
+// @Param        mode       query   string  false "Batch mode"  Enums(atomic, partial)  default(atomic)
 // @Param        request    body    map[string][]models.Todo  true  "Todos payload"
 // @Success      201  {object}  map[string]interface{}
-// @Failure      400  {object}  map[string]string
+// @Success      207  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      422  {object}  map[string]interface{}
 // @Router       /todos [post]
 func (h *TodoHandler) AddTodos(c *gin.Context) {
 	var request struct {
@@ -38,29 +47,48 @@ func (h *TodoHandler) AddTodos(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
 	for i := range request.Todos {
-		if err := h.DB.Create(&request.Todos[i]).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
+		clearServerFields(&request.Todos[i])
+		// New todos get their id and first version from the repository.
+		request.Todos[i].ID, request.Todos[i].Version = 0, 0
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"todos": request.Todos})
+	h.runBatch(c, request.Todos, http.StatusCreated, func(repo repository.TodoRepository, i int) error {
+		return repo.Create(c.Request.Context(), &request.Todos[i])
+	})
 }
 
 // UpdateTodos godoc
 // @Summary      Update a list of todos
-// @Description  Updates one or more todos by id
+// @Description  Updates one or more todos by id. Batches are atomic unless mode=partial is given, see POST /todos.
+// @Description  Plain JSON items only change non-zero fields. To set false, empty or null values send
+// @Description  application/merge-patch+json items ({"id": 1, "complete": false}) or
+// @Description  application/json-patch+json items ({"id": 1, "patch": [{"op": "remove", "path": "/due_date"}]}).
+// @Description  Items may carry the version they were read at; an item whose todo has moved on fails with 409.
 // @Tags         todos
-// @Accept       json
+// @Accept       json,application/merge-patch+json,application/json-patch+json
 // @Produce      json
 // @Param        X-API-Key  header  string  true  "API key"
+// @Param        Idempotency-Key  header  string  false "Key that makes retries of this request replay the first response"
+// @Param        mode       query   string  false "Batch mode"  Enums(atomic, partial)  default(atomic)
+// @Param        force      query   bool    false "Allow completing todos while todos blocking them are still open"
 // @Param        request    body    map[string][]models.Todo  true  "Todos payload"
 // @Success      200  {object}  map[string]interface{}
-// @Failure      400  {object}  map[string]string
+// @Success      207  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      422  {object}  map[string]interface{}
 // @Router       /todos [patch]
 func (h *TodoHandler) UpdateTodos(c *gin.Context) {
+	if !parseForce(c) {
+		return
+	}
+	if contentType := c.ContentType(); isPatchContentType(contentType) {
+		h.patchTodos(c, contentType)
+		return
+	}
+
 	var request struct {
 		Todos []models.Todo `json:"todos"`
 	}
@@ -69,37 +97,119 @@ func (h *TodoHandler) UpdateTodos(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	for i := range request.Todos {
+		clearServerFields(&request.Todos[i])
+	}
 
-	for _, todo := range request.Todos {
-		if err := h.DB.Model(&models.Todo{}).Where("id = ?", todo.ID).Updates(todo).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	h.runBatch(c, request.Todos, http.StatusOK, func(repo repository.TodoRepository, i int) error {
+		if err := repo.Update(c.Request.Context(), &request.Todos[i]); err != nil {
+			return err
+		}
+		saved, err := repo.FindByID(c.Request.Context(), request.Todos[i].ID)
+		if err != nil {
+			return err
+		}
+		request.Todos[i] = *saved
+		return nil
+	})
+}
+
+// patchTodos handles PATCH /todos for merge patch and JSON patch bodies.
+func (h *TodoHandler) patchTodos(c *gin.Context, contentType string) {
+	var request struct {
+		Todos []json.RawMessage `json:"todos"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	todos := make([]models.Todo, len(request.Todos))
+	patches := make([]todoPatch, len(request.Todos))
+	for i, item := range request.Todos {
+		id, version, patch, err := splitBatchPatch(contentType, item)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "index": i})
 			return
 		}
+		todos[i].ID = id
+		todos[i].Version = version
+		patches[i] = patch
 	}
 
-	c.JSON(http.StatusOK, gin.H{"todos": request.Todos})
+	h.runBatch(c, todos, http.StatusOK, func(repo repository.TodoRepository, i int) error {
+		saved, err := patchTodo(c.Request.Context(), repo, todos[i].ID, todos[i].Version, patches[i])
+		if err != nil {
+			return err
+		}
+		todos[i] = *saved
+		return nil
+	})
 }
 
 // GetTodos godoc
 // @Summary      List todos
-// @Description  Returns a paginated list of todos
+// @Description  Returns a paginated list of todos, optionally filtered, searched and sorted.
+// @Description  Time ranges are exclusive and accept RFC 3339 timestamps or YYYY-MM-DD dates.
+// @Description  Passing cursor (empty for the first page) switches to keyset pagination over (created_at, id);
+// @Description  the response then carries next_cursor/prev_cursor instead of page and total, and sort is not allowed.
+// @Description  A cursor only continues the listing it came from: changing the filters while passing it answers 400.
 // @Tags         todos
 // @Produce      json
-// @Param        X-API-Key  header  string  true  "API key"
-// @Param        page       query   int     false "Page number"  default(1)
-// @Param        limit      query   int     false "Items per page"  default(10)
+// @Param        X-API-Key       header  string  true  "API key"
+// @Param        page            query   int     false "Page number"  default(1)
+// @Param        limit           query   int     false "Items per page"  default(10) maximum(100)
+// @Param        complete        query   bool    false "Only complete or incomplete todos"
+// @Param        due_before      query   string  false "Due before"
+// @Param        due_after       query   string  false "Due after"
+// @Param        remind_before   query   string  false "Reminder set before"
+// @Param        created_before  query   string  false "Created before"
+// @Param        created_after   query   string  false "Created after"
+// @Param        updated_before  query   string  false "Updated before"
+// @Param        updated_after   query   string  false "Updated after"
+// @Param        q               query   string  false "Substring of the title or description"
+// @Param        title           query   string  false "Substring of the title"
+// @Param        description     query   string  false "Substring of the description"
+// @Param        project_id      query   int     false "Only todos of this project"
+// @Param        parent_id       query   int     false "Only direct subtasks of this todo"
+// @Param        tag             query   []string  false "Tag names to filter by"  collectionFormat(multi)
+// @Param        tag_match       query   string  false "Whether todos need any or all of the tags"  Enums(any, all)  default(any)
+// @Param        sort            query   []string  false "Sort fields, e.g. due_date,-created_at or due_date:asc"  collectionFormat(multi)
+// @Param        cursor          query   string  false "Opaque cursor from a previous next_cursor/prev_cursor"
 // @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
 // @Router       /todos [get]
 func (h *TodoHandler) GetTodos(c *gin.Context) {
+	query, err := parseTodoFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.listTodos(c, query)
+}
+
+// listTodos pages through the todos matching query, by offset or by cursor.
+func (h *TodoHandler) listTodos(c *gin.Context, query repository.TodoQuery) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	limit = min(limit, repository.MaxPageSize)
 	offset := (page - 1) * limit
 
-	var todos []models.Todo
-	var total int64
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		h.getTodosByCursor(c, query, cursor, limit)
+		return
+	}
 
-	h.DB.Model(&models.Todo{}).Count(&total)
-	h.DB.Limit(limit).Offset(offset).Find(&todos)
+	query.Limit = limit
+	query.Offset = offset
+
+	todos, total, err := h.Todos.List(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"todos": todos,
@@ -111,26 +221,299 @@ func (h *TodoHandler) GetTodos(c *gin.Context) {
 	})
 }
 
+// getTodosByCursor serves GET /todos in keyset mode. Cursor pages carry no
+// total, so the matching todos are not counted.
+func (h *TodoHandler) getTodosByCursor(c *gin.Context, query repository.TodoQuery, raw string, limit int) {
+	if len(query.Sort) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort cannot be combined with cursor pagination"})
+		return
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	filters := filterHash(query)
+	var cursor *pageCursor
+	if raw != "" {
+		decoded, err := h.Cursors.decode(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if decoded.Filters != filters {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ErrCursorFilters.Error()})
+			return
+		}
+		cursor = &decoded
+	}
+	query.SkipTotal = true
+
+	backwards := cursor != nil && cursor.Direction == cursorPrev
+	var position *repository.TodoPosition
+	if cursor != nil {
+		position = cursor.position()
+	}
+
+	todos, more, _, err := repository.ListPage(c.Request.Context(), h.Todos, query, position, backwards, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	pagination := gin.H{"limit": limit, "next_cursor": nil, "prev_cursor": nil}
+	if len(todos) > 0 {
+		first, last := todos[0], todos[len(todos)-1]
+		if more || backwards {
+			pagination["next_cursor"] = h.Cursors.encode(pageCursor{Direction: cursorNext, CreatedAt: last.CreatedAt, ID: last.ID, Filters: filters})
+		}
+		if (backwards && more) || (!backwards && cursor != nil) {
+			pagination["prev_cursor"] = h.Cursors.encode(pageCursor{Direction: cursorPrev, CreatedAt: first.CreatedAt, ID: first.ID, Filters: filters})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"todos": todos, "pagination": pagination})
+}
+
+// GetTodoById godoc
+// @Summary      Get todo by ID
+// @Description  The ETag response header carries the todo's version; send it back as If-Match on writes.
+// @Tags         todos
+// @Produce      json
+// @Param        X-API-Key      header  string  true  "API key"
+// @Param        id             path    int     true "Todo ID"
+// @Param        If-None-Match  header  string  false "ETag from a previous read"
+// @Success      200  {object}  map[string]models.Todo
+// @Success      304
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /todos/{id} [get]
+func (h *TodoHandler) GetTodoById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	todo, err := h.Todos.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", etag(todo.Version))
+	if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, todo.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"todo": todo})
+}
+
+// ReplaceTodoById godoc
+// @Summary      Replace todo by ID
+// @Description  Overwrites every editable field; omitted fields are reset to their zero value.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string       true  "API key"
+// @Param        id         path    int          true  "Todo ID"
+// @Param        If-Match   header  string       false "ETag the change is conditional on"
+// @Param        complete_children  query  bool  false "Also complete every subtask when the todo ends up complete"
+// @Param        force      query   bool         false "Allow completing the todo while todos blocking it are still open"
+// @Param        request    body    models.Todo  true  "Todo"
+// @Success      200  {object}  map[string]models.Todo
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      412  {object}  map[string]string
+// @Router       /todos/{id} [put]
+func (h *TodoHandler) ReplaceTodoById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var todo models.Todo
+	if err := c.ShouldBindJSON(&todo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if todo.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title is required"})
+		return
+	}
+	todo.ID = id
+	clearServerFields(&todo)
+	cascade, ok := parseCompleteChildren(c)
+	if !ok || !parseForce(c) {
+		return
+	}
+
+	h.saveTodo(c, id, func(repo repository.TodoRepository) error {
+		expected, err := matchedVersion(c, repo, id)
+		if err != nil {
+			return err
+		}
+		if todo.Version == 0 {
+			todo.Version = expected
+		}
+		if err := repo.Replace(c.Request.Context(), &todo); err != nil || !cascade {
+			return err
+		}
+		return completeChildren(c.Request.Context(), repo, id)
+	})
+}
+
+// UpdateTodoById godoc
+// @Summary      Update todo by ID
+// @Description  Applies the non-zero fields of a plain JSON body to the todo. An application/merge-patch+json (RFC 7396)
+// @Description  or application/json-patch+json (RFC 6902) body is applied exactly, including false, empty and null values.
+// @Tags         todos
+// @Accept       json,application/merge-patch+json,application/json-patch+json
+// @Produce      json
+// @Param        X-API-Key  header  string       true  "API key"
+// @Param        id         path    int          true  "Todo ID"
+// @Param        If-Match   header  string       false "ETag the change is conditional on"
+// @Param        complete_children  query  bool  false "Also complete every subtask when the todo ends up complete"
+// @Param        force      query   bool         false "Allow completing the todo while todos blocking it are still open"
+// @Param        request    body    models.Todo  true  "Fields to change"
+// @Success      200  {object}  map[string]models.Todo
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      412  {object}  map[string]string
+// @Router       /todos/{id} [patch]
+func (h *TodoHandler) UpdateTodoById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	cascade, ok := parseCompleteChildren(c)
+	if !ok || !parseForce(c) {
+		return
+	}
+
+	if contentType := c.ContentType(); isPatchContentType(contentType) {
+		document, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		patch := todoPatch{contentType: contentType, document: document}
+		h.saveTodo(c, id, func(repo repository.TodoRepository) error {
+			expected, err := matchedVersion(c, repo, id)
+			if err != nil {
+				return err
+			}
+			if _, err := patchTodo(c.Request.Context(), repo, id, expected, patch); err != nil || !cascade {
+				return err
+			}
+			return completeChildren(c.Request.Context(), repo, id)
+		})
+		return
+	}
+
+	var todo models.Todo
+	if err := c.ShouldBindJSON(&todo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	todo.ID = id
+	clearServerFields(&todo)
+
+	h.saveTodo(c, id, func(repo repository.TodoRepository) error {
+		expected, err := matchedVersion(c, repo, id)
+		if err != nil {
+			return err
+		}
+		if todo.Version == 0 {
+			todo.Version = expected
+		}
+		if err := repo.Update(c.Request.Context(), &todo); err != nil || !cascade {
+			return err
+		}
+		return completeChildren(c.Request.Context(), repo, id)
+	})
+}
+
+// saveTodo runs a single-todo write and responds with the stored result.
+func (h *TodoHandler) saveTodo(c *gin.Context, id uint, write func(repo repository.TodoRepository) error) {
+	var saved *models.Todo
+	err := h.Todos.Transaction(c.Request.Context(), func(repo repository.TodoRepository) error {
+		if err := write(repo); err != nil {
+			return err
+		}
+		var err error
+		saved, err = repo.FindByID(c.Request.Context(), id)
+		return err
+	})
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", etag(saved.Version))
+	if saved.Complete && saved.Blocked {
+		c.JSON(http.StatusOK, gin.H{"todo": saved, "warning": "todo is complete while todos blocking it are still open"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"todo": saved})
+}
+
 // DeleteTodoById godoc
 // @Summary      Delete todo by ID
+// @Description  A todo with live subtasks is only deleted when children=reparent moves them up to its parent
+// @Description  or children=cascade trashes them too.
 // @Tags         todos
 // @Param        X-API-Key  header  string  true  "API key"
 // @Param        id         path    int     true "Todo ID"
+// @Param        If-Match   header  string  false "ETag the delete is conditional on"
+// @Param        children   query   string  false "What to do with subtasks"  Enums(block, reparent, cascade)  default(block)
 // @Success      200  {object}  map[string]string
 // @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      412  {object}  map[string]string
 // @Router       /todos/{id} [delete]
 func (h *TodoHandler) DeleteTodoById(c *gin.Context) {
-	id := c.Param("id")
-
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Id is required"})
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	policy, ok := parseChildPolicy(c)
+	if !ok {
 		return
 	}
 
-	if err := h.DB.Delete(&models.Todo{}, id).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	err := h.Todos.Transaction(c.Request.Context(), func(repo repository.TodoRepository) error {
+		expected, err := matchedVersion(c, repo, id)
+		if err != nil {
+			return err
+		}
+		return repository.DeleteTodo(c.Request.Context(), repo, id, expected, policy)
+	})
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Todo with id %s deleted successfully", id)})
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Todo with id %d deleted successfully", id)})
+}
+
+// clearServerFields drops what a request body said about the fields of a
+// todo that only the server sets: its owner, its timestamps and whether it
+// is in the trash.
+func clearServerFields(todo *models.Todo) {
+	todo.OwnerID = 0
+	todo.CreatedAt, todo.UpdatedAt = time.Time{}, time.Time{}
+	todo.DeletedAt.Time, todo.DeletedAt.Valid = time.Time{}, false
+}
+
+// parseID reads the :id path parameter, answering 400 when it is not a
+// positive integer.
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Id must be a positive integer"})
+		return 0, false
+	}
+	return uint(id), true
 }
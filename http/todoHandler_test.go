@@ -10,6 +10,7 @@ import (
 
 	"github.com/Xillon/golang-todo-api/helpers"
 	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -34,6 +35,7 @@ func TestAddTodos(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create request: %v", err)
 	}
+	helpers.WithAPIKey(req)
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -65,6 +67,7 @@ func TestUpdateTodos(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create request: %v", err)
 	}
+	helpers.WithAPIKey(req)
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -86,6 +89,7 @@ func TestGetTodos(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create request: %v", err)
 	}
+	helpers.WithAPIKey(req)
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
@@ -101,6 +105,7 @@ func TestDeleteTodoByID(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create request: %v", err)
 	}
+	helpers.WithAPIKey(req)
 
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
@@ -111,3 +116,152 @@ func TestDeleteTodoByID(t *testing.T) {
 	db.Model(&models.Todo{}).Count(&count)
 	assert.Equal(t, int64(0), count)
 }
+
+func TestAddAndGetTodosWithMemoryRepository(t *testing.T) {
+	router, repo := helpers.SetupRouterWithMemory(t)
+
+	jsonPayload, err := json.Marshal(map[string][]models.Todo{
+		"todos": {{Title: "Memory first"}, {Title: "Memory second"}},
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/todos", bytes.NewReader(jsonPayload))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	req, err = http.NewRequest(http.MethodGet, "/todos?limit=1", nil)
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Todos      []models.Todo `json:"todos"`
+		Pagination struct {
+			Total int64 `json:"total"`
+		} `json:"pagination"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Todos, 1)
+	assert.Equal(t, "Memory first", body.Todos[0].Title)
+	assert.Equal(t, int64(2), body.Pagination.Total)
+
+	_, total, err := repo.List(req.Context(), repository.TodoQuery{Limit: -1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+}
+
+func TestGetTodoByID(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Seed get"})
+	require.Len(t, seeded, 1)
+
+	cases := map[string]int{
+		"/todos/" + strconv.Itoa(int(seeded[0].ID)):      http.StatusOK,
+		"/todos/" + strconv.Itoa(int(seeded[0].ID)+1000): http.StatusNotFound,
+		"/todos/abc": http.StatusBadRequest,
+	}
+	for url, status := range cases {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		helpers.WithAPIKey(req)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, status, rec.Code, url)
+	}
+}
+
+func TestReplaceTodoByID(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Seed replace", Description: "Will be cleared", Complete: true})
+	require.Len(t, seeded, 1)
+
+	jsonPayload, err := json.Marshal(models.Todo{Title: "Seed replaced"})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPut, "/todos/"+strconv.Itoa(int(seeded[0].ID)), bytes.NewReader(jsonPayload))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var replaced models.Todo
+	require.NoError(t, db.First(&replaced, seeded[0].ID).Error)
+	assert.Equal(t, "Seed replaced", replaced.Title)
+	assert.Empty(t, replaced.Description)
+	assert.False(t, replaced.Complete)
+
+	req, err = http.NewRequest(http.MethodPut, "/todos/"+strconv.Itoa(int(seeded[0].ID)+1000), bytes.NewReader(jsonPayload))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestUpdateTodoByID(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Seed patch", Description: "Kept"})
+	require.Len(t, seeded, 1)
+
+	req, err := http.NewRequest(http.MethodPatch, "/todos/"+strconv.Itoa(int(seeded[0].ID)), bytes.NewReader([]byte(`{"complete": true}`)))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var body struct {
+		Todo models.Todo `json:"todo"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.True(t, body.Todo.Complete)
+	assert.Equal(t, "Kept", body.Todo.Description)
+}
+
+func TestDeleteTodoByIDNotFound(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+
+	req, err := http.NewRequest(http.MethodDelete, "/todos/4242", nil)
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAddTodosIgnoresClientIDs(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Seed taken"})
+	require.Len(t, seeded, 1)
+
+	body := `{"todos": [{"id": ` + strconv.Itoa(int(seeded[0].ID)) + `, "version": 7, "title": "Fresh"}]}`
+	req, err := http.NewRequest(http.MethodPost, "/todos", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Len(t, created.Todos, 1)
+	assert.NotEqual(t, seeded[0].ID, created.Todos[0].ID)
+	assert.Equal(t, uint(1), created.Todos[0].Version)
+
+	var kept models.Todo
+	require.NoError(t, db.First(&kept, seeded[0].ID).Error)
+	assert.Equal(t, "Seed taken", kept.Title)
+}
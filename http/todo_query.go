@@ -0,0 +1,134 @@
+package http
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// parseTodoFilters reads the filtering, search and sort parameters of
+// GET /todos. Pagination is left to the caller.
+func parseTodoFilters(c *gin.Context) (repository.TodoQuery, error) {
+	var query repository.TodoQuery
+
+	if raw, ok := c.GetQuery("complete"); ok {
+		complete, err := strconv.ParseBool(raw)
+		if err != nil {
+			return query, fmt.Errorf("complete must be true or false")
+		}
+		query.Complete = &complete
+	}
+
+	times := []struct {
+		param string
+		dest  **time.Time
+	}{
+		{"due_before", &query.DueBefore},
+		{"due_after", &query.DueAfter},
+		{"remind_before", &query.RemindBefore},
+		{"created_before", &query.CreatedBefore},
+		{"created_after", &query.CreatedAfter},
+		{"updated_before", &query.UpdatedBefore},
+		{"updated_after", &query.UpdatedAfter},
+	}
+	for _, t := range times {
+		raw, ok := c.GetQuery(t.param)
+		if !ok {
+			continue
+		}
+		parsed, err := parseQueryTime(raw)
+		if err != nil {
+			return query, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", t.param)
+		}
+		*t.dest = &parsed
+	}
+
+	query.Search = c.Query("q")
+	query.Title = c.Query("title")
+	query.Description = c.Query("description")
+
+	if raw, ok := c.GetQuery("project_id"); ok {
+		projectID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || projectID == 0 {
+			return query, fmt.Errorf("project_id must be a positive integer")
+		}
+		id := uint(projectID)
+		query.ProjectID = &id
+	}
+
+	if raw, ok := c.GetQuery("parent_id"); ok {
+		parentID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || parentID == 0 {
+			return query, fmt.Errorf("parent_id must be a positive integer")
+		}
+		id := uint(parentID)
+		query.ParentID = &id
+	}
+
+	for _, name := range c.QueryArray("tag") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(query.Tags, name) {
+			query.Tags = append(query.Tags, name)
+		}
+	}
+	switch c.DefaultQuery("tag_match", "any") {
+	case "any":
+	case "all":
+		query.AllTags = true
+	default:
+		return query, fmt.Errorf("tag_match must be any or all")
+	}
+
+	sort, err := parseSort(c.QueryArray("sort"))
+	if err != nil {
+		return query, err
+	}
+	query.Sort = sort
+
+	return query, nil
+}
+
+func parseQueryTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
+
+// parseSort accepts comma separated or repeated sort parameters. Each field is
+// a column name optionally prefixed with "-" or suffixed with ":asc"/":desc".
+func parseSort(values []string) ([]repository.SortField, error) {
+	var fields []repository.SortField
+	for _, value := range values {
+		for _, token := range strings.Split(value, ",") {
+			token = strings.TrimSpace(token)
+			if token == "" {
+				continue
+			}
+
+			field := repository.SortField{Column: token}
+			if column, ok := strings.CutPrefix(token, "-"); ok {
+				field = repository.SortField{Column: column, Desc: true}
+			} else if column, direction, ok := strings.Cut(token, ":"); ok {
+				switch strings.ToLower(direction) {
+				case "asc":
+					field = repository.SortField{Column: column}
+				case "desc":
+					field = repository.SortField{Column: column, Desc: true}
+				default:
+					return nil, fmt.Errorf("sort direction for %s must be asc or desc", column)
+				}
+			}
+
+			if !repository.IsSortableTodoColumn(field.Column) {
+				return nil, fmt.Errorf("cannot sort by %q; allowed fields are %s", field.Column, strings.Join(repository.SortableTodoColumns, ", "))
+			}
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,83 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listTitles(t *testing.T, router *gin.Engine, url string) []string {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var body struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+	titles := make([]string, 0, len(body.Todos))
+	for _, todo := range body.Todos {
+		titles = append(titles, todo.Title)
+	}
+	return titles
+}
+
+func TestGetTodosFiltersAndSorts(t *testing.T) {
+	due := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	dueIn := func(days int) *time.Time {
+		d := due.AddDate(0, 0, days)
+		return &d
+	}
+	seed := []models.Todo{
+		{Title: "Write report", Description: "Quarterly numbers", DueDate: dueIn(0)},
+		{Title: "Review report", Description: "Read the draft", DueDate: dueIn(7), Complete: true},
+		{Title: "Buy 100% cotton towels", DueDate: dueIn(14)},
+		{Title: "Someday maybe"},
+	}
+
+	sqliteRouter, db := helpers.SetupRouterWithSQLite(t)
+	helpers.SeedTodos(t, db, seed...)
+
+	memoryRouter, repo := helpers.SetupRouterWithMemory(t)
+	for i := range seed {
+		seed[i].ID = 0
+		require.NoError(t, repo.Create(t.Context(), &seed[i]))
+	}
+
+	for name, router := range map[string]*gin.Engine{"sqlite": sqliteRouter, "memory": memoryRouter} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, []string{"Buy 100% cotton towels", "Write report", "Someday maybe"}, listTitles(t, router, "/todos?complete=false&sort=-due_date"))
+			assert.Equal(t, []string{"Review report", "Write report"}, listTitles(t, router, "/todos?q=REPORT&sort=title:asc"))
+			assert.Equal(t, []string{"Buy 100% cotton towels"}, listTitles(t, router, "/todos?title=100%25"))
+			assert.Equal(t, []string{"Review report"}, listTitles(t, router, "/todos?due_after=2025-10-02&due_before=2025-10-10"))
+			assert.Empty(t, listTitles(t, router, "/todos?description=nothing+like+this"))
+		})
+	}
+}
+
+func TestGetTodosRejectsUnknownSortField(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+
+	for _, url := range []string{"/todos?sort=password", "/todos?sort=title:sideways", "/todos?complete=maybe", "/todos?due_before=tomorrow"} {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		helpers.WithAPIKey(req)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, url)
+	}
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrash godoc
+// @Summary      List trashed todos
+// @Description  Returns a paginated list of deleted todos that can still be restored.
+// @Tags         trash
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        page       query   int     false "Page number"  default(1)
+// @Param        limit      query   int     false "Items per page"  default(10)
+// @Success      200  {object}  map[string]interface{}
+// @Router       /trash [get]
+func (h *TodoHandler) GetTrash(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	todos, total, err := h.Todos.List(c.Request.Context(), repository.TodoQuery{Trashed: true, Limit: limit, Offset: offset})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"todos": todos,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// RestoreTodoById godoc
+// @Summary      Restore a trashed todo
+// @Tags         trash
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Todo ID"
+// @Success      200  {object}  map[string]models.Todo
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /todos/{id}/restore [post]
+func (h *TodoHandler) RestoreTodoById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	h.saveTodo(c, id, func(repo repository.TodoRepository) error {
+		return repo.Restore(c.Request.Context(), id)
+	})
+}
+
+// PurgeTrash godoc
+// @Summary      Empty the trash
+// @Description  Permanently deletes trashed todos. With before, only todos trashed before that time are removed.
+// @Tags         trash
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        before     query   string  false "RFC 3339 timestamp or YYYY-MM-DD date"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Router       /trash [delete]
+func (h *TodoHandler) PurgeTrash(c *gin.Context) {
+	before := time.Now()
+	if raw, ok := c.GetQuery("before"); ok {
+		parsed, err := parseQueryTime(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "before must be an RFC 3339 timestamp or a YYYY-MM-DD date"})
+			return
+		}
+		before = parsed
+	}
+
+	purged, err := h.Todos.PurgeTrash(c.Request.Context(), before)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
+
+// PurgeTodoById godoc
+// @Summary      Permanently delete a trashed todo
+// @Tags         trash
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Todo ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /trash/{id} [delete]
+func (h *TodoHandler) PurgeTodoById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Todos.Purge(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Todo with id %d purged", id)})
+}
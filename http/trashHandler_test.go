@@ -0,0 +1,90 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, router *gin.Engine, method, url string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	helpers.WithAPIKey(req)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDeletedTodoCanBeRestoredFromTrash(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Oops"}, models.Todo{Title: "Keep"})
+	id := strconv.Itoa(int(seeded[0].ID))
+
+	require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, "/todos/"+id).Code)
+	assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodGet, "/todos/"+id).Code)
+	assert.Equal(t, []string{"Keep"}, listTitles(t, router, "/todos"))
+	assert.Equal(t, []string{"Oops"}, listTitles(t, router, "/trash"))
+
+	rec := serve(t, router, http.MethodPost, "/todos/"+id+"/restore")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []string{"Oops", "Keep"}, listTitles(t, router, "/todos"))
+	assert.Empty(t, listTitles(t, router, "/trash"))
+
+	assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodPost, "/todos/"+id+"/restore").Code)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	router, db := helpers.SetupRouterWithSQLite(t)
+	seeded := helpers.SeedTodos(t, db, models.Todo{Title: "Gone one"}, models.Todo{Title: "Gone two"}, models.Todo{Title: "Live"})
+	for _, todo := range seeded[:2] {
+		require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, "/todos/"+strconv.Itoa(int(todo.ID))).Code)
+	}
+
+	// Live todos cannot be purged directly.
+	assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodDelete, "/trash/"+strconv.Itoa(int(seeded[2].ID))).Code)
+	assert.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, "/trash/"+strconv.Itoa(int(seeded[0].ID))).Code)
+
+	rec := serve(t, router, http.MethodDelete, "/trash")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Purged int64 `json:"purged"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, int64(1), body.Purged)
+
+	var remaining int64
+	db.Unscoped().Model(&models.Todo{}).Count(&remaining)
+	assert.Equal(t, int64(1), remaining)
+}
+
+func TestTodosCannotBeWrittenIntoTheTrash(t *testing.T) {
+	router, _ := helpers.SetupRouterWithSQLite(t)
+	const trashed = `"deleted_at": "2024-01-01T00:00:00Z"`
+
+	rec := sendWithAPIKey(t, router, helpers.TestAPIKey, http.MethodPost, "/todos", `{"todos": [{"title": "Sneaky", `+trashed+`}]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created struct {
+		Todos []models.Todo `json:"todos"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	url := "/todos/" + strconv.Itoa(int(created.Todos[0].ID))
+
+	rec = sendWithAPIKey(t, router, helpers.TestAPIKey, http.MethodPut, url, `{"title": "Sneaky", `+trashed+`}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = sendWithAPIKey(t, router, helpers.TestAPIKey, http.MethodPatch, "/todos", `{"todos": [{"id": `+strconv.Itoa(int(created.Todos[0].ID))+`, `+trashed+`}]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.Equal(t, []string{"Sneaky"}, listTitles(t, router, "/todos"))
+	assert.Empty(t, listTitles(t, router, "/trash"))
+	assert.Equal(t, http.StatusOK, serve(t, router, http.MethodGet, url).Code)
+}
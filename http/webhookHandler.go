@@ -0,0 +1,301 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler manages webhook subscriptions and their delivery log.
+type WebhookHandler struct {
+	Webhooks repository.WebhookStore
+}
+
+func ProvideWebhookHandler(webhooks repository.WebhookStore) *WebhookHandler {
+	return &WebhookHandler{Webhooks: webhooks}
+}
+
+// webhookRequest holds the fields of a webhook that clients may set. Absent
+// fields are left alone by PATCH.
+type webhookRequest struct {
+	URL    *string   `json:"url"`
+	Secret *string   `json:"secret"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active"`
+}
+
+// GetWebhooks godoc
+// @Summary      List webhooks
+// @Description  Secrets are never returned after a webhook has been created.
+// @Tags         webhooks
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Success      200  {object}  map[string][]models.Webhook
+// @Router       /webhooks [get]
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	webhooks, err := h.Webhooks.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+// AddWebhook godoc
+// @Summary      Subscribe a URL to todo events
+// @Description  events is a subset of todo.created, todo.updated, todo.completed and todo.deleted; leave it empty for all of them.
+// @Description  When no secret is given a random one is generated. The secret is only returned in this response.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string          true  "API key"
+// @Param        request    body    models.Webhook  true  "Webhook"
+// @Success      201  {object}  map[string]models.Webhook
+// @Failure      400  {object}  map[string]string
+// @Router       /webhooks [post]
+func (h *WebhookHandler) AddWebhook(c *gin.Context) {
+	var request webhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.URL == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url is required"})
+		return
+	}
+
+	webhook := models.Webhook{Active: true}
+	if err := request.applyTo(&webhook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if webhook.Secret == "" {
+		webhook.Secret = newWebhookSecret()
+	}
+
+	if err := h.Webhooks.CreateWebhook(c.Request.Context(), &webhook); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"webhook": webhook})
+}
+
+// GetWebhookById godoc
+// @Summary      Get webhook by ID
+// @Tags         webhooks
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Webhook ID"
+// @Success      200  {object}  map[string]models.Webhook
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhookById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.Webhooks.FindWebhook(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	webhook.Secret = ""
+
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+// UpdateWebhookById godoc
+// @Summary      Change a webhook
+// @Description  Only the fields present in the body are changed. Send a new secret to rotate it, or active=false to pause deliveries.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key  header  string          true  "API key"
+// @Param        id         path    int             true  "Webhook ID"
+// @Param        request    body    models.Webhook  true  "Fields to change"
+// @Success      200  {object}  map[string]models.Webhook
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /webhooks/{id} [patch]
+func (h *WebhookHandler) UpdateWebhookById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var request webhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := h.Webhooks.FindWebhook(c.Request.Context(), id)
+	if err == nil {
+		err = request.applyTo(webhook)
+	}
+	if err == nil {
+		err = h.Webhooks.UpdateWebhook(c.Request.Context(), webhook)
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	webhook.Secret = ""
+
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+// DeleteWebhookById godoc
+// @Summary      Delete a webhook
+// @Description  Pending deliveries are dropped along with the delivery log.
+// @Tags         webhooks
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Webhook ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhookById(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Webhooks.DeleteWebhook(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Webhook with id %d deleted successfully", id)})
+}
+
+// GetWebhookDeliveries godoc
+// @Summary      List the deliveries of a webhook
+// @Description  Newest first, with the payload sent, the number of attempts and the outcome of the last one.
+// @Tags         webhooks
+// @Produce      json
+// @Param        X-API-Key  header  string  true  "API key"
+// @Param        id         path    int     true "Webhook ID"
+// @Param        limit      query   int     false "Maximum number of deliveries"  default(50)
+// @Success      200  {object}  map[string][]models.WebhookDelivery
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	if _, err := h.Webhooks.FindWebhook(c.Request.Context(), id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	deliveries, err := h.Webhooks.ListDeliveries(c.Request.Context(), id, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// RedeliverWebhookDelivery godoc
+// @Summary      Send a delivery again
+// @Description  Queues a copy of the delivery with the same payload, whatever the outcome of the original. It is sent on the dispatcher's next run.
+// @Tags         webhooks
+// @Produce      json
+// @Param        X-API-Key    header  string  true  "API key"
+// @Param        id           path    int     true "Webhook ID"
+// @Param        delivery_id  path    int     true "Delivery ID"
+// @Success      202  {object}  map[string]models.WebhookDelivery
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) RedeliverWebhookDelivery(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 64)
+	if err != nil || deliveryID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "delivery_id must be a positive integer"})
+		return
+	}
+
+	original, err := h.Webhooks.FindDelivery(c.Request.Context(), id, uint(deliveryID))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	delivery := models.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now(),
+		RedeliveryOf:  &original.ID,
+	}
+	if err := h.Webhooks.AddDelivery(c.Request.Context(), &delivery); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"delivery": delivery})
+}
+
+// applyTo validates the fields present in the request and copies them onto
+// webhook.
+func (r webhookRequest) applyTo(webhook *models.Webhook) error {
+	if r.URL != nil {
+		parsed, err := url.Parse(*r.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("url must be an absolute http or https URL")
+		}
+		webhook.URL = *r.URL
+	}
+	if r.Secret != nil {
+		webhook.Secret = *r.Secret
+	}
+	if r.Events != nil {
+		for _, event := range *r.Events {
+			if !models.IsTodoEventType(event) {
+				return fmt.Errorf("unknown event %q; known events are %s", event, strings.Join(models.TodoEventTypes, ", "))
+			}
+		}
+		webhook.Events = slices.Compact(slices.Sorted(slices.Values(*r.Events)))
+	}
+	if r.Active != nil {
+		webhook.Active = *r.Active
+	}
+	return nil
+}
+
+// newWebhookSecret returns 32 random bytes, hex encoded.
+func newWebhookSecret() string {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return hex.EncodeToString(secret)
+}
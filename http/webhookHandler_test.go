@@ -0,0 +1,213 @@
+package http_test
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/worker"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver records the requests posted to it and answers them with
+// the queued statuses, then with 204.
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	received []receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	receiver := &webhookReceiver{statuses: statuses}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+		receiver.received = append(receiver.received, receivedWebhook{header: r.Header.Clone(), body: body})
+		status := http.StatusNoContent
+		if len(receiver.statuses) > 0 {
+			status, receiver.statuses = receiver.statuses[0], receiver.statuses[1:]
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, "receiver says "+strconv.Itoa(status))
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+func (r *webhookReceiver) events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []string
+	for _, received := range r.received {
+		events = append(events, received.header.Get("X-Webhook-Event"))
+	}
+	return events
+}
+
+func createWebhook(t *testing.T, router *gin.Engine, body string) models.Webhook {
+	t.Helper()
+
+	rec := sendWithKey(t, router, http.MethodPost, "/webhooks", "", body)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var response struct {
+		Webhook models.Webhook `json:"webhook"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	return response.Webhook
+}
+
+func listDeliveries(t *testing.T, router *gin.Engine, webhookID uint) []models.WebhookDelivery {
+	t.Helper()
+
+	rec := serve(t, router, http.MethodGet, "/webhooks/"+strconv.Itoa(int(webhookID))+"/deliveries")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Deliveries []models.WebhookDelivery `json:"deliveries"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	return response.Deliveries
+}
+
+func TestWebhooksDeliverSignedEventsWithRetries(t *testing.T) {
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError)
+	router, dispatcher := helpers.SetupRouterWithWebhooks(t, receiver.Client())
+	webhook := createWebhook(t, router, `{"url": "`+receiver.URL+`", "events": ["todo.completed", "todo.created"]}`)
+	require.NotEmpty(t, webhook.Secret)
+	assert.Equal(t, models.EventTypes{"todo.completed", "todo.created"}, webhook.Events)
+	assert.True(t, webhook.Active)
+
+	todos := createTodos(t, router, `{"todos": [{"title": "Ship it"}]}`)
+	id := strconv.Itoa(int(todos[0].ID))
+	rec := sendPatch(t, router, "/todos/"+id, "application/json", `{"description": "not subscribed"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = sendPatch(t, router, "/todos/"+id, "application/json", `{"complete": true}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	// A batch that rolls back announces nothing.
+	rec = sendWithKey(t, router, http.MethodPost, "/todos", "", `{"todos": [{"title": "Fresh"}, {"title": "Ship it"}]}`)
+	require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+
+	// The receiver fails the first delivery, which is retried after a backoff.
+	now := time.Now()
+	sent, err := dispatcher.RunOnce(t.Context(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	deliveries := listDeliveries(t, router, webhook.ID)
+	require.Len(t, deliveries, 2)
+	completed, created := deliveries[0], deliveries[1]
+	assert.Equal(t, models.EventTodoCompleted, completed.Event)
+	assert.Equal(t, models.DeliveryDelivered, completed.Status)
+	assert.Equal(t, models.EventTodoCreated, created.Event)
+	assert.Equal(t, models.DeliveryPending, created.Status)
+	assert.Equal(t, http.StatusInternalServerError, created.ResponseStatus)
+	assert.Contains(t, created.LastError, "receiver says 500")
+
+	sent, err = dispatcher.RunOnce(t.Context(), now)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	sent, err = dispatcher.RunOnce(t.Context(), now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"todo.created", "todo.completed", "todo.created"}, receiver.events())
+
+	for _, received := range receiver.received {
+		signature := received.header.Get("X-Webhook-Signature-256")
+		assert.True(t, hmac.Equal([]byte(worker.SignWebhookPayload(webhook.Secret, received.body)), []byte(signature)))
+		var event models.TodoEvent
+		require.NoError(t, json.Unmarshal(received.body, &event))
+		assert.Equal(t, "Ship it", event.Todo.Title)
+		assert.Equal(t, received.header.Get("X-Webhook-Event"), event.Type)
+	}
+
+	// Redelivery queues a copy with the same payload.
+	rec = serve(t, router, http.MethodPost, "/webhooks/"+strconv.Itoa(int(webhook.ID))+"/deliveries/"+strconv.Itoa(int(completed.ID))+"/redeliver")
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	sent, err = dispatcher.RunOnce(t.Context(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, receiver.received, 4)
+	assert.Equal(t, receiver.received[1].body, receiver.received[3].body)
+	deliveries = listDeliveries(t, router, webhook.ID)
+	require.NotNil(t, deliveries[0].RedeliveryOf)
+	assert.Equal(t, completed.ID, *deliveries[0].RedeliveryOf)
+
+	assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodPost, "/webhooks/"+strconv.Itoa(int(webhook.ID))+"/deliveries/999/redeliver").Code)
+}
+
+func TestWebhookDeliveriesGiveUpAfterMaxAttempts(t *testing.T) {
+	statuses := []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}
+	receiver := newWebhookReceiver(t, statuses...)
+	router, dispatcher := helpers.SetupRouterWithWebhooks(t, receiver.Client())
+	webhook := createWebhook(t, router, `{"url": "`+receiver.URL+`", "secret": "s3cret"}`)
+	assert.Equal(t, "s3cret", webhook.Secret)
+
+	todos := createTodos(t, router, `{"todos": [{"title": "Flaky"}]}`)
+	rec := serve(t, router, http.MethodDelete, "/todos/"+strconv.Itoa(int(todos[0].ID)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// The dispatcher is set up with three attempts, one second apart at first.
+	now := time.Now()
+	for _, at := range []time.Duration{0, time.Second, 3 * time.Second, time.Hour} {
+		_, err := dispatcher.RunOnce(t.Context(), now.Add(at))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"todo.created", "todo.deleted", "todo.created", "todo.deleted", "todo.created", "todo.deleted"}, receiver.events())
+	for _, delivery := range listDeliveries(t, router, webhook.ID) {
+		assert.Equal(t, models.DeliveryFailed, delivery.Status)
+		assert.Equal(t, 3, delivery.Attempts)
+	}
+
+	// Paused webhooks receive nothing.
+	rec = sendPatch(t, router, "/webhooks/"+strconv.Itoa(int(webhook.ID)), "application/json", `{"active": false}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "s3cret")
+	createTodos(t, router, `{"todos": [{"title": "Quiet"}]}`)
+	assert.Len(t, listDeliveries(t, router, webhook.ID), 2)
+}
+
+func TestWebhookValidation(t *testing.T) {
+	router, _ := helpers.SetupRouterWithWebhooks(t, nil)
+
+	for _, body := range []string{
+		`{}`,
+		`{"url": "ftp://example.com/hook"}`,
+		`{"url": "/relative"}`,
+		`{"url": "https://example.com/hook", "events": ["todo.exploded"]}`,
+	} {
+		rec := sendWithKey(t, router, http.MethodPost, "/webhooks", "", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+
+	webhook := createWebhook(t, router, `{"url": "https://example.com/hook"}`)
+	id := strconv.Itoa(int(webhook.ID))
+	rec := serve(t, router, http.MethodGet, "/webhooks/"+id)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), webhook.Secret)
+	rec = serve(t, router, http.MethodGet, "/webhooks")
+	assert.NotContains(t, rec.Body.String(), webhook.Secret)
+
+	rec = sendPatch(t, router, "/webhooks/"+id, "application/json", `{"events": ["todo.deleted"]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"events":["todo.deleted"]`)
+
+	require.Equal(t, http.StatusOK, serve(t, router, http.MethodDelete, "/webhooks/"+id).Code)
+	assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodGet, "/webhooks/"+id).Code)
+	assert.Equal(t, http.StatusNotFound, serve(t, router, http.MethodGet, "/webhooks/"+id+"/deliveries").Code)
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// ScopeTodosRead allows reading todos, tags, projects and their events.
+	ScopeTodosRead = "todos:read"
+	// ScopeTodosWrite allows changing them, and implies ScopeTodosRead.
+	ScopeTodosWrite = "todos:write"
+	// ScopeAdmin allows everything, including managing API keys and webhooks.
+	ScopeAdmin = "admin"
+
+	maxAPIKeyNameLength = 100
+)
+
+// Scopes lists what an API key may do. It is stored as a JSON array.
+type Scopes []string
+
+func (s Scopes) Value() (driver.Value, error) {
+	return jsonValue(s)
+}
+
+func (s *Scopes) Scan(value any) error {
+	return scanJSON(value, s)
+}
+
+// Allows reports whether the scopes grant scope, directly or through a
+// broader scope.
+func (s Scopes) Allows(scope string) bool {
+	switch {
+	case slices.Contains(s, ScopeAdmin), slices.Contains(s, scope):
+		return true
+	case scope == ScopeTodosRead:
+		return slices.Contains(s, ScopeTodosWrite)
+	}
+	return false
+}
+
+// NormalizeScopes checks that every scope is known and removes duplicates.
+func NormalizeScopes(scopes []string) (Scopes, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	normalized := Scopes{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		switch scope {
+		case ScopeTodosRead, ScopeTodosWrite, ScopeAdmin:
+		default:
+			return nil, fmt.Errorf("unknown scope %q; use %s, %s or %s", scope, ScopeTodosRead, ScopeTodosWrite, ScopeAdmin)
+		}
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+// APIKey lets a program call the API without a user account. Only the
+// SHA-256 hash of the key is stored; the key itself is shown once, when it is
+// created or rotated. Requests made with a key see every user's todos.
+type APIKey struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"size:100;not null"`
+	// Prefix is the start of the key, to tell keys apart without the key.
+	Prefix     string     `json:"prefix" gorm:"size:16;not null"`
+	Hash       string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Scopes     Scopes     `json:"scopes" gorm:"type:text"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Active reports whether the key can be used at the given time.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
+
+// NormalizeAPIKeyName trims a key name and checks its length.
+func NormalizeAPIKeyName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	if utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return "", fmt.Errorf("name must be at most %d characters", maxAPIKeyNameLength)
+	}
+	return name, nil
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers the outcome of a mutating request sent with an
+// Idempotency-Key header so that retries get the original response back.
+type IdempotencyKey struct {
+	Key string `gorm:"primaryKey;size:255"`
+	// Fingerprint is a SHA-256 of the method, URL and body of the first request.
+	Fingerprint string `gorm:"size:64;not null"`
+	// StatusCode stays zero while the first request is still being handled.
+	StatusCode int
+	Header     map[string][]string `gorm:"serializer:json;type:text"`
+	Body       []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time `gorm:"not null;index"`
+}
+
+// Completed reports whether the response of the first request was recorded.
+func (k IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Project groups todos, e.g. per team. Todo titles only have to be unique
+// within their project. Like tags, projects belong to a user and their names
+// are unique per user.
+type Project struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	OwnerID     uint      `json:"-" gorm:"not null;default:0;uniqueIndex:idx_projects_owner_name,priority:1"`
+	Name        string    `json:"name" gorm:"size:255;uniqueIndex:idx_projects_owner_name,priority:2;not null"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
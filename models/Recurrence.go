@@ -0,0 +1,132 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	// Embed the zone database so timezones resolve on hosts without one.
+	_ "time/tzdata"
+
+	"github.com/teambition/rrule-go"
+)
+
+// NormalizeRecurrence checks the timezone and recurrence rule of the todo and
+// upper-cases the rule, dropping an "RRULE:" prefix. The due date starts the
+// series, so a recurring todo needs one and the rule may not set DTSTART.
+func (t *Todo) NormalizeRecurrence() error {
+	loc, err := t.location()
+	if err != nil {
+		return err
+	}
+	if t.Recurrence == "" {
+		return nil
+	}
+
+	t.Recurrence = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(t.Recurrence)), "RRULE:")
+	if _, err := parseRecurrence(t.Recurrence, loc); err != nil {
+		return err
+	}
+	if t.DueDate == nil {
+		return errors.New("a recurring todo needs a due date")
+	}
+	return nil
+}
+
+// NextOccurrence returns the todo that follows t in its series, or nil when
+// the rule has no dates left. The next due date keeps the wall-clock time of
+// the current one in the todo's timezone, across daylight saving changes, and
+// a COUNT in the rule counts down with every occurrence. A reminder keeps its
+// distance to the due date.
+func (t *Todo) NextOccurrence() (*Todo, error) {
+	if t.Recurrence == "" || t.DueDate == nil {
+		return nil, nil
+	}
+	loc, err := t.location()
+	if err != nil {
+		return nil, err
+	}
+	option, err := parseRecurrence(t.Recurrence, loc)
+	if err != nil {
+		return nil, err
+	}
+	remaining := option.Count
+	if remaining == 1 {
+		return nil, nil
+	}
+
+	option.Count = 0
+	option.Dtstart = t.DueDate.In(loc)
+	rule, err := rrule.NewRRule(*option)
+	if err != nil {
+		return nil, err
+	}
+	due := rule.After(option.Dtstart, false)
+	if due.IsZero() {
+		return nil, nil
+	}
+
+	next := &Todo{
+		OwnerID:     t.OwnerID,
+		Title:       t.Title,
+		Description: t.Description,
+		DueDate:     &due,
+		ProjectID:   t.ProjectID,
+		ParentID:    t.ParentID,
+		Recurrence:  t.Recurrence,
+		Timezone:    t.Timezone,
+		Tags:        make([]Tag, len(t.Tags)),
+	}
+	for i, tag := range t.Tags {
+		next.Tags[i] = Tag{Name: tag.Name}
+	}
+	if t.RemindAt != nil {
+		remindAt := due.Add(t.RemindAt.Sub(*t.DueDate))
+		next.RemindAt = &remindAt
+	}
+	if remaining > 1 {
+		next.Recurrence = withCount(t.Recurrence, remaining-1)
+	}
+	return next, nil
+}
+
+func (t *Todo) location() (*time.Location, error) {
+	if t.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", t.Timezone)
+	}
+	return loc, nil
+}
+
+// parseRecurrence parses an upper-case rule. Floating UNTIL times are read in
+// loc.
+func parseRecurrence(recurrence string, loc *time.Location) (*rrule.ROption, error) {
+	option, err := rrule.StrToROptionInLocation(recurrence, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence: %w", err)
+	}
+	if !option.Dtstart.IsZero() {
+		return nil, errors.New("invalid recurrence: DTSTART is taken from the due date")
+	}
+	if option.Count < 0 || option.Interval < 0 {
+		return nil, errors.New("invalid recurrence: COUNT and INTERVAL must be positive")
+	}
+	if _, err := rrule.NewRRule(*option); err != nil {
+		return nil, fmt.Errorf("invalid recurrence: %w", err)
+	}
+	return option, nil
+}
+
+func withCount(recurrence string, count int) string {
+	parts := strings.Split(recurrence, ";")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "COUNT=") {
+			parts[i] = "COUNT=" + strconv.Itoa(count)
+		}
+	}
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	// ReminderDue announces a todo that is about to fall due or is overdue.
+	ReminderDue = "due"
+	// ReminderRemindAt is sent at a todo's RemindAt time.
+	ReminderRemindAt = "remind_at"
+)
+
+const (
+	ReminderPending   = "pending"
+	ReminderDelivered = "delivered"
+	// ReminderCancelled marks reminders that became moot before they were
+	// delivered, because the todo was completed, deleted or rescheduled.
+	ReminderCancelled = "cancelled"
+)
+
+// Reminder tracks the delivery of one notification about a todo. It is stored
+// before the notification is sent and only marked delivered afterwards, so
+// every reminder is delivered at least once, even across restarts.
+type Reminder struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	TodoID uint   `json:"todo_id" gorm:"not null;uniqueIndex:idx_reminders_todo_kind_at"`
+	Kind   string `json:"kind" gorm:"size:16;not null;uniqueIndex:idx_reminders_todo_kind_at"`
+	// At is the due date or reminder time being announced.
+	At            time.Time  `json:"at" gorm:"not null;uniqueIndex:idx_reminders_todo_kind_at"`
+	Status        string     `json:"status" gorm:"size:16;not null;index:idx_reminders_status_next_attempt,priority:1"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty" gorm:"type:text"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null;index:idx_reminders_status_next_attempt,priority:2"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
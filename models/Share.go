@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Share gives a user a role (viewer, editor or owner) on a todo or project
+// that belongs to someone else. Exactly one of TodoID and ProjectID is set,
+// and a user has at most one share per todo or project.
+type Share struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	TodoID    *uint  `json:"todo_id,omitempty" gorm:"uniqueIndex:idx_shares_todo_user,priority:1"`
+	ProjectID *uint  `json:"project_id,omitempty" gorm:"uniqueIndex:idx_shares_project_user,priority:1"`
+	UserID    uint   `json:"user_id" gorm:"not null;index;uniqueIndex:idx_shares_todo_user,priority:2;uniqueIndex:idx_shares_project_user,priority:2"`
+	Role      string `json:"role" gorm:"size:16;not null"`
+	// Username is the name of the user the share is for. It is filled in by
+	// the API.
+	Username  string    `json:"username" gorm:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const maxTagNameLength = 64
+
+var tagColourPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// Tag labels todos. Every user has tags of their own, whose names are unique
+// among them; tags of todos created with the shared API key have no owner.
+// The colour is an optional #rrggbb value
+// for clients to render the tag with.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	OwnerID   uint      `json:"-" gorm:"not null;default:0;uniqueIndex:idx_tags_owner_name,priority:1"`
+	Name      string    `json:"name" gorm:"size:64;uniqueIndex:idx_tags_owner_name,priority:2;not null"`
+	Colour    string    `json:"colour,omitempty" gorm:"size:7"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UnmarshalJSON also accepts a bare string as the tag name, so that todo
+// payloads can list tags as ["work", "home"].
+func (t *Tag) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = Tag{Name: name}
+		return nil
+	}
+
+	type plain Tag
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+// Normalize trims the name, lower-cases the colour and checks both.
+func (t *Tag) Normalize() error {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Colour = strings.ToLower(strings.TrimSpace(t.Colour))
+	if t.Name == "" {
+		return errors.New("tag name is required")
+	}
+	if len(t.Name) > maxTagNameLength {
+		return errors.New("tag name must be at most 64 characters")
+	}
+	if t.Colour != "" && !tagColourPattern.MatchString(t.Colour) {
+		return errors.New("tag colour must look like #1e90ff")
+	}
+	return nil
+}
+
+// TagNames lists the names of tags in their current order.
+func TagNames(tags []Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
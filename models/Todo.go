@@ -1,13 +1,56 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Todo struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Title       string    `json:"title" gorm:"unique;not null"`
-	Description string    `json:"description,omitempty"`
-	DueDate     time.Time `json:"due_date,omitempty"`
-	Complete    bool      `json:"complete" gorm:"default:false"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID uint `json:"id" gorm:"primaryKey"`
+	// OwnerID is the user the todo belongs to, or zero for todos created with
+	// an API key. It is set by the API; clients cannot choose it.
+	OwnerID     uint       `json:"owner_id,omitempty" gorm:"not null;default:0;index;uniqueIndex:idx_todos_owner_scope_title,priority:1"`
+	Title       string     `json:"title" gorm:"not null;index:idx_todos_project_title,priority:2,length:191;uniqueIndex:idx_todos_owner_scope_title,priority:3,length:191"`
+	Description string     `json:"description,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	// RemindAt asks for a reminder at that time, on top of the one sent when
+	// the todo falls due.
+	RemindAt *time.Time `json:"remind_at,omitempty" gorm:"index"`
+	Complete bool       `json:"complete" gorm:"default:false"`
+	// ProjectID is nil for todos outside of any project. Titles are unique
+	// per project, and among todos without a project.
+	ProjectID *uint    `json:"project_id" gorm:"index:idx_todos_project_title,priority:1"`
+	Project   *Project `json:"-" gorm:"constraint:OnDelete:RESTRICT"`
+	// TitleScope backs the unique index on titles: it is the project id, or
+	// zero outside of projects. Occurrences of a recurring series, which may
+	// share their title, leave it nil and so escape the index. The repository
+	// maintains it.
+	TitleScope *uint `json:"-" gorm:"uniqueIndex:idx_todos_owner_scope_title,priority:2"`
+	// ParentID makes the todo a subtask of another todo.
+	ParentID *uint `json:"parent_id" gorm:"index"`
+	Parent   *Todo `json:"-"`
+	// Tags are sorted by name. On writes a nil slice leaves the tags of an
+	// existing todo alone, except for a full replace which clears them.
+	Tags []Tag `json:"tags" gorm:"many2many:todo_tags"`
+	// Recurrence is an iCalendar RRULE such as FREQ=WEEKLY;BYDAY=MO. Completing
+	// a recurring todo creates its next occurrence, due on the rule's next
+	// date after DueDate on the wall clock of Timezone (an IANA zone, UTC when
+	// empty).
+	Recurrence string `json:"recurrence,omitempty" gorm:"size:255"`
+	Timezone   string `json:"timezone,omitempty" gorm:"size:64"`
+	// SeriesID links the occurrences of a recurring todo, which may share a
+	// title. It is set by the API.
+	SeriesID *uint `json:"series_id,omitempty" gorm:"index"`
+	// BlockedBy lists the live todos this one waits for, and Blocked is set
+	// while any of them is still open. Both are computed on reads.
+	BlockedBy []uint `json:"blocked_by,omitempty" gorm:"-"`
+	Blocked   bool   `json:"blocked" gorm:"-"`
+	// Version is bumped on every write and doubles as the todo's ETag.
+	Version   uint      `json:"version" gorm:"not null;default:1"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is set while the todo sits in the trash. Only deletes set it;
+	// request bodies cannot.
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggertype:"string"`
 }
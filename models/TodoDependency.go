@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TodoDependency records that a todo is blocked by another todo until the
+// blocker is complete.
+type TodoDependency struct {
+	TodoID    uint      `json:"todo_id" gorm:"primaryKey;autoIncrement:false"`
+	BlockerID uint      `json:"blocker_id" gorm:"primaryKey;autoIncrement:false;index"`
+	Todo      *Todo     `json:"-"`
+	Blocker   *Todo     `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	EventTodoCreated = "todo.created"
+	EventTodoUpdated = "todo.updated"
+	// EventTodoCompleted replaces todo.updated for the write that completes a
+	// todo.
+	EventTodoCompleted = "todo.completed"
+	EventTodoDeleted   = "todo.deleted"
+)
+
+// TodoEventTypes lists every event type, in the order they are documented.
+var TodoEventTypes = []string{EventTodoCreated, EventTodoUpdated, EventTodoCompleted, EventTodoDeleted}
+
+// IsTodoEventType reports whether name appears in TodoEventTypes.
+func IsTodoEventType(name string) bool {
+	return slices.Contains(TodoEventTypes, name)
+}
+
+// TodoEvent announces a change made to a todo, carrying the todo as it was
+// right after the change (or right before it was deleted). Events are kept in
+// an append-only log whose IDs grow in the order the events were published.
+type TodoEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Type       string    `json:"type" gorm:"size:32;not null"`
+	OccurredAt time.Time `json:"occurred_at" gorm:"not null;index"`
+	Todo       Todo      `json:"todo" gorm:"type:text;serializer:json"`
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+)
+
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+	RevisionRevert  = "revert"
+)
+
+// TodoRevision records one change made to a todo. Revisions are numbered per
+// todo starting at 1 and are kept after the todo itself is purged.
+type TodoRevision struct {
+	ID       uint   `json:"-" gorm:"primaryKey"`
+	TodoID   uint   `json:"todo_id" gorm:"not null;uniqueIndex:idx_todo_revisions_todo_revision,priority:1"`
+	Revision uint   `json:"revision" gorm:"not null;uniqueIndex:idx_todo_revisions_todo_revision,priority:2"`
+	Action   string `json:"action" gorm:"size:16;not null"`
+	Actor    string `json:"actor" gorm:"size:255"`
+	// RevertedTo names the revision whose snapshot a revert restored.
+	RevertedTo *uint        `json:"reverted_to,omitempty"`
+	Changes    FieldChanges `json:"changes" gorm:"type:text"`
+	// Snapshot is the state of the todo after this revision was applied.
+	Snapshot  TodoSnapshot `json:"snapshot" gorm:"type:text"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// TodoSnapshot holds the user-editable fields of a todo.
+type TodoSnapshot struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueDate     *time.Time `json:"due_date"`
+	RemindAt    *time.Time `json:"remind_at,omitempty"`
+	Complete    bool       `json:"complete"`
+	Tags        []string   `json:"tags,omitempty"`
+	ProjectID   *uint      `json:"project_id,omitempty"`
+	ParentID    *uint      `json:"parent_id,omitempty"`
+	Recurrence  string     `json:"recurrence,omitempty"`
+	Timezone    string     `json:"timezone,omitempty"`
+}
+
+func SnapshotOf(todo *Todo) TodoSnapshot {
+	snapshot := TodoSnapshot{
+		Title:       todo.Title,
+		Description: todo.Description,
+		DueDate:     todo.DueDate,
+		RemindAt:    todo.RemindAt,
+		Complete:    todo.Complete,
+		ProjectID:   todo.ProjectID,
+		ParentID:    todo.ParentID,
+		Recurrence:  todo.Recurrence,
+		Timezone:    todo.Timezone,
+	}
+	if len(todo.Tags) > 0 {
+		snapshot.Tags = TagNames(todo.Tags)
+		slices.Sort(snapshot.Tags)
+	}
+	return snapshot
+}
+
+// ApplyTo copies the snapshot onto todo, leaving its identity and timestamps alone.
+func (s TodoSnapshot) ApplyTo(todo *Todo) {
+	todo.Title = s.Title
+	todo.Description = s.Description
+	todo.DueDate = s.DueDate
+	todo.RemindAt = s.RemindAt
+	todo.Complete = s.Complete
+	todo.ProjectID = s.ProjectID
+	todo.ParentID = s.ParentID
+	todo.Recurrence = s.Recurrence
+	todo.Timezone = s.Timezone
+	todo.Tags = make([]Tag, len(s.Tags))
+	for i, name := range s.Tags {
+		todo.Tags[i] = Tag{Name: name}
+	}
+}
+
+// Diff lists the fields that differ between s and next, keyed by JSON name.
+func (s TodoSnapshot) Diff(next TodoSnapshot) FieldChanges {
+	before, after := s.fields(), next.fields()
+	changes := FieldChanges{}
+	for name, from := range before {
+		if to := after[name]; !reflect.DeepEqual(from, to) {
+			changes[name] = FieldChange{From: from, To: to}
+		}
+	}
+	return changes
+}
+
+func (s TodoSnapshot) fields() map[string]any {
+	fields := map[string]any{}
+	raw, _ := json.Marshal(s)
+	_ = json.Unmarshal(raw, &fields)
+	return fields
+}
+
+func (s TodoSnapshot) Value() (driver.Value, error) {
+	return jsonValue(s)
+}
+
+func (s *TodoSnapshot) Scan(value any) error {
+	return scanJSON(value, s)
+}
+
+// FieldChange is the before and after value of a single field.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+type FieldChanges map[string]FieldChange
+
+func (c FieldChanges) Value() (driver.Value, error) {
+	return jsonValue(c)
+}
+
+func (c *FieldChanges) Scan(value any) error {
+	return scanJSON(value, c)
+}
+
+func jsonValue(v any) (driver.Value, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func scanJSON(value any, dest any) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	}
+	return fmt.Errorf("cannot scan %T into %T", value, dest)
+}
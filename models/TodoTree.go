@@ -0,0 +1,16 @@
+package models
+
+// Progress summarises how many of a todo's descendants are complete. A todo
+// without subtasks is either 0 or 100 percent done.
+type Progress struct {
+	Complete int `json:"complete"`
+	Total    int `json:"total"`
+	Percent  int `json:"percent"`
+}
+
+// TodoNode is a todo together with its subtasks, nested to any depth.
+type TodoNode struct {
+	Todo
+	Progress Progress   `json:"progress"`
+	Children []TodoNode `json:"children"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxUsernameLength = 64
+	minPasswordLength = 8
+	// maxPasswordLength is the most bcrypt looks at.
+	maxPasswordLength = 72
+)
+
+// User owns todos, tags and projects. The password is only kept as a bcrypt
+// hash. Users created for the subject of a JWT have none and cannot log in
+// with a password.
+type User struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Username     string    `json:"username" gorm:"size:64;uniqueIndex;not null"`
+	PasswordHash string    `json:"-" gorm:"size:255;not null"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// AuthToken is a login session. Only the SHA-256 hash of the token handed to
+// the user is stored, so a leaked table cannot be replayed.
+type AuthToken struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	UserID    uint      `json:"-" gorm:"not null;index"`
+	User      User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Hash      string    `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalizeUsername trims a username and checks its length.
+func NormalizeUsername(username string) (string, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return "", errors.New("username is required")
+	}
+	if utf8.RuneCountInString(username) > maxUsernameLength {
+		return "", errors.New("username must be at most 64 characters")
+	}
+	return username, nil
+}
+
+// CheckPassword enforces the password length bcrypt can handle.
+func CheckPassword(password string) error {
+	if len(password) < minPasswordLength {
+		return errors.New("password must be at least 8 characters")
+	}
+	if len(password) > maxPasswordLength {
+		return errors.New("password must be at most 72 bytes")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// DeliveryFailed marks deliveries that ran out of attempts. They are only
+	// sent again when redelivered by hand.
+	DeliveryFailed = "failed"
+)
+
+// Webhook subscribes a URL to todo events. Every delivery is signed with the
+// secret, which is only ever returned when the webhook is created.
+type Webhook struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	URL    string `json:"url" gorm:"size:2048;not null"`
+	Secret string `json:"secret,omitempty" gorm:"size:255;not null"`
+	// Events limits the subscription to the named event types; empty means
+	// every type.
+	Events    EventTypes `json:"events" gorm:"type:text"`
+	Active    bool       `json:"active" gorm:"not null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Wants reports whether the webhook is active and subscribed to eventType.
+func (w *Webhook) Wants(eventType string) bool {
+	return w.Active && (len(w.Events) == 0 || slices.Contains(w.Events, eventType))
+}
+
+// EventTypes is a list of event type names stored as a JSON array.
+type EventTypes []string
+
+func (e EventTypes) Value() (driver.Value, error) {
+	return jsonValue(e)
+}
+
+func (e *EventTypes) Scan(value any) error {
+	return scanJSON(value, e)
+}
+
+// WebhookDelivery is one attempt, possibly retried, at posting an event to a
+// webhook. Deliveries double as the webhook's delivery log.
+type WebhookDelivery struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	WebhookID uint   `json:"webhook_id" gorm:"not null;index"`
+	Event     string `json:"event" gorm:"size:32;not null"`
+	// Payload is the exact body that is signed and posted.
+	Payload       json.RawMessage `json:"payload" gorm:"type:text;not null" swaggertype:"object"`
+	Status        string          `json:"status" gorm:"size:16;not null;index:idx_webhook_deliveries_status_next_attempt,priority:1"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" gorm:"not null;index:idx_webhook_deliveries_status_next_attempt,priority:2"`
+	// ResponseStatus is the HTTP status of the last attempt, or 0 when the
+	// receiver could not be reached.
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty" gorm:"type:text"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	// RedeliveryOf names the delivery this one was copied from by hand.
+	RedeliveryOf *uint     `json:"redelivery_of,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// LogNotifier writes notifications to a logger.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogNotifier logs to logger, or to the standard logger when it is nil.
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.logger.Printf("%s (todo %d)", notification.Subject(), notification.Todo.ID)
+	return nil
+}
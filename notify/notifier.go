@@ -0,0 +1,85 @@
+// Package notify delivers reminders about todos to people.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+// Notification is a reminder about a todo, ready to be delivered.
+type Notification struct {
+	// Kind is models.ReminderDue or models.ReminderRemindAt.
+	Kind string      `json:"kind"`
+	At   time.Time   `json:"at"`
+	Todo models.Todo `json:"todo"`
+}
+
+// Subject is a one-line summary of the notification.
+func (n Notification) Subject() string {
+	if n.Kind == models.ReminderRemindAt {
+		return fmt.Sprintf("Reminder: %s", n.Todo.Title)
+	}
+	if n.At.After(time.Now()) {
+		return fmt.Sprintf("Due %s: %s", n.At.Format(time.RFC1123), n.Todo.Title)
+	}
+	return fmt.Sprintf("Overdue since %s: %s", n.At.Format(time.RFC1123), n.Todo.Title)
+}
+
+// Notifier delivers notifications. Notify may be called more than once for
+// the same notification, so receivers should tolerate duplicates.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// Multi sends every notification to all of the notifiers. It fails when any
+// of them fails, so a retry reaches the others again as well.
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, notification Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ProvideNotifier builds the notifiers listed in REMINDER_NOTIFIERS, a comma
+// separated list of log, webhook and smtp that defaults to log.
+func ProvideNotifier() (Notifier, error) {
+	names := os.Getenv("REMINDER_NOTIFIERS")
+	if names == "" {
+		names = "log"
+	}
+
+	var notifiers Multi
+	for _, name := range strings.Split(names, ",") {
+		var notifier Notifier
+		var err error
+		switch strings.TrimSpace(name) {
+		case "log":
+			notifier = NewLogNotifier(nil)
+		case "webhook":
+			notifier, err = webhookFromEnv()
+		case "smtp":
+			notifier, err = smtpFromEnv()
+		default:
+			err = fmt.Errorf("unknown reminder notifier %q", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	if len(notifiers) == 1 {
+		return notifiers[0], nil
+	}
+	return notifiers, nil
+}
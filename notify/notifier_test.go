@@ -0,0 +1,68 @@
+package notify_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNotification() notify.Notification {
+	return notify.Notification{
+		Kind: models.ReminderRemindAt,
+		At:   time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC),
+		Todo: models.Todo{ID: 7, Title: "Water plants"},
+	}
+}
+
+func TestLogNotifier(t *testing.T) {
+	var out bytes.Buffer
+	notifier := notify.NewLogNotifier(log.New(&out, "", 0))
+
+	require.NoError(t, notifier.Notify(t.Context(), testNotification()))
+	assert.Equal(t, "Reminder: Water plants (todo 7)\n", out.String())
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	status := http.StatusNoContent
+	var received notify.Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	notifier := notify.NewWebhookNotifier(server.URL, server.Client())
+
+	require.NoError(t, notifier.Notify(t.Context(), testNotification()))
+	assert.Equal(t, "Water plants", received.Todo.Title)
+	assert.Equal(t, models.ReminderRemindAt, received.Kind)
+
+	status = http.StatusInternalServerError
+	assert.Error(t, notifier.Notify(t.Context(), testNotification()))
+
+	// A failing receiver fails the whole fan-out so the reminder is retried.
+	var out bytes.Buffer
+	multi := notify.Multi{notify.NewLogNotifier(log.New(&out, "", 0)), notifier}
+	assert.Error(t, multi.Notify(t.Context(), testNotification()))
+	assert.NotEmpty(t, out.String())
+}
+
+func TestProvideNotifierRejectsUnknownNames(t *testing.T) {
+	t.Setenv("REMINDER_NOTIFIERS", "log,pager")
+	_, err := notify.ProvideNotifier()
+	assert.ErrorContains(t, err, "pager")
+
+	t.Setenv("REMINDER_NOTIFIERS", "webhook")
+	t.Setenv("REMINDER_WEBHOOK_URL", "")
+	_, err = notify.ProvideNotifier()
+	assert.Error(t, err)
+}
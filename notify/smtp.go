@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier emails notifications through an SMTP server.
+type SMTPNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewSMTPNotifier sends mail from one address to the recipients through the
+// server at addr (host:port). auth may be nil for servers without login.
+func NewSMTPNotifier(addr string, auth smtp.Auth, from string, to []string) *SMTPNotifier {
+	return &SMTPNotifier{addr: addr, auth: auth, from: from, to: to}
+}
+
+// smtpFromEnv reads SMTP_ADDR, SMTP_FROM and REMINDER_EMAIL_TO (comma
+// separated), and logs in with SMTP_USER and SMTP_PASS when they are set.
+func smtpFromEnv() (*SMTPNotifier, error) {
+	addr, from := os.Getenv("SMTP_ADDR"), os.Getenv("SMTP_FROM")
+	var to []string
+	for _, recipient := range strings.Split(os.Getenv("REMINDER_EMAIL_TO"), ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			to = append(to, recipient)
+		}
+	}
+	if addr == "" || from == "" || len(to) == 0 {
+		return nil, errors.New("SMTP_ADDR, SMTP_FROM and REMINDER_EMAIL_TO are required for the smtp notifier")
+	}
+
+	var auth smtp.Auth
+	if user := os.Getenv("SMTP_USER"); user != "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("SMTP_ADDR: %w", err)
+		}
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASS"), host)
+	}
+	return NewSMTPNotifier(addr, auth, from, to), nil
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, notification Notification) error {
+	return smtp.SendMail(n.addr, n.auth, n.from, n.to, n.message(notification))
+}
+
+func (n *SMTPNotifier) message(notification Notification) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", notification.Subject())
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n", notification.Todo.Title)
+	if notification.Todo.Description != "" {
+		fmt.Fprintf(&msg, "\r\n%s\r\n", notification.Todo.Description)
+	}
+	if due := notification.Todo.DueDate; due != nil {
+		fmt.Fprintf(&msg, "\r\nDue: %s\r\n", due.Format(time.RFC1123))
+	}
+	return msg.Bytes()
+}
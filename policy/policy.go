@@ -0,0 +1,163 @@
+// Package policy decides what a user may do with a todo or project. It knows
+// nothing about HTTP or storage: callers describe who owns a resource and who
+// it is shared with, and get back the user's role and whether an action is
+// allowed.
+package policy
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Role is what a user may do with a todo or project. Roles are ordered:
+// every role may do everything the roles below it may.
+type Role string
+
+const (
+	// RoleNone means the resource is not visible to the user at all.
+	RoleNone Role = ""
+	// RoleViewer may read the resource.
+	RoleViewer Role = "viewer"
+	// RoleEditor may also change it and, for projects, add todos to it.
+	RoleEditor Role = "editor"
+	// RoleOwner may also delete it and share it with others.
+	RoleOwner Role = "owner"
+)
+
+// Roles lists the roles a resource can be shared with, lowest first.
+var Roles = []Role{RoleViewer, RoleEditor, RoleOwner}
+
+// ParseRole returns the role with the given name.
+func ParseRole(name string) (Role, error) {
+	for _, role := range Roles {
+		if string(role) == name {
+			return role, nil
+		}
+	}
+	return RoleNone, fmt.Errorf("role must be one of viewer, editor or owner, not %q", name)
+}
+
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleOwner:
+		return 3
+	}
+	return 0
+}
+
+// AtLeast reports whether r includes everything other allows.
+func (r Role) AtLeast(other Role) bool {
+	return r.rank() >= other.rank()
+}
+
+// Action is something done to a todo or project.
+type Action string
+
+const (
+	ActionRead   Action = "read"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	ActionShare  Action = "share"
+)
+
+// required maps each action to the lowest role allowed to perform it.
+var required = map[Action]Role{
+	ActionRead:   RoleViewer,
+	ActionUpdate: RoleEditor,
+	ActionDelete: RoleOwner,
+	ActionShare:  RoleOwner,
+}
+
+// ErrNotVisible is returned for resources the user has no role on. Callers
+// report them as missing rather than forbidden, so that their existence is
+// not revealed.
+var ErrNotVisible = errors.New("resource is not visible to the user")
+
+// DeniedError is returned when a user can see a resource but their role does
+// not allow the action.
+type DeniedError struct {
+	Role   Role
+	Action Action
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("the %s role does not allow to %s this; %s is required", e.Role, e.Action, required[e.Action])
+}
+
+// Authorize checks whether role allows action. It returns ErrNotVisible for
+// RoleNone and a *DeniedError when the role is too low.
+func Authorize(role Role, action Action) error {
+	if role == RoleNone {
+		return ErrNotVisible
+	}
+	needed, ok := required[action]
+	if !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	if !role.AtLeast(needed) {
+		return &DeniedError{Role: role, Action: action}
+	}
+	return nil
+}
+
+// Grant gives a user a role on a resource.
+type Grant struct {
+	UserID uint
+	Role   Role
+}
+
+// Resource describes who may access a todo or project: its owner, the users
+// it is shared with and, for a todo, the project it belongs to.
+type Resource struct {
+	OwnerID uint
+	Grants  []Grant
+	// Project is set for todos that belong to a project. Roles on the project
+	// carry over to its todos.
+	Project *Resource
+}
+
+// RoleOf returns the role userID has on the resource: owner when they own it
+// or its project, and otherwise the highest role shared with them on either.
+func RoleOf(userID uint, resource Resource) Role {
+	if resource.OwnerID == userID {
+		return RoleOwner
+	}
+	role := RoleNone
+	for _, grant := range resource.Grants {
+		if grant.UserID == userID && grant.Role.AtLeast(role) {
+			role = grant.Role
+		}
+	}
+	if resource.Project != nil {
+		if inherited := RoleOf(userID, *resource.Project); inherited.AtLeast(role) {
+			role = inherited
+		}
+	}
+	return role
+}
+
+// ErrNestingShared is returned when a user nests a todo below another user's
+// todo without putting it in a project they can edit.
+var ErrNestingShared = errors.New("subtasks of another user's todo must be placed in a project you can edit")
+
+// AuthorizeNesting checks whether userID may put a todo below parent, with
+// the todo ending up in project (nil for none). Editors of the parent may do
+// so. Since subtasks belong to the owner of their parent, a user nesting below
+// someone else's todo must also be able to edit the project the subtask is
+// in; otherwise they would lose sight of it.
+func AuthorizeNesting(userID uint, parent Resource, project *Resource) error {
+	if err := Authorize(RoleOf(userID, parent), ActionUpdate); err != nil {
+		return err
+	}
+	if parent.OwnerID == userID {
+		return nil
+	}
+	if project == nil || !RoleOf(userID, *project).AtLeast(RoleEditor) {
+		return ErrNestingShared
+	}
+	return nil
+}
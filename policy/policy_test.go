@@ -0,0 +1,108 @@
+package policy_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Xillon/golang-todo-api/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	alice uint = iota + 1
+	bob
+	carol
+)
+
+func TestAuthorize(t *testing.T) {
+	allowed := map[policy.Role][]policy.Action{
+		policy.RoleViewer: {policy.ActionRead},
+		policy.RoleEditor: {policy.ActionRead, policy.ActionUpdate},
+		policy.RoleOwner:  {policy.ActionRead, policy.ActionUpdate, policy.ActionDelete, policy.ActionShare},
+	}
+	actions := []policy.Action{policy.ActionRead, policy.ActionUpdate, policy.ActionDelete, policy.ActionShare}
+
+	for _, role := range policy.Roles {
+		for _, action := range actions {
+			err := policy.Authorize(role, action)
+			if slices.Contains(allowed[role], action) {
+				assert.NoError(t, err, "%s %s", role, action)
+				continue
+			}
+			var denied *policy.DeniedError
+			require.ErrorAs(t, err, &denied, "%s %s", role, action)
+			assert.Equal(t, role, denied.Role)
+			assert.Equal(t, action, denied.Action)
+		}
+	}
+
+	for _, action := range actions {
+		assert.ErrorIs(t, policy.Authorize(policy.RoleNone, action), policy.ErrNotVisible)
+	}
+	assert.Error(t, policy.Authorize(policy.RoleOwner, "archive"))
+}
+
+func TestParseRole(t *testing.T) {
+	for _, role := range policy.Roles {
+		parsed, err := policy.ParseRole(string(role))
+		require.NoError(t, err)
+		assert.Equal(t, role, parsed)
+	}
+	for _, name := range []string{"", "admin", "Owner"} {
+		_, err := policy.ParseRole(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRoleOf(t *testing.T) {
+	project := &policy.Resource{OwnerID: alice, Grants: []policy.Grant{{UserID: bob, Role: policy.RoleViewer}}}
+
+	for name, test := range map[string]struct {
+		resource policy.Resource
+		user     uint
+		want     policy.Role
+	}{
+		"owner":          {policy.Resource{OwnerID: alice}, alice, policy.RoleOwner},
+		"stranger":       {policy.Resource{OwnerID: alice}, carol, policy.RoleNone},
+		"shared":         {policy.Resource{OwnerID: alice, Grants: []policy.Grant{{UserID: carol, Role: policy.RoleEditor}}}, carol, policy.RoleEditor},
+		"other grantees": {policy.Resource{OwnerID: alice, Grants: []policy.Grant{{UserID: bob, Role: policy.RoleOwner}}}, carol, policy.RoleNone},
+		"from project":   {policy.Resource{OwnerID: alice, Project: project}, bob, policy.RoleViewer},
+		"todo above project": {
+			policy.Resource{OwnerID: alice, Grants: []policy.Grant{{UserID: bob, Role: policy.RoleEditor}}, Project: project},
+			bob, policy.RoleEditor,
+		},
+		"project above todo": {
+			policy.Resource{OwnerID: alice, Grants: []policy.Grant{{UserID: bob, Role: policy.RoleViewer}}, Project: &policy.Resource{OwnerID: alice, Grants: []policy.Grant{{UserID: bob, Role: policy.RoleOwner}}}},
+			bob, policy.RoleOwner,
+		},
+		"project owner": {policy.Resource{OwnerID: bob, Project: &policy.Resource{OwnerID: carol}}, carol, policy.RoleOwner},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, policy.RoleOf(test.user, test.resource))
+		})
+	}
+}
+
+func TestAuthorizeNesting(t *testing.T) {
+	editable := &policy.Resource{OwnerID: alice, Grants: []policy.Grant{{UserID: bob, Role: policy.RoleEditor}}}
+	viewable := &policy.Resource{OwnerID: alice, Grants: []policy.Grant{{UserID: bob, Role: policy.RoleViewer}}}
+
+	// Owners nest wherever they like.
+	assert.NoError(t, policy.AuthorizeNesting(alice, policy.Resource{OwnerID: alice}, nil))
+
+	// Editors need to keep the subtask in a project they can edit.
+	parent := policy.Resource{OwnerID: alice, Project: editable}
+	assert.NoError(t, policy.AuthorizeNesting(bob, parent, editable))
+	assert.ErrorIs(t, policy.AuthorizeNesting(bob, parent, nil), policy.ErrNestingShared)
+	shared := policy.Resource{OwnerID: alice, Grants: []policy.Grant{{UserID: bob, Role: policy.RoleEditor}}}
+	assert.ErrorIs(t, policy.AuthorizeNesting(bob, shared, nil), policy.ErrNestingShared)
+	shared.Project = viewable
+	assert.ErrorIs(t, policy.AuthorizeNesting(bob, shared, viewable), policy.ErrNestingShared)
+
+	// Viewers cannot nest at all, and strangers do not see the parent.
+	var denied *policy.DeniedError
+	assert.ErrorAs(t, policy.AuthorizeNesting(bob, policy.Resource{OwnerID: alice, Project: viewable}, editable), &denied)
+	assert.True(t, errors.Is(policy.AuthorizeNesting(carol, parent, editable), policy.ErrNotVisible))
+}
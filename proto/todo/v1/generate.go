@@ -0,0 +1,4 @@
+// Package todov1 holds the generated protobuf and gRPC code for todo.proto.
+package todov1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative todo.proto
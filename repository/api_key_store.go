@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyInactive = errors.New("api key is revoked or expired")
+)
+
+// APIKeyStore persists API keys. Keys are looked up by the hash of the value
+// handed out.
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	// ListAPIKeys returns every key, revoked and expired ones included,
+	// ordered by id.
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	FindAPIKey(ctx context.Context, id uint) (*models.APIKey, error)
+	// FindActiveAPIKey returns the key with the given hash, or
+	// ErrAPIKeyNotFound when there is none or it cannot be used at now.
+	FindActiveAPIKey(ctx context.Context, hash string, now time.Time) (*models.APIKey, error)
+	// RevokeAPIKey revokes a key for good. Revoking it again keeps the time
+	// it was first revoked.
+	RevokeAPIKey(ctx context.Context, id uint, at time.Time) error
+	// RotateAPIKey stores replacement and makes the key it replaces expire at
+	// retireAt, unless it expires earlier, in one transaction. Only active
+	// keys can be rotated; others return ErrAPIKeyInactive.
+	RotateAPIKey(ctx context.Context, id uint, replacement *models.APIKey, retireAt time.Time) error
+	// TouchAPIKey records when a key was last used.
+	TouchAPIKey(ctx context.Context, id uint, at time.Time) error
+}
+
+type GormAPIKeyStore struct {
+	db *gorm.DB
+}
+
+func NewGormAPIKeyStore(db *gorm.DB) *GormAPIKeyStore {
+	return &GormAPIKeyStore{db: db}
+}
+
+func ProvideAPIKeyStore(db *gorm.DB) APIKeyStore {
+	return NewGormAPIKeyStore(db)
+}
+
+func (s *GormAPIKeyStore) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return s.db.WithContext(ctx).Create(key).Error
+}
+
+func (s *GormAPIKeyStore) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := s.db.WithContext(ctx).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+func (s *GormAPIKeyStore) FindAPIKey(ctx context.Context, id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := s.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		return nil, translateAPIKeyError(err)
+	}
+	return &key, nil
+}
+
+func (s *GormAPIKeyStore) FindActiveAPIKey(ctx context.Context, hash string, now time.Time) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := s.db.WithContext(ctx).Scopes(activeAPIKeys(now)).Where("hash = ?", hash).First(&key).Error; err != nil {
+		return nil, translateAPIKeyError(err)
+	}
+	return &key, nil
+}
+
+func (s *GormAPIKeyStore) RevokeAPIKey(ctx context.Context, id uint, at time.Time) error {
+	if _, err := s.FindAPIKey(ctx, id); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+func (s *GormAPIKeyStore) RotateAPIKey(ctx context.Context, id uint, replacement *models.APIKey, retireAt time.Time) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.APIKey
+		if err := tx.First(&current, id).Error; err != nil {
+			return translateAPIKeyError(err)
+		}
+		if !current.Active(time.Now()) {
+			return ErrAPIKeyInactive
+		}
+		if current.ExpiresAt == nil || retireAt.Before(*current.ExpiresAt) {
+			if err := tx.Model(&current).Update("expires_at", retireAt).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(replacement).Error
+	})
+}
+
+func (s *GormAPIKeyStore) TouchAPIKey(ctx context.Context, id uint, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&models.APIKey{}).Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
+
+func activeAPIKeys(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", now)
+	}
+}
+
+func translateAPIKeyError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+// MemoryAPIKeyStore keeps API keys in a slice. It is meant for tests and
+// single-process setups that also use MemoryTodoRepository.
+type MemoryAPIKeyStore struct {
+	mu     sync.Mutex
+	keys   []models.APIKey
+	nextID uint
+}
+
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{}
+}
+
+func (s *MemoryAPIKeyStore) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.create(key)
+	return nil
+}
+
+func (s *MemoryAPIKeyStore) create(key *models.APIKey) {
+	now := time.Now().Round(0)
+	s.nextID++
+	key.ID = s.nextID
+	key.CreatedAt, key.UpdatedAt = now, now
+	s.keys = append(s.keys, *key)
+}
+
+func (s *MemoryAPIKeyStore) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.keys), nil
+}
+
+func (s *MemoryAPIKeyStore) FindAPIKey(ctx context.Context, id uint) (*models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.keys, func(k models.APIKey) bool { return k.ID == id })
+	if i < 0 {
+		return nil, ErrAPIKeyNotFound
+	}
+	key := s.keys[i]
+	return &key, nil
+}
+
+func (s *MemoryAPIKeyStore) FindActiveAPIKey(ctx context.Context, hash string, now time.Time) (*models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.keys, func(k models.APIKey) bool { return k.Hash == hash && k.Active(now) })
+	if i < 0 {
+		return nil, ErrAPIKeyNotFound
+	}
+	key := s.keys[i]
+	return &key, nil
+}
+
+func (s *MemoryAPIKeyStore) RevokeAPIKey(ctx context.Context, id uint, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.keys, func(k models.APIKey) bool { return k.ID == id })
+	if i < 0 {
+		return ErrAPIKeyNotFound
+	}
+	if s.keys[i].RevokedAt == nil {
+		s.keys[i].RevokedAt = &at
+		s.keys[i].UpdatedAt = time.Now().Round(0)
+	}
+	return nil
+}
+
+func (s *MemoryAPIKeyStore) RotateAPIKey(ctx context.Context, id uint, replacement *models.APIKey, retireAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.keys, func(k models.APIKey) bool { return k.ID == id })
+	if i < 0 {
+		return ErrAPIKeyNotFound
+	}
+	current := &s.keys[i]
+	if !current.Active(time.Now()) {
+		return ErrAPIKeyInactive
+	}
+	if current.ExpiresAt == nil || retireAt.Before(*current.ExpiresAt) {
+		current.ExpiresAt = &retireAt
+		current.UpdatedAt = time.Now().Round(0)
+	}
+	s.create(replacement)
+	return nil
+}
+
+func (s *MemoryAPIKeyStore) TouchAPIKey(ctx context.Context, id uint, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := slices.IndexFunc(s.keys, func(k models.APIKey) bool { return k.ID == id }); i >= 0 {
+		s.keys[i].LastUsedAt = &at
+	}
+	return nil
+}
@@ -54,22 +54,70 @@ func ProvideDatabase() (*gorm.DB, error) {
 		}
 
 		dsn = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&loc=Local&charset=utf8mb4&collation=utf8mb4_unicode_ci", username, password, host, port, database)
-		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
 	} else {
 		fmt.Println("Using SQLite as the default database...")
 		dsn = "todo.db"
-		db, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+		db, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true})
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Auto-migrate the Todo model
-	if err := db.AutoMigrate(&models.Todo{}); err != nil {
+	if err := AutoMigrate(db); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
 	log.Println("Database connected and migrated successfully!")
 	return db, nil
 }
+
+// AutoMigrate creates or updates the tables of every model the API stores.
+func AutoMigrate(db *gorm.DB) error {
+	err := db.AutoMigrate(
+		&models.Project{},
+		&models.Todo{},
+		&models.TodoRevision{},
+		&models.Tag{},
+		&models.TodoDependency{},
+		&models.IdempotencyKey{},
+		&models.Reminder{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.TodoEvent{},
+		&models.User{},
+		&models.AuthToken{},
+		&models.APIKey{},
+		&models.Share{},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Titles used to be unique across all todos. They are unique per project
+	// now, backed by idx_todos_owner_scope_title, so drop the old constraint
+	// and fill in the scope of todos stored before it existed.
+	migrator := db.Migrator()
+	if migrator.HasConstraint(&models.Todo{}, "uni_todos_title") {
+		if err := migrator.DropConstraint(&models.Todo{}, "uni_todos_title"); err != nil {
+			return err
+		}
+	}
+	err = db.Unscoped().Model(&models.Todo{}).
+		Where("title_scope IS NULL AND series_id IS NULL").
+		Update("title_scope", gorm.Expr("COALESCE(project_id, 0)")).Error
+	if err != nil {
+		return err
+	}
+	// Tag and project names used to be unique across all users.
+	if migrator.HasIndex(&models.Tag{}, "idx_tags_name") {
+		if err := migrator.DropIndex(&models.Tag{}, "idx_tags_name"); err != nil {
+			return err
+		}
+	}
+	if migrator.HasIndex(&models.Project{}, "idx_projects_name") {
+		return migrator.DropIndex(&models.Project{}, "idx_projects_name")
+	}
+	return nil
+}
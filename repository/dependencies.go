@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+type allowBlockedKey struct{}
+
+// AllowBlockedCompletion lets writes made with ctx complete todos whose
+// blockers are still open.
+func AllowBlockedCompletion(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowBlockedKey{}, true)
+}
+
+func blockedCompletionAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(allowBlockedKey{}).(bool)
+	return allowed
+}
+
+// ListBlockers returns the live todos a live todo is blocked by.
+func ListBlockers(ctx context.Context, repo TodoRepository, id uint) ([]models.Todo, error) {
+	todo, err := repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	blockers := make([]models.Todo, 0, len(todo.BlockedBy))
+	for _, blockerID := range todo.BlockedBy {
+		blocker, err := repo.FindByID(ctx, blockerID)
+		if err != nil {
+			return nil, err
+		}
+		blockers = append(blockers, *blocker)
+	}
+	return blockers, nil
+}
+
+// OrderByDependencies sorts todos so that every todo comes after the todos in
+// the slice that block it. Todos that are free to go keep their relative
+// order, so the caller's sort decides between them. Blockers outside of the
+// slice are ignored.
+func OrderByDependencies(todos []models.Todo) ([]models.Todo, error) {
+	index := make(map[uint]int, len(todos))
+	for i, todo := range todos {
+		index[todo.ID] = i
+	}
+	waiting := make([]int, len(todos))
+	unblocks := make([][]int, len(todos))
+	for i, todo := range todos {
+		for _, blockerID := range todo.BlockedBy {
+			if j, ok := index[blockerID]; ok {
+				waiting[i]++
+				unblocks[j] = append(unblocks[j], i)
+			}
+		}
+	}
+
+	ordered := make([]models.Todo, 0, len(todos))
+	done := make([]bool, len(todos))
+	for len(ordered) < len(todos) {
+		next := -1
+		for i := range todos {
+			if !done[i] && waiting[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, ErrDependencyCycle
+		}
+		done[next] = true
+		ordered = append(ordered, todos[next])
+		for _, i := range unblocks[next] {
+			waiting[i]--
+		}
+	}
+	return ordered, nil
+}
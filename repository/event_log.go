@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/events"
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+)
+
+// EventLog is the append-only log of published todo events that clients
+// resume from after losing their connection.
+type EventLog interface {
+	// Append stores an event and sets its ID, which is higher than that of
+	// every event appended before.
+	Append(ctx context.Context, event *models.TodoEvent) error
+	// Since returns up to limit events with an ID above afterID, oldest first.
+	Since(ctx context.Context, afterID uint, limit int) ([]models.TodoEvent, error)
+	// PurgeBefore removes events that occurred before the given time and
+	// reports how many were removed.
+	PurgeBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type GormEventLog struct {
+	db *gorm.DB
+}
+
+func NewGormEventLog(db *gorm.DB) *GormEventLog {
+	return &GormEventLog{db: db}
+}
+
+func ProvideEventLog(db *gorm.DB) EventLog {
+	return NewGormEventLog(db)
+}
+
+// ProvideEventBus returns a bus that records every event in log before
+// publishing it.
+func ProvideEventBus(log EventLog) *events.Bus {
+	return events.NewBus(log)
+}
+
+// ProvideEventStream returns a stream that replays from log and follows the
+// events published on bus.
+func ProvideEventStream(log EventLog, bus *events.Bus) *events.Stream {
+	stream := events.NewStream(log)
+	bus.Subscribe(stream.Publish)
+	return stream
+}
+
+func (l *GormEventLog) Append(ctx context.Context, event *models.TodoEvent) error {
+	event.ID = 0
+	return l.db.WithContext(ctx).Create(event).Error
+}
+
+func (l *GormEventLog) Since(ctx context.Context, afterID uint, limit int) ([]models.TodoEvent, error) {
+	var events []models.TodoEvent
+	err := l.db.WithContext(ctx).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (l *GormEventLog) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := l.db.WithContext(ctx).Where("occurred_at < ?", before).Delete(&models.TodoEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// MemoryEventLog keeps events in a slice. It is meant for tests and
+// single-process setups that also use MemoryTodoRepository.
+type MemoryEventLog struct {
+	mu     sync.Mutex
+	events []models.TodoEvent
+	nextID uint
+}
+
+func NewMemoryEventLog() *MemoryEventLog {
+	return &MemoryEventLog{}
+}
+
+func (l *MemoryEventLog) Append(ctx context.Context, event *models.TodoEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.nextID++
+	event.ID = l.nextID
+	l.events = append(l.events, *event)
+	return nil
+}
+
+func (l *MemoryEventLog) Since(ctx context.Context, afterID uint, limit int) ([]models.TodoEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	i, _ := slices.BinarySearchFunc(l.events, afterID+1, func(event models.TodoEvent, id uint) int { return int(event.ID) - int(id) })
+	events := l.events[i:]
+	return slices.Clone(events[:min(limit, len(events))]), nil
+}
+
+func (l *MemoryEventLog) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := len(l.events)
+	l.events = slices.DeleteFunc(l.events, func(event models.TodoEvent) bool { return event.OccurredAt.Before(before) })
+	return int64(count - len(l.events)), nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+// EventPublisher receives the events of committed writes.
+type EventPublisher interface {
+	Publish(ctx context.Context, event models.TodoEvent)
+}
+
+// EventTodoRepository wraps a TodoRepository and publishes a TodoEvent for
+// every todo created, updated, completed, deleted or restored through it.
+// Events are held back until the transaction they were raised in commits and
+// are dropped when it rolls back, so subscribers never hear of writes that did
+// not happen. Writes that change no field raise no event.
+type EventTodoRepository struct {
+	TodoRepository
+	publisher EventPublisher
+	// pending collects the events of the transaction the repository is bound
+	// to; it is nil outside of one.
+	pending *[]models.TodoEvent
+}
+
+func NewEventTodoRepository(inner TodoRepository, publisher EventPublisher) *EventTodoRepository {
+	return &EventTodoRepository{TodoRepository: inner, publisher: publisher}
+}
+
+func (r *EventTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	return r.Transaction(ctx, func(repo TodoRepository) error {
+		tx := repo.(*EventTodoRepository)
+		if err := tx.TodoRepository.Create(ctx, todo); err != nil {
+			return err
+		}
+		tx.emit(models.EventTodoCreated, todo)
+		return nil
+	})
+}
+
+func (r *EventTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	return r.write(ctx, todo.ID, func(tx TodoRepository) error {
+		return tx.Update(ctx, todo)
+	})
+}
+
+func (r *EventTodoRepository) Replace(ctx context.Context, todo *models.Todo) error {
+	return r.write(ctx, todo.ID, func(tx TodoRepository) error {
+		return tx.Replace(ctx, todo)
+	})
+}
+
+func (r *EventTodoRepository) Delete(ctx context.Context, id, version uint) error {
+	return r.Transaction(ctx, func(repo TodoRepository) error {
+		tx := repo.(*EventTodoRepository)
+		before, err := tx.TodoRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.TodoRepository.Delete(ctx, id, version); err != nil {
+			return err
+		}
+		tx.emit(models.EventTodoDeleted, before)
+		return nil
+	})
+}
+
+// Restore announces the todo coming back from the trash as an update.
+func (r *EventTodoRepository) Restore(ctx context.Context, id uint) error {
+	return r.Transaction(ctx, func(repo TodoRepository) error {
+		tx := repo.(*EventTodoRepository)
+		if err := tx.TodoRepository.Restore(ctx, id); err != nil {
+			return err
+		}
+		after, err := tx.TodoRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		tx.emit(models.EventTodoUpdated, after)
+		return nil
+	})
+}
+
+func (r *EventTodoRepository) Transaction(ctx context.Context, fn func(repo TodoRepository) error) error {
+	if r.pending != nil {
+		// A nested transaction only drops its own events when it fails.
+		mark := len(*r.pending)
+		err := r.TodoRepository.Transaction(ctx, func(tx TodoRepository) error {
+			return fn(&EventTodoRepository{TodoRepository: tx, publisher: r.publisher, pending: r.pending})
+		})
+		if err != nil {
+			*r.pending = (*r.pending)[:mark]
+		}
+		return err
+	}
+
+	var pending []models.TodoEvent
+	err := r.TodoRepository.Transaction(ctx, func(tx TodoRepository) error {
+		return fn(&EventTodoRepository{TodoRepository: tx, publisher: r.publisher, pending: &pending})
+	})
+	if err != nil {
+		return err
+	}
+	for _, event := range pending {
+		r.publisher.Publish(ctx, event)
+	}
+	return nil
+}
+
+// write runs an update of the todo with the given id and raises
+// todo.completed when it completed the todo, or todo.updated when it changed
+// any other field.
+func (r *EventTodoRepository) write(ctx context.Context, id uint, fn func(tx TodoRepository) error) error {
+	return r.Transaction(ctx, func(repo TodoRepository) error {
+		tx := repo.(*EventTodoRepository)
+		before, err := tx.TodoRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx.TodoRepository); err != nil {
+			return err
+		}
+		after, err := tx.TodoRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		switch {
+		case after.Complete && !before.Complete:
+			tx.emit(models.EventTodoCompleted, after)
+		case len(models.SnapshotOf(before).Diff(models.SnapshotOf(after))) > 0:
+			tx.emit(models.EventTodoUpdated, after)
+		}
+		return nil
+	})
+}
+
+func (r *EventTodoRepository) emit(eventType string, todo *models.Todo) {
+	*r.pending = append(*r.pending, models.TodoEvent{Type: eventType, OccurredAt: time.Now(), Todo: *todo})
+}
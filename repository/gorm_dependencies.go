@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *GormTodoRepository) AddDependency(ctx context.Context, todoID, blockerID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var todo models.Todo
+		if err := tx.Scopes(accessible).Select("id", "owner_id").First(&todo, todoID).Error; err != nil {
+			return translateError(err)
+		}
+		// A todo can only wait for todos of its own owner.
+		err := tx.Scopes(accessible).Select("id").Where("owner_id = ?", todo.OwnerID).First(&models.Todo{}, blockerID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBlockerNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkDependency(tx, todoID, blockerID); err != nil {
+			return err
+		}
+		dependency := models.TodoDependency{TodoID: todoID, BlockerID: blockerID}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&dependency).Error
+	})
+}
+
+func (r *GormTodoRepository) RemoveDependency(ctx context.Context, todoID, blockerID uint) error {
+	db := r.db.WithContext(ctx)
+	if err := ensureExists(db, todoID); err != nil {
+		if errors.Is(err, ErrTodoNotFound) {
+			return ErrDependencyNotFound
+		}
+		return err
+	}
+	result := db.Where("todo_id = ? AND blocker_id = ?", todoID, blockerID).Delete(&models.TodoDependency{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDependencyNotFound
+	}
+	return nil
+}
+
+// checkDependency makes sure todoID may wait for blockerID. Dependencies of
+// trashed todos count too, so restoring a todo can never close a cycle.
+func checkDependency(db *gorm.DB, todoID, blockerID uint) error {
+	if todoID == blockerID {
+		return ErrDependencyCycle
+	}
+
+	// Walk the blockers of the blocker; meeting the todo means a cycle.
+	seen := map[uint]bool{blockerID: true}
+	for frontier := []uint{blockerID}; len(frontier) > 0; {
+		var next []uint
+		if err := db.Model(&models.TodoDependency{}).Where("todo_id IN ?", frontier).Pluck("blocker_id", &next).Error; err != nil {
+			return err
+		}
+		frontier = frontier[:0]
+		for _, id := range next {
+			if id == todoID {
+				return ErrDependencyCycle
+			}
+			if !seen[id] {
+				seen[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return nil
+}
+
+// checkUnblocked refuses to complete an open todo while any of its live
+// blockers is still open, unless ctx allows it.
+func checkUnblocked(ctx context.Context, db *gorm.DB, id uint) error {
+	if blockedCompletionAllowed(ctx) {
+		return nil
+	}
+	var current models.Todo
+	if err := db.Select("id", "complete").First(&current, id).Error; err != nil {
+		return translateError(err)
+	}
+	if current.Complete {
+		return nil
+	}
+
+	var open int64
+	err := db.Model(&models.Todo{}).
+		Joins("JOIN todo_dependencies ON todo_dependencies.blocker_id = todos.id").
+		Where("todo_dependencies.todo_id = ? AND todos.complete = ?", id, false).
+		Count(&open).Error
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return ErrTodoBlocked
+	}
+	return nil
+}
+
+// loadDependencies fills in the computed BlockedBy and Blocked fields.
+func loadDependencies(db *gorm.DB, todos []models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	index := make(map[uint]int, len(todos))
+	ids := make([]uint, len(todos))
+	for i, todo := range todos {
+		index[todo.ID] = i
+		ids[i] = todo.ID
+	}
+
+	var edges []struct {
+		TodoID    uint
+		BlockerID uint
+		Complete  bool
+	}
+	err := db.Model(&models.TodoDependency{}).
+		Select("todo_dependencies.todo_id, todo_dependencies.blocker_id, todos.complete").
+		Joins("JOIN todos ON todos.id = todo_dependencies.blocker_id AND todos.deleted_at IS NULL").
+		Where("todo_dependencies.todo_id IN ?", ids).
+		Order("todo_dependencies.blocker_id").
+		Scan(&edges).Error
+	if err != nil {
+		return err
+	}
+	for _, edge := range edges {
+		todo := &todos[index[edge.TodoID]]
+		todo.BlockedBy = append(todo.BlockedBy, edge.BlockerID)
+		if !edge.Complete {
+			todo.Blocked = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+)
+
+func (r *GormTodoRepository) CreateProject(ctx context.Context, project *models.Project) error {
+	if err := normalizeProject(project); err != nil {
+		return err
+	}
+	project.OwnerID = ownerOf(ctx, project.OwnerID)
+	return translateProjectError(r.db.WithContext(ctx).Create(project).Error)
+}
+
+func (r *GormTodoRepository) UpdateProject(ctx context.Context, project *models.Project) error {
+	if err := normalizeProject(project); err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).Model(&models.Project{}).Scopes(accessibleProjects).Where("id = ?", project.ID).
+		Updates(map[string]any{"name": project.Name, "description": project.Description})
+	if result.Error != nil {
+		return translateProjectError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.FindProject(ctx, project.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *GormTodoRepository) FindProject(ctx context.Context, id uint) (*models.Project, error) {
+	var project models.Project
+	if err := r.db.WithContext(ctx).Scopes(accessibleProjects).First(&project, id).Error; err != nil {
+		return nil, translateProjectError(err)
+	}
+	return &project, nil
+}
+
+func (r *GormTodoRepository) ListProjects(ctx context.Context) ([]models.Project, error) {
+	var projects []models.Project
+	err := r.db.WithContext(ctx).Scopes(accessibleProjects).Order("name").Find(&projects).Error
+	return projects, err
+}
+
+func (r *GormTodoRepository) DeleteProject(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(accessibleProjects).Select("id").First(&models.Project{}, id).Error; err != nil {
+			return translateProjectError(err)
+		}
+		var todos int64
+		if err := tx.Unscoped().Model(&models.Todo{}).Where("project_id = ?", id).Count(&todos).Error; err != nil {
+			return err
+		}
+		if todos > 0 {
+			return ErrProjectNotEmpty
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&models.Share{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Project{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrProjectNotFound
+		}
+		return nil
+	})
+}
+
+// checkPlacement makes sure a todo with the given id may be stored under
+// title in the project: the project has to exist and belong to the todo's
+// owner, and no other todo in it, including trashed ones, may use the title.
+// Todos without a project are only compared with those of the same owner.
+// Occurrences of the todo's own recurring series do not count.
+//
+// The unique index on titles has the last word when todos are written
+// concurrently; checking first gives the usual error for the common case.
+func checkPlacement(db *gorm.DB, id, ownerID uint, seriesID, projectID *uint, title string) error {
+	if projectID != nil {
+		if err := db.Scopes(accessibleProjects).Select("id").Where("owner_id = ?", ownerID).First(&models.Project{}, *projectID).Error; err != nil {
+			return translateProjectError(err)
+		}
+	}
+
+	query := db.Unscoped().Model(&models.Todo{}).Where("title = ? AND id <> ?", title, id)
+	if projectID != nil {
+		query = query.Where("project_id = ?", *projectID)
+	} else {
+		query = query.Where("project_id IS NULL AND owner_id = ?", ownerID)
+	}
+	if seriesID != nil {
+		query = query.Where("(series_id IS NULL OR series_id <> ?)", *seriesID)
+	}
+	var taken int64
+	if err := query.Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrDuplicateTitle
+	}
+	return nil
+}
+
+// titleScope returns the models.Todo.TitleScope of a todo in projectID and
+// seriesID.
+func titleScope(projectID, seriesID *uint) *uint {
+	if seriesID != nil {
+		return nil
+	}
+	var scope uint
+	if projectID != nil {
+		scope = *projectID
+	}
+	return &scope
+}
+
+func normalizeProject(project *models.Project) error {
+	project.Name = strings.TrimSpace(project.Name)
+	if project.Name == "" {
+		return errors.New("project name is required")
+	}
+	return nil
+}
+
+func translateProjectError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrProjectNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicateProject
+	}
+	return err
+}
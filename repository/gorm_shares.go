@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/policy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *GormTodoRepository) SaveShare(ctx context.Context, share *models.Share) error {
+	target := targetOf(share)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		owner, err := targetOwner(tx, target)
+		if err != nil {
+			return err
+		}
+		if owner == share.UserID {
+			return ErrShareWithOwner
+		}
+
+		var existing models.Share
+		err = shareQuery(tx, target).Where("user_id = ?", share.UserID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			target.apply(share)
+			return tx.Create(share).Error
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&existing).Update("role", share.Role).Error; err != nil {
+			return err
+		}
+		return tx.First(share, existing.ID).Error
+	})
+}
+
+func (r *GormTodoRepository) ListShares(ctx context.Context, target ShareTarget) ([]models.Share, error) {
+	var shares []models.Share
+	err := shareQuery(r.db.WithContext(ctx), target).Order("id").Find(&shares).Error
+	return shares, err
+}
+
+func (r *GormTodoRepository) DeleteShare(ctx context.Context, target ShareTarget, userID uint) error {
+	result := shareQuery(r.db.WithContext(ctx), target).Where("user_id = ?", userID).Delete(&models.Share{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrShareNotFound
+	}
+	return nil
+}
+
+func (r *GormTodoRepository) Access(ctx context.Context, target ShareTarget) (policy.Resource, error) {
+	db := r.db.WithContext(ctx)
+	if target.ProjectID != 0 {
+		if err := db.Scopes(accessibleProjects).Select("id").First(&models.Project{}, target.ProjectID).Error; err != nil {
+			return policy.Resource{}, translateProjectError(err)
+		}
+		return projectResource(db, target.ProjectID)
+	}
+
+	var todo models.Todo
+	if err := db.Unscoped().Scopes(accessible).Select("id", "owner_id", "project_id").First(&todo, target.TodoID).Error; err != nil {
+		return policy.Resource{}, translateError(err)
+	}
+	var shares []models.Share
+	if err := shareQuery(db, target).Find(&shares).Error; err != nil {
+		return policy.Resource{}, err
+	}
+	resource := policy.Resource{OwnerID: todo.OwnerID, Grants: grants(shares)}
+	if todo.ProjectID != nil {
+		// Roles on the project carry over even when only the todo itself
+		// is shared, so the project is loaded whether it is visible or not.
+		project, err := projectResource(db, *todo.ProjectID)
+		if err != nil {
+			return policy.Resource{}, err
+		}
+		resource.Project = &project
+	}
+	return resource, nil
+}
+
+// projectResource describes the owner and shares of a project.
+func projectResource(db *gorm.DB, id uint) (policy.Resource, error) {
+	var project models.Project
+	if err := db.Select("id", "owner_id").First(&project, id).Error; err != nil {
+		return policy.Resource{}, translateProjectError(err)
+	}
+	var shares []models.Share
+	if err := shareQuery(db, ShareTarget{ProjectID: id}).Find(&shares).Error; err != nil {
+		return policy.Resource{}, err
+	}
+	return policy.Resource{OwnerID: project.OwnerID, Grants: grants(shares)}, nil
+}
+
+// targetOwner returns the owner of the live todo or project a share is on.
+func targetOwner(db *gorm.DB, target ShareTarget) (uint, error) {
+	if target.TodoID != 0 {
+		var todo models.Todo
+		err := db.Select("id", "owner_id").First(&todo, target.TodoID).Error
+		return todo.OwnerID, translateError(err)
+	}
+	var project models.Project
+	err := db.Select("id", "owner_id").First(&project, target.ProjectID).Error
+	return project.OwnerID, translateProjectError(err)
+}
+
+func shareQuery(db *gorm.DB, target ShareTarget) *gorm.DB {
+	if target.TodoID != 0 {
+		return db.Where(clause.Eq{Column: "todo_id", Value: target.TodoID})
+	}
+	return db.Where(clause.Eq{Column: "project_id", Value: target.ProjectID})
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+)
+
+// checkParent makes sure the todo with the given id may be nested below
+// parentID, a todo of the same owner. id is zero for todos that do not exist
+// yet.
+func checkParent(db *gorm.DB, id, ownerID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	if *parentID == id {
+		return ErrParentCycle
+	}
+
+	var parent models.Todo
+	err := db.Scopes(accessible).Select("id", "parent_id").Where("owner_id = ?", ownerID).First(&parent, *parentID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrParentNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Walk up from the new parent; meeting the todo itself means a cycle.
+	for ancestor := parent.ParentID; ancestor != nil && id != 0; {
+		if *ancestor == id {
+			return ErrParentCycle
+		}
+		var next models.Todo
+		if err := db.Unscoped().Select("id", "parent_id").First(&next, *ancestor).Error; err != nil {
+			return translateError(err)
+		}
+		ancestor = next.ParentID
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+)
+
+func (r *GormTodoRepository) CreateTag(ctx context.Context, tag *models.Tag) error {
+	if err := tag.Normalize(); err != nil {
+		return err
+	}
+	tag.OwnerID = ownerOf(ctx, tag.OwnerID)
+	return translateTagError(r.db.WithContext(ctx).Create(tag).Error)
+}
+
+func (r *GormTodoRepository) UpdateTag(ctx context.Context, tag *models.Tag) error {
+	if err := tag.Normalize(); err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).Model(&models.Tag{}).Scopes(owned).Where("id = ?", tag.ID).
+		Updates(map[string]any{"name": tag.Name, "colour": tag.Colour})
+	if result.Error != nil {
+		return translateTagError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.FindTag(ctx, tag.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *GormTodoRepository) FindTag(ctx context.Context, id uint) (*models.Tag, error) {
+	var tag models.Tag
+	if err := r.db.WithContext(ctx).Scopes(owned).First(&tag, id).Error; err != nil {
+		return nil, translateTagError(err)
+	}
+	return &tag, nil
+}
+
+func (r *GormTodoRepository) ListTags(ctx context.Context) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.WithContext(ctx).Scopes(owned).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+func (r *GormTodoRepository) DeleteTag(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(owned).Select("id").First(&models.Tag{}, id).Error; err != nil {
+			return translateTagError(err)
+		}
+		if err := tx.Exec("DELETE FROM todo_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tag{}, id).Error
+	})
+}
+
+// setTags points the todo's tag associations at tags of its owner, resolving
+// them first, and returns the stored tags.
+func (r *GormTodoRepository) setTags(db *gorm.DB, todoID, ownerID uint, tags []models.Tag) ([]models.Tag, error) {
+	resolved, err := r.resolveTags(db, ownerID, tags)
+	if err != nil {
+		return nil, err
+	}
+	sortTags(resolved)
+	association := db.Model(&models.Todo{ID: todoID}).Association("Tags")
+	if len(resolved) == 0 {
+		return resolved, association.Clear()
+	}
+	return resolved, association.Replace(resolved)
+}
+
+// resolveTags finds the owner's stored tag for each entry, creating tags that
+// are named but do not exist yet. Duplicates are dropped.
+func (r *GormTodoRepository) resolveTags(db *gorm.DB, ownerID uint, tags []models.Tag) ([]models.Tag, error) {
+	resolved := make([]models.Tag, 0, len(tags))
+	seen := map[uint]bool{}
+	for _, tag := range tags {
+		var found models.Tag
+		if tag.Name == "" && tag.ID != 0 {
+			if err := db.Where("owner_id = ?", ownerID).First(&found, tag.ID).Error; err != nil {
+				return nil, translateTagError(err)
+			}
+		} else {
+			if err := tag.Normalize(); err != nil {
+				return nil, err
+			}
+			err := db.Where("owner_id = ? AND name = ?", ownerID, tag.Name).First(&found).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				found = models.Tag{OwnerID: ownerID, Name: tag.Name, Colour: tag.Colour}
+				err = db.Create(&found).Error
+			}
+			if err != nil {
+				return nil, translateTagError(err)
+			}
+		}
+		if !seen[found.ID] {
+			seen[found.ID] = true
+			resolved = append(resolved, found)
+		}
+	}
+	return resolved, nil
+}
+
+// preloadTags loads the tags of queried todos in name order.
+func preloadTags(db *gorm.DB) *gorm.DB {
+	return db.Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name") })
+}
+
+// filterTags keeps todos carrying any, or all, of the named tags.
+func filterTags(db *gorm.DB, names []string, all bool) *gorm.DB {
+	tagged := db.Session(&gorm.Session{NewDB: true}).Table("todo_tags").
+		Select("todo_tags.todo_id").
+		Joins("JOIN tags ON tags.id = todo_tags.tag_id").
+		Where("tags.name IN ?", names)
+	if all {
+		tagged = tagged.Group("todo_tags.todo_id").Having("COUNT(DISTINCT tags.id) = ?", len(names))
+	}
+	return db.Where("id IN (?)", tagged)
+}
+
+func translateTagError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrTagNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicateTag
+	}
+	return err
+}
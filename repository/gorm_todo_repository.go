@@ -0,0 +1,464 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/events"
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormTodoRepository stores todos through GORM. It is used for both the MySQL
+// and SQLite dialects opened by ProvideDatabase.
+type GormTodoRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTodoRepository(db *gorm.DB) *GormTodoRepository {
+	return &GormTodoRepository{db: db}
+}
+
+// ProvideTodoRepository returns the GORM repository wrapped so that users'
+// changes are checked against the policy package, every write is recorded in
+// the todo's history, completing a recurring todo schedules its next
+// occurrence and committed changes are published on bus.
+func ProvideTodoRepository(db *gorm.DB, bus *events.Bus) TodoRepository {
+	return NewPolicyTodoRepository(NewRecurringTodoRepository(NewHistoryTodoRepository(NewEventTodoRepository(NewGormTodoRepository(db), bus))))
+}
+
+func (r *GormTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	// Ids and versions are assigned here, never taken from the caller.
+	todo.ID, todo.Version = 0, 1
+	// A new todo does not wait for anything yet.
+	todo.BlockedBy, todo.Blocked = nil, false
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		owner, err := placementOwner(tx, todo)
+		if err != nil {
+			return err
+		}
+		todo.OwnerID = owner
+		if err := checkPlacement(tx, 0, todo.OwnerID, todo.SeriesID, todo.ProjectID, todo.Title); err != nil {
+			return err
+		}
+		todo.TitleScope = titleScope(todo.ProjectID, todo.SeriesID)
+		if err := checkParent(tx, 0, todo.OwnerID, todo.ParentID); err != nil {
+			return err
+		}
+		if err := translateTitleError(tx.Omit("Tags").Create(todo).Error); err != nil {
+			return err
+		}
+		tags, err := r.setTags(tx, todo.ID, todo.OwnerID, todo.Tags)
+		if err != nil {
+			return err
+		}
+		todo.Tags = tags
+		return nil
+	})
+}
+
+func (r *GormTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	fields := map[string]any{}
+	if todo.Title != "" {
+		fields["title"] = todo.Title
+	}
+	if todo.Description != "" {
+		fields["description"] = todo.Description
+	}
+	if todo.DueDate != nil {
+		fields["due_date"] = todo.DueDate
+	}
+	if todo.RemindAt != nil {
+		fields["remind_at"] = todo.RemindAt
+	}
+	if todo.Complete {
+		fields["complete"] = true
+	}
+	if todo.ProjectID != nil {
+		fields["project_id"] = todo.ProjectID
+	}
+	if todo.ParentID != nil {
+		fields["parent_id"] = todo.ParentID
+	}
+	if todo.Recurrence != "" {
+		fields["recurrence"] = todo.Recurrence
+	}
+	if todo.Timezone != "" {
+		fields["timezone"] = todo.Timezone
+	}
+	if todo.SeriesID != nil {
+		fields["series_id"] = todo.SeriesID
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.Todo
+		if err := tx.Scopes(accessible).First(&current, todo.ID).Error; err != nil {
+			return translateError(err)
+		}
+		if todo.Title != "" || todo.ProjectID != nil || todo.SeriesID != nil {
+			title, seriesID, projectID := current.Title, current.SeriesID, current.ProjectID
+			if todo.Title != "" {
+				title = todo.Title
+			}
+			if todo.SeriesID != nil {
+				seriesID = todo.SeriesID
+			}
+			if todo.ProjectID != nil {
+				projectID = todo.ProjectID
+			}
+			if err := checkPlacement(tx, todo.ID, current.OwnerID, seriesID, projectID, title); err != nil {
+				return err
+			}
+			fields["title_scope"] = titleScope(projectID, seriesID)
+		}
+		if err := checkParent(tx, todo.ID, current.OwnerID, todo.ParentID); err != nil {
+			return err
+		}
+		if todo.Complete {
+			if err := checkUnblocked(ctx, tx, todo.ID); err != nil {
+				return err
+			}
+		}
+		if err := updateVersioned(tx, todo.ID, todo.Version, fields); err != nil {
+			return err
+		}
+		if todo.Tags == nil {
+			return nil
+		}
+		_, err := r.setTags(tx, todo.ID, current.OwnerID, todo.Tags)
+		return err
+	})
+}
+
+func (r *GormTodoRepository) Replace(ctx context.Context, todo *models.Todo) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.Todo
+		if err := tx.Scopes(accessible).Select("id", "owner_id").First(&current, todo.ID).Error; err != nil {
+			return translateError(err)
+		}
+		if err := checkPlacement(tx, todo.ID, current.OwnerID, todo.SeriesID, todo.ProjectID, todo.Title); err != nil {
+			return err
+		}
+		if err := checkParent(tx, todo.ID, current.OwnerID, todo.ParentID); err != nil {
+			return err
+		}
+		if todo.Complete {
+			if err := checkUnblocked(ctx, tx, todo.ID); err != nil {
+				return err
+			}
+		}
+		err := updateVersioned(tx, todo.ID, todo.Version, map[string]any{
+			"title":       todo.Title,
+			"description": todo.Description,
+			"due_date":    todo.DueDate,
+			"remind_at":   todo.RemindAt,
+			"complete":    todo.Complete,
+			"project_id":  todo.ProjectID,
+			"parent_id":   todo.ParentID,
+			"recurrence":  todo.Recurrence,
+			"timezone":    todo.Timezone,
+			"series_id":   todo.SeriesID,
+			"title_scope": titleScope(todo.ProjectID, todo.SeriesID),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = r.setTags(tx, todo.ID, current.OwnerID, todo.Tags)
+		return err
+	})
+}
+
+// updateVersioned writes fields to a live todo and bumps its version. A
+// non-zero version makes the write conditional on the stored version.
+func updateVersioned(db *gorm.DB, id, version uint, fields map[string]any) error {
+	query := db.Model(&models.Todo{}).Scopes(accessible).Where("id = ?", id)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	fields["version"] = gorm.Expr("version + 1")
+
+	result := query.Updates(fields)
+	if result.Error != nil {
+		return translateTitleError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// Bumping the version always changes the row, so nothing affected
+		// means the todo is gone or its version moved on.
+		if err := ensureExists(db, id); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func ensureExists(db *gorm.DB, id uint) error {
+	var count int64
+	if err := db.Model(&models.Todo{}).Scopes(accessible).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrTodoNotFound
+	}
+	return nil
+}
+
+func (r *GormTodoRepository) FindByID(ctx context.Context, id uint) (*models.Todo, error) {
+	var todo models.Todo
+	db := r.db.WithContext(ctx)
+	if err := preloadTags(db).Scopes(accessible).First(&todo, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	todos := []models.Todo{todo}
+	if err := loadDependencies(db, todos); err != nil {
+		return nil, err
+	}
+	return &todos[0], nil
+}
+
+func (r *GormTodoRepository) List(ctx context.Context, query TodoQuery) ([]models.Todo, int64, error) {
+	var todos []models.Todo
+	var total int64
+
+	db := r.db.WithContext(ctx)
+	if !query.SkipTotal {
+		if err := filterTodos(db.Model(&models.Todo{}), query).Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	find := preloadTags(filterTodos(db, query))
+	switch {
+	case query.After != nil:
+		find = find.Where("(created_at > ? OR (created_at = ? AND id > ?))", query.After.CreatedAt, query.After.CreatedAt, query.After.ID).
+			Order("created_at").Order("id")
+	case query.Before != nil:
+		find = find.Where("(created_at < ? OR (created_at = ? AND id < ?))", query.Before.CreatedAt, query.Before.CreatedAt, query.Before.ID).
+			Order("created_at DESC").Order("id DESC")
+	default:
+		find = sortTodos(find, query.Sort)
+	}
+	if err := find.Limit(query.Limit).Offset(query.Offset).Find(&todos).Error; err != nil {
+		return nil, 0, err
+	}
+	if query.Before != nil {
+		slices.Reverse(todos)
+	}
+	if err := loadDependencies(db, todos); err != nil {
+		return nil, 0, err
+	}
+	return todos, total, nil
+}
+
+func filterTodos(db *gorm.DB, query TodoQuery) *gorm.DB {
+	db = db.Scopes(accessible)
+	if query.Trashed {
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if query.Complete != nil {
+		db = db.Where("complete = ?", *query.Complete)
+	}
+	ranges := []struct {
+		condition string
+		value     *time.Time
+	}{
+		{"due_date < ?", query.DueBefore},
+		{"due_date > ?", query.DueAfter},
+		{"remind_at < ?", query.RemindBefore},
+		{"created_at < ?", query.CreatedBefore},
+		{"created_at > ?", query.CreatedAfter},
+		{"updated_at < ?", query.UpdatedBefore},
+		{"updated_at > ?", query.UpdatedAfter},
+	}
+	for _, r := range ranges {
+		if r.value != nil {
+			db = db.Where(r.condition, *r.value)
+		}
+	}
+	if query.Search != "" {
+		pattern := likePattern(query.Search)
+		db = db.Where("(title LIKE ? ESCAPE '!' OR description LIKE ? ESCAPE '!')", pattern, pattern)
+	}
+	if query.Title != "" {
+		db = db.Where("title LIKE ? ESCAPE '!'", likePattern(query.Title))
+	}
+	if query.Description != "" {
+		db = db.Where("description LIKE ? ESCAPE '!'", likePattern(query.Description))
+	}
+	if len(query.Tags) > 0 {
+		db = filterTags(db, query.Tags, query.AllTags)
+	}
+	if query.ProjectID != nil {
+		db = db.Where("project_id = ?", *query.ProjectID)
+	}
+	if query.ParentID != nil {
+		db = db.Where("parent_id = ?", *query.ParentID)
+	}
+	return db
+}
+
+// sortTodos orders by the requested columns, falling back to id so that rows
+// with equal sort keys keep a stable order across pages.
+func sortTodos(db *gorm.DB, fields []SortField) *gorm.DB {
+	hasID := false
+	for _, f := range fields {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: f.Column}, Desc: f.Desc})
+		hasID = hasID || f.Column == "id"
+	}
+	if !hasID {
+		db = db.Order("id")
+	}
+	return db
+}
+
+// likePattern builds a substring LIKE pattern, escaping the wildcards in text.
+func likePattern(text string) string {
+	return "%" + likeEscaper.Replace(text) + "%"
+}
+
+// likeEscaper escapes with "!" rather than a backslash because MySQL and SQLite
+// disagree on how a backslash inside a string literal is parsed.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func (r *GormTodoRepository) Delete(ctx context.Context, id, version uint) error {
+	return updateVersioned(r.db.WithContext(ctx), id, version, map[string]any{"deleted_at": time.Now()})
+}
+
+func (r *GormTodoRepository) Restore(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&models.Todo{}).Scopes(accessible).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTodoNotFound
+	}
+	return nil
+}
+
+func (r *GormTodoRepository) Purge(ctx context.Context, id uint) error {
+	purged, err := r.purge(ctx, accessible, "id = ? AND deleted_at IS NOT NULL", id)
+	if err == nil && purged == 0 {
+		return ErrTodoNotFound
+	}
+	return err
+}
+
+func (r *GormTodoRepository) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	return r.purge(ctx, owned, "deleted_at IS NOT NULL AND deleted_at < ?", before)
+}
+
+// purge hard-deletes the todos in scope matching the condition along with
+// their tag and dependency associations and their shares. Subtasks left
+// behind lose their parent.
+func (r *GormTodoRepository) purge(ctx context.Context, scope func(*gorm.DB) *gorm.DB, condition string, args ...any) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(&models.Todo{}).Scopes(scope).Where(condition, args...).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Exec("DELETE FROM todo_tags WHERE todo_id IN ?", ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("todo_id IN ? OR blocker_id IN ?", ids, ids).Delete(&models.TodoDependency{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("todo_id IN ?", ids).Delete(&models.Share{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Todo{}).Where("parent_id IN ?", ids).Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Delete(&models.Todo{}, ids)
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+func (r *GormTodoRepository) AddRevision(ctx context.Context, revision *models.TodoRevision) error {
+	db := r.db.WithContext(ctx)
+
+	var latest uint
+	if err := db.Model(&models.TodoRevision{}).Where("todo_id = ?", revision.TodoID).
+		Select("COALESCE(MAX(revision), 0)").Scan(&latest).Error; err != nil {
+		return err
+	}
+	revision.Revision = latest + 1
+	return db.Create(revision).Error
+}
+
+func (r *GormTodoRepository) ListRevisions(ctx context.Context, todoID uint) ([]models.TodoRevision, error) {
+	db := r.db.WithContext(ctx)
+	if visible, err := revisionsVisible(db, todoID); err != nil || !visible {
+		return nil, err
+	}
+	var revisions []models.TodoRevision
+	err := db.Where("todo_id = ?", todoID).Order("revision").Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *GormTodoRepository) FindRevision(ctx context.Context, todoID, revision uint) (*models.TodoRevision, error) {
+	db := r.db.WithContext(ctx)
+	if visible, err := revisionsVisible(db, todoID); err != nil || !visible {
+		return nil, cmp.Or(err, ErrRevisionNotFound)
+	}
+	var found models.TodoRevision
+	err := db.Where("todo_id = ? AND revision = ?", todoID, revision).First(&found).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+// revisionsVisible reports whether the history of a todo may be read with the
+// context of db. A user only sees the history of the todos they may access,
+// trashed ones included; purged todos have no owner left to check against.
+func revisionsVisible(db *gorm.DB, todoID uint) (bool, error) {
+	if _, ok := OwnerFrom(db.Statement.Context); !ok {
+		return true, nil
+	}
+	var count int64
+	err := db.Unscoped().Model(&models.Todo{}).Scopes(accessible).Where("id = ?", todoID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *GormTodoRepository) Transaction(ctx context.Context, fn func(repo TodoRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewGormTodoRepository(tx))
+	})
+}
+
+// translateError maps driver errors onto the repository's sentinel errors.
+// It relies on gorm.Config.TranslateError being enabled for the connection.
+func translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrTodoNotFound
+	}
+	return err
+}
+
+// translateTitleError is translateError for writes of todos whose id is not
+// in question, where the only unique index left to violate is the one on
+// titles.
+func translateTitleError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicateTitle
+	}
+	return translateError(err)
+}
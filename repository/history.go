@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+type actorKey struct{}
+
+type revertKey struct{}
+
+// WithActor attaches the identity making a change to ctx so that it can be
+// stored on the revisions recorded for that change.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor attached by WithActor, or "anonymous".
+func ActorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
+
+// HistoryTodoRepository wraps a TodoRepository and records a TodoRevision for
+// every create, update, delete and restore made through it. Each write and its
+// revision are stored in the same transaction.
+type HistoryTodoRepository struct {
+	TodoRepository
+}
+
+func NewHistoryTodoRepository(inner TodoRepository) *HistoryTodoRepository {
+	return &HistoryTodoRepository{TodoRepository: inner}
+}
+
+func (r *HistoryTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	return r.TodoRepository.Transaction(ctx, func(tx TodoRepository) error {
+		if err := tx.Create(ctx, todo); err != nil {
+			return err
+		}
+		return record(ctx, tx, models.RevisionCreate, models.TodoSnapshot{}, todo)
+	})
+}
+
+func (r *HistoryTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	return r.write(ctx, todo.ID, models.RevisionUpdate, func(tx TodoRepository) error {
+		return tx.Update(ctx, todo)
+	})
+}
+
+func (r *HistoryTodoRepository) Replace(ctx context.Context, todo *models.Todo) error {
+	action := models.RevisionUpdate
+	if _, ok := ctx.Value(revertKey{}).(uint); ok {
+		action = models.RevisionRevert
+	}
+	return r.write(ctx, todo.ID, action, func(tx TodoRepository) error {
+		return tx.Replace(ctx, todo)
+	})
+}
+
+func (r *HistoryTodoRepository) Delete(ctx context.Context, id, version uint) error {
+	return r.TodoRepository.Transaction(ctx, func(tx TodoRepository) error {
+		before, err := tx.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(ctx, id, version); err != nil {
+			return err
+		}
+		return record(ctx, tx, models.RevisionDelete, models.SnapshotOf(before), before)
+	})
+}
+
+func (r *HistoryTodoRepository) Restore(ctx context.Context, id uint) error {
+	return r.TodoRepository.Transaction(ctx, func(tx TodoRepository) error {
+		if err := tx.Restore(ctx, id); err != nil {
+			return err
+		}
+		after, err := tx.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return record(ctx, tx, models.RevisionRestore, models.SnapshotOf(after), after)
+	})
+}
+
+func (r *HistoryTodoRepository) Transaction(ctx context.Context, fn func(repo TodoRepository) error) error {
+	return r.TodoRepository.Transaction(ctx, func(tx TodoRepository) error {
+		return fn(NewHistoryTodoRepository(tx))
+	})
+}
+
+// write runs an update of the todo with the given id and records a revision
+// when it changed any field.
+func (r *HistoryTodoRepository) write(ctx context.Context, id uint, action string, fn func(tx TodoRepository) error) error {
+	return r.TodoRepository.Transaction(ctx, func(tx TodoRepository) error {
+		before, err := tx.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		after, err := tx.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if action != models.RevisionRevert && len(models.SnapshotOf(before).Diff(models.SnapshotOf(after))) == 0 {
+			return nil
+		}
+		return record(ctx, tx, action, models.SnapshotOf(before), after)
+	})
+}
+
+func record(ctx context.Context, tx TodoRepository, action string, before models.TodoSnapshot, after *models.Todo) error {
+	snapshot := models.SnapshotOf(after)
+	revision := &models.TodoRevision{
+		TodoID:   after.ID,
+		Action:   action,
+		Actor:    ActorFrom(ctx),
+		Changes:  before.Diff(snapshot),
+		Snapshot: snapshot,
+	}
+	if target, ok := ctx.Value(revertKey{}).(uint); ok {
+		revision.RevertedTo = &target
+	}
+	return tx.AddRevision(ctx, revision)
+}
+
+// RevertTodo sets the editable fields of a live todo back to their state after
+// the given revision. The change is itself recorded as a revert revision when
+// repo records history.
+func RevertTodo(ctx context.Context, repo TodoRepository, id, revision uint) (*models.Todo, error) {
+	var reverted *models.Todo
+	err := repo.Transaction(ctx, func(tx TodoRepository) error {
+		target, err := tx.FindRevision(ctx, id, revision)
+		if err != nil {
+			return err
+		}
+		todo, err := tx.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		target.Snapshot.ApplyTo(todo)
+		if err := tx.Replace(context.WithValue(ctx, revertKey{}, revision), todo); err != nil {
+			return err
+		}
+		reverted, err = tx.FindByID(ctx, id)
+		return err
+	})
+	return reverted, err
+}
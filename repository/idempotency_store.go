@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+)
+
+// IdempotencyStore persists Idempotency-Key reservations and the responses
+// recorded for them. Expired keys behave as if they had never been used.
+type IdempotencyStore interface {
+	// Reserve claims key.Key for a new request. When the key is already held
+	// by an unexpired record, that record is returned and nothing is written.
+	Reserve(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error)
+	// Complete stores the response of a reserved key.
+	Complete(ctx context.Context, key *models.IdempotencyKey) error
+	// Release drops a reservation so the request can be retried from scratch.
+	Release(ctx context.Context, key string) error
+	// PurgeExpired removes keys that expired before the given time.
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type GormIdempotencyStore struct {
+	db *gorm.DB
+}
+
+func NewGormIdempotencyStore(db *gorm.DB) *GormIdempotencyStore {
+	return &GormIdempotencyStore{db: db}
+}
+
+func ProvideIdempotencyStore(db *gorm.DB) IdempotencyStore {
+	return NewGormIdempotencyStore(db)
+}
+
+func (s *GormIdempotencyStore) Reserve(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	db := s.db.WithContext(ctx)
+	if err := db.Where("`key` = ? AND expires_at <= ?", key.Key, time.Now()).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, err
+	}
+
+	err := db.Create(key).Error
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, err
+	}
+
+	var existing models.IdempotencyKey
+	if err := db.Where("`key` = ?", key.Key).First(&existing).Error; err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (s *GormIdempotencyStore) Complete(ctx context.Context, key *models.IdempotencyKey) error {
+	return s.db.WithContext(ctx).Model(&models.IdempotencyKey{}).Where("`key` = ?", key.Key).
+		Select("status_code", "header", "body").Updates(key).Error
+}
+
+func (s *GormIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("`key` = ?", key).Delete(&models.IdempotencyKey{}).Error
+}
+
+func (s *GormIdempotencyStore) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+// MemoryIdempotencyStore keeps idempotency keys in a map. It is meant for tests
+// and single-process setups that also use MemoryTodoRepository.
+type MemoryIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]models.IdempotencyKey
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{keys: map[string]models.IdempotencyKey{}}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.keys[key.Key]; ok && existing.ExpiresAt.After(time.Now()) {
+		return &existing, nil
+	}
+	key.CreatedAt = time.Now().Round(0)
+	s.keys[key.Key] = *key
+	return nil, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key *models.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.keys[key.Key]
+	if !ok {
+		return nil
+	}
+	stored.StatusCode = key.StatusCode
+	stored.Header = key.Header
+	stored.Body = key.Body
+	s.keys[key.Key] = stored
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for k, key := range s.keys {
+		if key.ExpiresAt.Before(before) {
+			delete(s.keys, k)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+func (r *MemoryTodoRepository) AddDependency(ctx context.Context, todoID, blockerID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.live(todoID)
+	if !ok || !r.visible(ctx, todo) {
+		return ErrTodoNotFound
+	}
+	if blocker, ok := r.live(blockerID); !ok || blocker.OwnerID != todo.OwnerID || !r.visible(ctx, blocker) {
+		return ErrBlockerNotFound
+	}
+	if err := r.checkDependency(todoID, blockerID); err != nil {
+		return err
+	}
+	if !slices.Contains(r.blockersOf(todoID), blockerID) {
+		r.dependencies = append(r.dependencies, models.TodoDependency{
+			TodoID:    todoID,
+			BlockerID: blockerID,
+			CreatedAt: time.Now().Round(0),
+		})
+	}
+	return nil
+}
+
+func (r *MemoryTodoRepository) RemoveDependency(ctx context.Context, todoID, blockerID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if todo, ok := r.live(todoID); !ok || !r.visible(ctx, todo) {
+		return ErrDependencyNotFound
+	}
+	before := len(r.dependencies)
+	r.dependencies = slices.DeleteFunc(r.dependencies, func(d models.TodoDependency) bool {
+		return d.TodoID == todoID && d.BlockerID == blockerID
+	})
+	if len(r.dependencies) == before {
+		return ErrDependencyNotFound
+	}
+	return nil
+}
+
+// checkDependency mirrors the GORM check of the same name.
+func (r *MemoryTodoRepository) checkDependency(todoID, blockerID uint) error {
+	if todoID == blockerID {
+		return ErrDependencyCycle
+	}
+	seen := map[uint]bool{blockerID: true}
+	for frontier := []uint{blockerID}; len(frontier) > 0; {
+		id := frontier[0]
+		frontier = frontier[1:]
+		for _, next := range r.blockersOf(id) {
+			if next == todoID {
+				return ErrDependencyCycle
+			}
+			if !seen[next] {
+				seen[next] = true
+				frontier = append(frontier, next)
+			}
+		}
+	}
+	return nil
+}
+
+// checkUnblocked mirrors the GORM check of the same name for the stored
+// state of a todo that is about to be completed.
+func (r *MemoryTodoRepository) checkUnblocked(ctx context.Context, current models.Todo) error {
+	if current.Complete || blockedCompletionAllowed(ctx) {
+		return nil
+	}
+	if r.withDependencies(current).Blocked {
+		return ErrTodoBlocked
+	}
+	return nil
+}
+
+// blockersOf returns the ids of every todo, trashed or not, that id waits for.
+func (r *MemoryTodoRepository) blockersOf(id uint) []uint {
+	var blockers []uint
+	for _, d := range r.dependencies {
+		if d.TodoID == id {
+			blockers = append(blockers, d.BlockerID)
+		}
+	}
+	return blockers
+}
+
+// withDependencies returns todo with the computed BlockedBy and Blocked
+// fields filled in.
+func (r *MemoryTodoRepository) withDependencies(todo models.Todo) models.Todo {
+	todo.BlockedBy, todo.Blocked = nil, false
+	blockers := r.blockersOf(todo.ID)
+	slices.Sort(blockers)
+	for _, id := range blockers {
+		if blocker, ok := r.live(id); ok {
+			todo.BlockedBy = append(todo.BlockedBy, id)
+			todo.Blocked = todo.Blocked || !blocker.Complete
+		}
+	}
+	return todo
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+func (r *MemoryTodoRepository) CreateProject(ctx context.Context, project *models.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := normalizeProject(project); err != nil {
+		return err
+	}
+	project.OwnerID = ownerOf(ctx, project.OwnerID)
+	if r.projectNameTaken(project.OwnerID, project.Name, 0) {
+		return ErrDuplicateProject
+	}
+	r.nextProjectID++
+	now := time.Now().Round(0)
+	project.ID = r.nextProjectID
+	project.CreatedAt = now
+	project.UpdatedAt = now
+	r.projects[project.ID] = *project
+	return nil
+}
+
+func (r *MemoryTodoRepository) UpdateProject(ctx context.Context, project *models.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.projects[project.ID]
+	if !ok || !r.projectVisible(ctx, current) {
+		return ErrProjectNotFound
+	}
+	if err := normalizeProject(project); err != nil {
+		return err
+	}
+	if r.projectNameTaken(current.OwnerID, project.Name, project.ID) {
+		return ErrDuplicateProject
+	}
+	current.Name = project.Name
+	current.Description = project.Description
+	current.UpdatedAt = time.Now().Round(0)
+	r.projects[project.ID] = current
+	return nil
+}
+
+func (r *MemoryTodoRepository) FindProject(ctx context.Context, id uint) (*models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[id]
+	if !ok || !r.projectVisible(ctx, project) {
+		return nil, ErrProjectNotFound
+	}
+	return &project, nil
+}
+
+func (r *MemoryTodoRepository) ListProjects(ctx context.Context) ([]models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	projects := make([]models.Project, 0, len(r.projects))
+	for _, project := range r.projects {
+		if r.projectVisible(ctx, project) {
+			projects = append(projects, project)
+		}
+	}
+	slices.SortFunc(projects, func(a, b models.Project) int { return strings.Compare(a.Name, b.Name) })
+	return projects, nil
+}
+
+func (r *MemoryTodoRepository) DeleteProject(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if project, ok := r.projects[id]; !ok || !r.projectVisible(ctx, project) {
+		return ErrProjectNotFound
+	}
+	for _, todo := range r.todos {
+		if todo.ProjectID != nil && *todo.ProjectID == id {
+			return ErrProjectNotEmpty
+		}
+	}
+	delete(r.projects, id)
+	r.shares = slices.DeleteFunc(r.shares, ShareTarget{ProjectID: id}.matches)
+	return nil
+}
+
+// checkPlacement mirrors the GORM check of the same name: the project has to
+// exist for the owner and the title must be free within it, trashed todos
+// included.
+func (r *MemoryTodoRepository) checkPlacement(ctx context.Context, id, ownerID uint, seriesID, projectID *uint, title string) error {
+	if projectID != nil {
+		if project, ok := r.projects[*projectID]; !ok || project.OwnerID != ownerID || !r.projectVisible(ctx, project) {
+			return ErrProjectNotFound
+		}
+	}
+	for otherID, todo := range r.todos {
+		if seriesID != nil && todo.SeriesID != nil && *todo.SeriesID == *seriesID {
+			continue
+		}
+		if projectID == nil && todo.OwnerID != ownerID {
+			continue
+		}
+		if otherID != id && todo.Title == title && sameID(todo.ProjectID, projectID) {
+			return ErrDuplicateTitle
+		}
+	}
+	return nil
+}
+
+func (r *MemoryTodoRepository) projectNameTaken(ownerID uint, name string, exceptID uint) bool {
+	for id, project := range r.projects {
+		if id != exceptID && project.OwnerID == ownerID && project.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sameID compares optional ids, treating two nils as equal.
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/policy"
+)
+
+func (r *MemoryTodoRepository) SaveShare(ctx context.Context, share *models.Share) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	target := targetOf(share)
+	owner, ok := r.targetOwner(target)
+	if !ok {
+		return target.notFound()
+	}
+	if owner == share.UserID {
+		return ErrShareWithOwner
+	}
+
+	now := time.Now().Round(0)
+	for i, existing := range r.shares {
+		if target.matches(existing) && existing.UserID == share.UserID {
+			existing.Role = share.Role
+			existing.UpdatedAt = now
+			r.shares[i] = existing
+			*share = existing
+			return nil
+		}
+	}
+	r.nextShareID++
+	target.apply(share)
+	share.ID = r.nextShareID
+	share.CreatedAt = now
+	share.UpdatedAt = now
+	r.shares = append(r.shares, *share)
+	return nil
+}
+
+func (r *MemoryTodoRepository) ListShares(ctx context.Context, target ShareTarget) ([]models.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sharesOn(target), nil
+}
+
+func (r *MemoryTodoRepository) DeleteShare(ctx context.Context, target ShareTarget, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, share := range r.shares {
+		if target.matches(share) && share.UserID == userID {
+			r.shares = append(r.shares[:i], r.shares[i+1:]...)
+			return nil
+		}
+	}
+	return ErrShareNotFound
+}
+
+func (r *MemoryTodoRepository) Access(ctx context.Context, target ShareTarget) (policy.Resource, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if target.ProjectID != 0 {
+		project, ok := r.projects[target.ProjectID]
+		if !ok || !r.projectVisible(ctx, project) {
+			return policy.Resource{}, ErrProjectNotFound
+		}
+		return r.projectResource(project), nil
+	}
+
+	todo, ok := r.todos[target.TodoID]
+	if !ok || !r.visible(ctx, todo) {
+		return policy.Resource{}, ErrTodoNotFound
+	}
+	resource := policy.Resource{OwnerID: todo.OwnerID, Grants: grants(r.sharesOn(target))}
+	if todo.ProjectID != nil {
+		project := r.projectResource(r.projects[*todo.ProjectID])
+		resource.Project = &project
+	}
+	return resource, nil
+}
+
+func (r *MemoryTodoRepository) projectResource(project models.Project) policy.Resource {
+	return policy.Resource{OwnerID: project.OwnerID, Grants: grants(r.sharesOn(ShareTarget{ProjectID: project.ID}))}
+}
+
+func (r *MemoryTodoRepository) sharesOn(target ShareTarget) []models.Share {
+	var shares []models.Share
+	for _, share := range r.shares {
+		if target.matches(share) {
+			shares = append(shares, share)
+		}
+	}
+	return shares
+}
+
+// targetOwner returns the owner of the live todo or project a share is on.
+func (r *MemoryTodoRepository) targetOwner(target ShareTarget) (uint, bool) {
+	if target.TodoID != 0 {
+		todo, ok := r.live(target.TodoID)
+		return todo.OwnerID, ok
+	}
+	project, ok := r.projects[target.ProjectID]
+	return project.OwnerID, ok
+}
+
+// visible mirrors the accessible GORM scope.
+func (r *MemoryTodoRepository) visible(ctx context.Context, todo models.Todo) bool {
+	owner, ok := OwnerFrom(ctx)
+	if !ok || todo.OwnerID == owner {
+		return true
+	}
+	for _, share := range r.shares {
+		if share.UserID != owner {
+			continue
+		}
+		if sameID(share.TodoID, &todo.ID) || (todo.ProjectID != nil && sameID(share.ProjectID, todo.ProjectID)) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectVisible mirrors the accessibleProjects GORM scope.
+func (r *MemoryTodoRepository) projectVisible(ctx context.Context, project models.Project) bool {
+	owner, ok := OwnerFrom(ctx)
+	if !ok || project.OwnerID == owner {
+		return true
+	}
+	for _, share := range r.shares {
+		if share.UserID == owner && sameID(share.ProjectID, &project.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// placementOwner mirrors the GORM function of the same name.
+func (r *MemoryTodoRepository) placementOwner(ctx context.Context, todo *models.Todo) (uint, error) {
+	if todo.ProjectID != nil {
+		project, ok := r.projects[*todo.ProjectID]
+		if !ok || !r.projectVisible(ctx, project) {
+			return 0, ErrProjectNotFound
+		}
+		return project.OwnerID, nil
+	}
+	if todo.ParentID != nil {
+		parent, ok := r.live(*todo.ParentID)
+		if !ok || !r.visible(ctx, parent) {
+			return 0, ErrParentNotFound
+		}
+		return parent.OwnerID, nil
+	}
+	return ownerOf(ctx, todo.OwnerID), nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+func (r *MemoryTodoRepository) CreateTag(ctx context.Context, tag *models.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := tag.Normalize(); err != nil {
+		return err
+	}
+	tag.OwnerID = ownerOf(ctx, tag.OwnerID)
+	if r.tagNamed(tag.OwnerID, tag.Name, 0) != nil {
+		return ErrDuplicateTag
+	}
+	r.addTag(tag)
+	return nil
+}
+
+func (r *MemoryTodoRepository) UpdateTag(ctx context.Context, tag *models.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.tags[tag.ID]
+	if !ok || !visibleTo(ctx, current.OwnerID) {
+		return ErrTagNotFound
+	}
+	if err := tag.Normalize(); err != nil {
+		return err
+	}
+	if r.tagNamed(current.OwnerID, tag.Name, tag.ID) != nil {
+		return ErrDuplicateTag
+	}
+	current.Name = tag.Name
+	current.Colour = tag.Colour
+	current.UpdatedAt = time.Now().Round(0)
+	r.tags[tag.ID] = current
+	return nil
+}
+
+func (r *MemoryTodoRepository) FindTag(ctx context.Context, id uint) (*models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tag, ok := r.tags[id]
+	if !ok || !visibleTo(ctx, tag.OwnerID) {
+		return nil, ErrTagNotFound
+	}
+	return &tag, nil
+}
+
+func (r *MemoryTodoRepository) ListTags(ctx context.Context) ([]models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tags := make([]models.Tag, 0, len(r.tags))
+	for _, tag := range r.tags {
+		if visibleTo(ctx, tag.OwnerID) {
+			tags = append(tags, tag)
+		}
+	}
+	sortTags(tags)
+	return tags, nil
+}
+
+func (r *MemoryTodoRepository) DeleteTag(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tag, ok := r.tags[id]; !ok || !visibleTo(ctx, tag.OwnerID) {
+		return ErrTagNotFound
+	}
+	delete(r.tags, id)
+	for todoID, todo := range r.todos {
+		kept := slices.DeleteFunc(slices.Clone(todo.Tags), func(tag models.Tag) bool { return tag.ID == id })
+		if len(kept) != len(todo.Tags) {
+			todo.Tags = kept
+			r.todos[todoID] = todo
+		}
+	}
+	return nil
+}
+
+// resolveTags maps the tags of a write onto stored tags of the owner, creating
+// missing ones. Every entry is checked before any tag is created, so a failed
+// write leaves no tags behind. The result holds ids only, which is how todos
+// keep their tags in memory.
+func (r *MemoryTodoRepository) resolveTags(ownerID uint, tags []models.Tag) ([]models.Tag, error) {
+	for i := range tags {
+		if tags[i].Name == "" && tags[i].ID != 0 {
+			if tag, ok := r.tags[tags[i].ID]; !ok || tag.OwnerID != ownerID {
+				return nil, ErrTagNotFound
+			}
+			continue
+		}
+		if err := tags[i].Normalize(); err != nil {
+			return nil, err
+		}
+	}
+
+	refs := make([]models.Tag, 0, len(tags))
+	for _, tag := range tags {
+		id := tag.ID
+		if tag.Name != "" {
+			if found := r.tagNamed(ownerID, tag.Name, 0); found != nil {
+				id = found.ID
+			} else {
+				created := models.Tag{OwnerID: ownerID, Name: tag.Name, Colour: tag.Colour}
+				r.addTag(&created)
+				id = created.ID
+			}
+		}
+		if !slices.ContainsFunc(refs, func(ref models.Tag) bool { return ref.ID == id }) {
+			refs = append(refs, models.Tag{ID: id})
+		}
+	}
+	return refs, nil
+}
+
+// withTags returns todo with its id-only tag references replaced by the
+// stored tags, sorted by name.
+func (r *MemoryTodoRepository) withTags(todo models.Todo) models.Todo {
+	tags := make([]models.Tag, 0, len(todo.Tags))
+	for _, ref := range todo.Tags {
+		if tag, ok := r.tags[ref.ID]; ok {
+			tags = append(tags, tag)
+		}
+	}
+	sortTags(tags)
+	todo.Tags = tags
+	return todo
+}
+
+func (r *MemoryTodoRepository) addTag(tag *models.Tag) {
+	r.nextTagID++
+	now := time.Now().Round(0)
+	tag.ID = r.nextTagID
+	tag.CreatedAt = now
+	tag.UpdatedAt = now
+	r.tags[tag.ID] = *tag
+}
+
+func (r *MemoryTodoRepository) tagNamed(ownerID uint, name string, exceptID uint) *models.Tag {
+	for id, tag := range r.tags {
+		if id != exceptID && tag.OwnerID == ownerID && tag.Name == name {
+			return &tag
+		}
+	}
+	return nil
+}
+
+// hasTags reports whether todo carries any, or all, of the named tags.
+func hasTags(todo models.Todo, names []string, all bool) bool {
+	carried := models.TagNames(todo.Tags)
+	for _, name := range names {
+		if slices.Contains(carried, name) != all {
+			return !all
+		}
+	}
+	return all
+}
@@ -0,0 +1,550 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+)
+
+// MemoryTodoRepository keeps todos in process memory. It is meant for tests
+// and for embedding the handlers without a database.
+type MemoryTodoRepository struct {
+	mu            sync.RWMutex
+	txMu          sync.Mutex
+	todos         map[uint]models.Todo
+	nextID        uint
+	revisions     []models.TodoRevision
+	tags          map[uint]models.Tag
+	nextTagID     uint
+	projects      map[uint]models.Project
+	nextProjectID uint
+	dependencies  []models.TodoDependency
+	shares        []models.Share
+	nextShareID   uint
+}
+
+func NewMemoryTodoRepository() *MemoryTodoRepository {
+	return &MemoryTodoRepository{
+		todos:    map[uint]models.Todo{},
+		tags:     map[uint]models.Tag{},
+		projects: map[uint]models.Project{},
+	}
+}
+
+func (r *MemoryTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	owner, err := r.placementOwner(ctx, todo)
+	if err != nil {
+		return err
+	}
+	todo.OwnerID = owner
+	if err := r.checkPlacement(ctx, 0, todo.OwnerID, todo.SeriesID, todo.ProjectID, todo.Title); err != nil {
+		return err
+	}
+	if err := r.checkParent(ctx, 0, todo.OwnerID, todo.ParentID); err != nil {
+		return err
+	}
+	tags, err := r.resolveTags(todo.OwnerID, todo.Tags)
+	if err != nil {
+		return err
+	}
+
+	r.nextID++
+	now := time.Now().Round(0)
+	todo.ID = r.nextID
+	todo.Version = 1
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+	todo.Tags = tags
+	r.todos[todo.ID] = *todo
+	*todo = r.withDependencies(r.withTags(*todo))
+	return nil
+}
+
+func (r *MemoryTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.editable(ctx, todo.ID, todo.Version)
+	if err != nil {
+		return err
+	}
+	if todo.Title != "" {
+		current.Title = todo.Title
+	}
+	if todo.ProjectID != nil {
+		current.ProjectID = todo.ProjectID
+	}
+	if todo.SeriesID != nil {
+		current.SeriesID = todo.SeriesID
+	}
+	if err := r.checkPlacement(ctx, todo.ID, current.OwnerID, current.SeriesID, current.ProjectID, current.Title); err != nil {
+		return err
+	}
+	if err := r.checkParent(ctx, todo.ID, current.OwnerID, todo.ParentID); err != nil {
+		return err
+	}
+	if todo.ParentID != nil {
+		current.ParentID = todo.ParentID
+	}
+	if todo.Description != "" {
+		current.Description = todo.Description
+	}
+	if todo.DueDate != nil {
+		current.DueDate = todo.DueDate
+	}
+	if todo.RemindAt != nil {
+		current.RemindAt = todo.RemindAt
+	}
+	if todo.Recurrence != "" {
+		current.Recurrence = todo.Recurrence
+	}
+	if todo.Timezone != "" {
+		current.Timezone = todo.Timezone
+	}
+	if todo.Complete {
+		if err := r.checkUnblocked(ctx, current); err != nil {
+			return err
+		}
+		current.Complete = true
+	}
+	if todo.Tags != nil {
+		if current.Tags, err = r.resolveTags(current.OwnerID, todo.Tags); err != nil {
+			return err
+		}
+	}
+	r.save(current)
+	return nil
+}
+
+func (r *MemoryTodoRepository) Replace(ctx context.Context, todo *models.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := r.editable(ctx, todo.ID, todo.Version)
+	if err != nil {
+		return err
+	}
+	if err := r.checkPlacement(ctx, todo.ID, current.OwnerID, todo.SeriesID, todo.ProjectID, todo.Title); err != nil {
+		return err
+	}
+	if err := r.checkParent(ctx, todo.ID, current.OwnerID, todo.ParentID); err != nil {
+		return err
+	}
+	if todo.Complete {
+		if err := r.checkUnblocked(ctx, current); err != nil {
+			return err
+		}
+	}
+	current.Title = todo.Title
+	current.ProjectID = todo.ProjectID
+	current.ParentID = todo.ParentID
+	current.Description = todo.Description
+	current.DueDate = todo.DueDate
+	current.RemindAt = todo.RemindAt
+	current.Complete = todo.Complete
+	current.Recurrence = todo.Recurrence
+	current.Timezone = todo.Timezone
+	current.SeriesID = todo.SeriesID
+	if current.Tags, err = r.resolveTags(current.OwnerID, todo.Tags); err != nil {
+		return err
+	}
+	r.save(current)
+	return nil
+}
+
+func (r *MemoryTodoRepository) FindByID(ctx context.Context, id uint) (*models.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todo, ok := r.live(id)
+	if !ok || !r.visible(ctx, todo) {
+		return nil, ErrTodoNotFound
+	}
+	todo = r.withDependencies(r.withTags(todo))
+	return &todo, nil
+}
+
+func (r *MemoryTodoRepository) List(ctx context.Context, query TodoQuery) ([]models.Todo, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make([]models.Todo, 0, len(r.todos))
+	for _, todo := range r.todos {
+		todo = r.withDependencies(r.withTags(todo))
+		if todo.DeletedAt.Valid == query.Trashed && r.visible(ctx, todo) && matchesTodoQuery(todo, query) {
+			all = append(all, todo)
+		}
+	}
+	fields := query.Sort
+	if query.After != nil || query.Before != nil {
+		fields = []SortField{{Column: "created_at"}}
+	}
+	sort.Slice(all, func(i, j int) bool { return lessTodo(all[i], all[j], fields) })
+
+	var total int64
+	if !query.SkipTotal {
+		total = int64(len(all))
+	}
+	if query.Before != nil {
+		// Take the rows closest to the position, mirroring the DESC query
+		// that the GORM implementation reverses.
+		slices.Reverse(all)
+	}
+	start := min(max(query.Offset, 0), len(all))
+	end := len(all)
+	if query.Limit >= 0 {
+		end = min(start+query.Limit, len(all))
+	}
+	page := all[start:end]
+	if query.Before != nil {
+		slices.Reverse(page)
+	}
+	return page, total, nil
+}
+
+func (r *MemoryTodoRepository) Delete(ctx context.Context, id, version uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, err := r.editable(ctx, id, version)
+	if err != nil {
+		return err
+	}
+	todo.DeletedAt = gorm.DeletedAt{Time: time.Now().Round(0), Valid: true}
+	r.save(todo)
+	return nil
+}
+
+func (r *MemoryTodoRepository) Restore(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok || !todo.DeletedAt.Valid || !r.visible(ctx, todo) {
+		return ErrTodoNotFound
+	}
+	todo.DeletedAt = gorm.DeletedAt{}
+	r.save(todo)
+	return nil
+}
+
+func (r *MemoryTodoRepository) Purge(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok || !todo.DeletedAt.Valid || !r.visible(ctx, todo) {
+		return ErrTodoNotFound
+	}
+	r.remove(id)
+	return nil
+}
+
+func (r *MemoryTodoRepository) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, todo := range r.todos {
+		if todo.DeletedAt.Valid && todo.DeletedAt.Time.Before(before) && visibleTo(ctx, todo.OwnerID) {
+			r.remove(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (r *MemoryTodoRepository) AddRevision(ctx context.Context, revision *models.TodoRevision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	revision.Revision = 1
+	for _, existing := range r.revisions {
+		if existing.TodoID == revision.TodoID && existing.Revision >= revision.Revision {
+			revision.Revision = existing.Revision + 1
+		}
+	}
+	revision.ID = uint(len(r.revisions) + 1)
+	revision.CreatedAt = time.Now().Round(0)
+	r.revisions = append(r.revisions, *revision)
+	return nil
+}
+
+func (r *MemoryTodoRepository) ListRevisions(ctx context.Context, todoID uint) ([]models.TodoRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.revisionsVisible(ctx, todoID) {
+		return nil, nil
+	}
+	var revisions []models.TodoRevision
+	for _, revision := range r.revisions {
+		if revision.TodoID == todoID {
+			revisions = append(revisions, revision)
+		}
+	}
+	return revisions, nil
+}
+
+func (r *MemoryTodoRepository) FindRevision(ctx context.Context, todoID, revision uint) (*models.TodoRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.revisionsVisible(ctx, todoID) {
+		return nil, ErrRevisionNotFound
+	}
+	for _, existing := range r.revisions {
+		if existing.TodoID == todoID && existing.Revision == revision {
+			return &existing, nil
+		}
+	}
+	return nil, ErrRevisionNotFound
+}
+
+// revisionsVisible mirrors the GORM check of the same name.
+func (r *MemoryTodoRepository) revisionsVisible(ctx context.Context, todoID uint) bool {
+	if _, ok := OwnerFrom(ctx); !ok {
+		return true
+	}
+	todo, ok := r.todos[todoID]
+	return ok && r.visible(ctx, todo)
+}
+
+// editable returns a live todo of ctx's owner for writing, checking its
+// version when the caller passed one.
+func (r *MemoryTodoRepository) editable(ctx context.Context, id, version uint) (models.Todo, error) {
+	todo, ok := r.live(id)
+	if !ok || !r.visible(ctx, todo) {
+		return todo, ErrTodoNotFound
+	}
+	if version != 0 && todo.Version != version {
+		return todo, ErrVersionConflict
+	}
+	return todo, nil
+}
+
+// save stores a written todo, bumping its version and update time.
+func (r *MemoryTodoRepository) save(todo models.Todo) {
+	todo.Version++
+	todo.UpdatedAt = time.Now().Round(0)
+	r.todos[todo.ID] = todo
+}
+
+// remove deletes a todo for good, along with its dependencies and shares.
+// Its subtasks lose their parent.
+func (r *MemoryTodoRepository) remove(id uint) {
+	delete(r.todos, id)
+	r.dependencies = slices.DeleteFunc(r.dependencies, func(d models.TodoDependency) bool {
+		return d.TodoID == id || d.BlockerID == id
+	})
+	r.shares = slices.DeleteFunc(r.shares, ShareTarget{TodoID: id}.matches)
+	for childID, child := range r.todos {
+		if child.ParentID != nil && *child.ParentID == id {
+			child.ParentID = nil
+			r.todos[childID] = child
+		}
+	}
+}
+
+// checkParent mirrors the GORM check of the same name.
+func (r *MemoryTodoRepository) checkParent(ctx context.Context, id, ownerID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	if *parentID == id {
+		return ErrParentCycle
+	}
+	parent, ok := r.live(*parentID)
+	if !ok || parent.OwnerID != ownerID || !r.visible(ctx, parent) {
+		return ErrParentNotFound
+	}
+	for ancestor := parent.ParentID; ancestor != nil && id != 0; ancestor = r.todos[*ancestor].ParentID {
+		if *ancestor == id {
+			return ErrParentCycle
+		}
+	}
+	return nil
+}
+
+// live returns the todo with the given id unless it is missing or trashed.
+func (r *MemoryTodoRepository) live(id uint) (models.Todo, bool) {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt.Valid {
+		return models.Todo{}, false
+	}
+	return todo, true
+}
+
+func matchesTodoQuery(todo models.Todo, query TodoQuery) bool {
+	if query.Complete != nil && todo.Complete != *query.Complete {
+		return false
+	}
+	ranges := []struct {
+		value  *time.Time
+		bound  *time.Time
+		before bool
+	}{
+		{todo.DueDate, query.DueBefore, true},
+		{todo.DueDate, query.DueAfter, false},
+		{todo.RemindAt, query.RemindBefore, true},
+		{&todo.CreatedAt, query.CreatedBefore, true},
+		{&todo.CreatedAt, query.CreatedAfter, false},
+		{&todo.UpdatedAt, query.UpdatedBefore, true},
+		{&todo.UpdatedAt, query.UpdatedAfter, false},
+	}
+	for _, r := range ranges {
+		if r.bound == nil {
+			continue
+		}
+		// Like SQL comparisons, a missing value never matches a range.
+		if r.value == nil || r.before && !r.value.Before(*r.bound) || !r.before && !r.value.After(*r.bound) {
+			return false
+		}
+	}
+	position := TodoPosition{CreatedAt: todo.CreatedAt, ID: todo.ID}
+	if query.After != nil && !position.after(*query.After) {
+		return false
+	}
+	if query.Before != nil && !query.Before.after(position) {
+		return false
+	}
+	if query.Search != "" && !containsFold(todo.Title, query.Search) && !containsFold(todo.Description, query.Search) {
+		return false
+	}
+	if query.Title != "" && !containsFold(todo.Title, query.Title) {
+		return false
+	}
+	if query.Description != "" && !containsFold(todo.Description, query.Description) {
+		return false
+	}
+	if len(query.Tags) > 0 && !hasTags(todo, query.Tags, query.AllTags) {
+		return false
+	}
+	if query.ProjectID != nil && !sameID(todo.ProjectID, query.ProjectID) {
+		return false
+	}
+	if query.ParentID != nil && !sameID(todo.ParentID, query.ParentID) {
+		return false
+	}
+	return true
+}
+
+// lessTodo mirrors the ORDER BY built by sortTodos, including the id tie-breaker.
+func lessTodo(a, b models.Todo, fields []SortField) bool {
+	for _, f := range fields {
+		c := compareTodoColumn(a, b, f.Column)
+		if c == 0 {
+			continue
+		}
+		if f.Desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	return a.ID < b.ID
+}
+
+func compareTodoColumn(a, b models.Todo, column string) int {
+	switch column {
+	case "id":
+		return cmp.Compare(a.ID, b.ID)
+	case "title":
+		return strings.Compare(a.Title, b.Title)
+	case "description":
+		return strings.Compare(a.Description, b.Description)
+	case "due_date":
+		return compareOptionalTime(a.DueDate, b.DueDate)
+	case "complete":
+		return cmp.Compare(boolRank(a.Complete), boolRank(b.Complete))
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	}
+	return 0
+}
+
+func (p TodoPosition) after(other TodoPosition) bool {
+	if c := p.CreatedAt.Compare(other.CreatedAt); c != 0 {
+		return c > 0
+	}
+	return p.ID > other.ID
+}
+
+// compareOptionalTime orders nil before any time, as MySQL and SQLite order NULLs.
+func compareOptionalTime(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Compare(*b)
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// Transaction serialises transactions and rolls back by restoring a snapshot
+// taken before fn ran. Writes made outside a transaction while fn runs are
+// lost on rollback, which is acceptable for the tests this type serves.
+func (r *MemoryTodoRepository) Transaction(ctx context.Context, fn func(repo TodoRepository) error) error {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	r.mu.RLock()
+	snapshot := make(map[uint]models.Todo, len(r.todos))
+	for id, todo := range r.todos {
+		snapshot[id] = todo
+	}
+	tags, projects := maps.Clone(r.tags), maps.Clone(r.projects)
+	nextID, nextTagID, nextProjectID := r.nextID, r.nextTagID, r.nextProjectID
+	revisions := len(r.revisions)
+	dependencies := slices.Clone(r.dependencies)
+	shares, nextShareID := slices.Clone(r.shares), r.nextShareID
+	r.mu.RUnlock()
+
+	if err := fn(memoryTodoTx{r}); err != nil {
+		r.mu.Lock()
+		r.todos = snapshot
+		r.tags, r.projects = tags, projects
+		r.nextID, r.nextTagID, r.nextProjectID = nextID, nextTagID, nextProjectID
+		r.revisions = r.revisions[:revisions]
+		r.dependencies = dependencies
+		r.shares, r.nextShareID = shares, nextShareID
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// memoryTodoTx is handed to transaction callbacks so that nested calls to
+// Transaction join the outer one instead of deadlocking on txMu.
+type memoryTodoTx struct {
+	*MemoryTodoRepository
+}
+
+func (tx memoryTodoTx) Transaction(ctx context.Context, fn func(repo TodoRepository) error) error {
+	return fn(tx)
+}
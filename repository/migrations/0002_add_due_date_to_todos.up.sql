@@ -0,0 +1 @@
+ALTER TABLE todos ADD COLUMN due_date DATETIME(3) NULL AFTER description;
\ No newline at end of file
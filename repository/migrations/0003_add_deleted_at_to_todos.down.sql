@@ -0,0 +1 @@
+ALTER TABLE todos DROP INDEX idx_todos_deleted_at, DROP COLUMN deleted_at;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE todos ADD COLUMN deleted_at DATETIME(3) NULL, ADD INDEX idx_todos_deleted_at (deleted_at);
\ No newline at end of file
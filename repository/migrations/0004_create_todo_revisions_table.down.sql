@@ -0,0 +1 @@
+DROP TABLE IF EXISTS todo_revisions;
\ No newline at end of file
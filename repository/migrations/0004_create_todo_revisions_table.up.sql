@@ -0,0 +1,12 @@
+CREATE TABLE todo_revisions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    todo_id INT NOT NULL,
+    revision INT NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    actor VARCHAR(255),
+    reverted_to INT NULL,
+    changes TEXT,
+    snapshot TEXT,
+    created_at DATETIME(3),
+    UNIQUE KEY idx_todo_revisions_todo_revision (todo_id, revision)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE todos ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE idempotency_keys (
+    `key` VARCHAR(255) NOT NULL PRIMARY KEY,
+    fingerprint VARCHAR(64) NOT NULL,
+    status_code INT,
+    header TEXT,
+    body LONGBLOB,
+    created_at DATETIME(3),
+    expires_at DATETIME(3) NOT NULL,
+    INDEX idx_idempotency_keys_expires_at (expires_at)
+);
\ No newline at end of file
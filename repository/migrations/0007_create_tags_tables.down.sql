@@ -0,0 +1 @@
+DROP TABLE IF EXISTS todo_tags;DROP TABLE IF EXISTS tags;
\ No newline at end of file
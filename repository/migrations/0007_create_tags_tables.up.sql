@@ -0,0 +1,15 @@
+CREATE TABLE tags (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    colour VARCHAR(7),
+    created_at DATETIME(3),
+    updated_at DATETIME(3),
+    UNIQUE KEY idx_tags_name (name)
+);
+CREATE TABLE todo_tags (
+    todo_id INT NOT NULL,
+    tag_id INT NOT NULL,
+    PRIMARY KEY (todo_id, tag_id),
+    CONSTRAINT fk_todo_tags_todo FOREIGN KEY (todo_id) REFERENCES todos (id),
+    CONSTRAINT fk_todo_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
\ No newline at end of file
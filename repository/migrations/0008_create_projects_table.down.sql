@@ -0,0 +1 @@
+ALTER TABLE todos DROP FOREIGN KEY fk_todos_project, DROP INDEX idx_todos_project_title, DROP COLUMN project_id;DROP TABLE IF EXISTS projects;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE projects (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at DATETIME(3),
+    updated_at DATETIME(3),
+    UNIQUE KEY idx_projects_name (name)
+);
+ALTER TABLE todos
+    ADD COLUMN project_id INT NULL,
+    ADD INDEX idx_todos_project_title (project_id, title(191)),
+    ADD CONSTRAINT fk_todos_project FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE RESTRICT;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE todos DROP FOREIGN KEY fk_todos_parent, DROP INDEX idx_todos_parent_id, DROP COLUMN parent_id;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE todos
+    ADD COLUMN parent_id INT NULL,
+    ADD INDEX idx_todos_parent_id (parent_id),
+    ADD CONSTRAINT fk_todos_parent FOREIGN KEY (parent_id) REFERENCES todos (id);
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE todo_dependencies (
+    todo_id INT NOT NULL,
+    blocker_id INT NOT NULL,
+    created_at DATETIME(3),
+    PRIMARY KEY (todo_id, blocker_id),
+    INDEX idx_todo_dependencies_blocker_id (blocker_id),
+    CONSTRAINT fk_todo_dependencies_todo FOREIGN KEY (todo_id) REFERENCES todos (id),
+    CONSTRAINT fk_todo_dependencies_blocker FOREIGN KEY (blocker_id) REFERENCES todos (id)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE todos DROP INDEX idx_todos_series_id, DROP COLUMN series_id, DROP COLUMN timezone, DROP COLUMN recurrence;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE todos
+    ADD COLUMN recurrence VARCHAR(255) NULL,
+    ADD COLUMN timezone VARCHAR(64) NULL,
+    ADD COLUMN series_id INT NULL,
+    ADD INDEX idx_todos_series_id (series_id);
\ No newline at end of file
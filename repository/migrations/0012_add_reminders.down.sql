@@ -0,0 +1,2 @@
+DROP TABLE reminders;
+ALTER TABLE todos DROP INDEX idx_todos_remind_at, DROP COLUMN remind_at;
\ No newline at end of file
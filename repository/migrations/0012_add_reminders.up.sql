@@ -0,0 +1,19 @@
+ALTER TABLE todos
+    ADD COLUMN remind_at DATETIME(3) NULL,
+    ADD INDEX idx_todos_remind_at (remind_at);
+
+CREATE TABLE reminders (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    todo_id INT NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    at DATETIME(3) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    next_attempt_at DATETIME(3) NOT NULL,
+    delivered_at DATETIME(3) NULL,
+    created_at DATETIME(3),
+    updated_at DATETIME(3),
+    UNIQUE INDEX idx_reminders_todo_kind_at (todo_id, kind, at),
+    INDEX idx_reminders_status_next_attempt (status, next_attempt_at)
+);
\ No newline at end of file
@@ -0,0 +1,27 @@
+CREATE TABLE webhooks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events TEXT NULL,
+    active BOOLEAN NOT NULL,
+    created_at DATETIME(3),
+    updated_at DATETIME(3)
+);
+
+CREATE TABLE webhook_deliveries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    webhook_id INT NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(3) NOT NULL,
+    response_status INT NULL,
+    last_error TEXT NULL,
+    delivered_at DATETIME(3) NULL,
+    redelivery_of INT NULL,
+    created_at DATETIME(3),
+    updated_at DATETIME(3),
+    INDEX idx_webhook_deliveries_webhook_id (webhook_id),
+    INDEX idx_webhook_deliveries_status_next_attempt (status, next_attempt_at)
+);
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE todo_events (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    type VARCHAR(32) NOT NULL,
+    occurred_at DATETIME(3) NOT NULL,
+    todo TEXT NULL,
+    INDEX idx_todo_events_occurred_at (occurred_at)
+);
\ No newline at end of file
@@ -0,0 +1,16 @@
+ALTER TABLE projects
+    DROP INDEX idx_projects_owner_name,
+    ADD UNIQUE INDEX idx_projects_name (name),
+    DROP COLUMN owner_id;
+
+ALTER TABLE tags
+    DROP INDEX idx_tags_owner_name,
+    ADD UNIQUE INDEX idx_tags_name (name),
+    DROP COLUMN owner_id;
+
+ALTER TABLE todos
+    DROP INDEX idx_todos_owner_id,
+    DROP COLUMN owner_id;
+
+DROP TABLE auth_tokens;
+DROP TABLE users;
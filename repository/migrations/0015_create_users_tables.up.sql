@@ -0,0 +1,34 @@
+CREATE TABLE users (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    username VARCHAR(64) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at DATETIME(3),
+    updated_at DATETIME(3),
+    UNIQUE INDEX idx_users_username (username)
+);
+
+CREATE TABLE auth_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    created_at DATETIME(3),
+    UNIQUE INDEX idx_auth_tokens_hash (hash),
+    INDEX idx_auth_tokens_user_id (user_id),
+    INDEX idx_auth_tokens_expires_at (expires_at),
+    CONSTRAINT fk_auth_tokens_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+ALTER TABLE todos
+    ADD COLUMN owner_id INT NOT NULL DEFAULT 0,
+    ADD INDEX idx_todos_owner_id (owner_id);
+
+ALTER TABLE tags
+    ADD COLUMN owner_id INT NOT NULL DEFAULT 0,
+    DROP INDEX idx_tags_name,
+    ADD UNIQUE INDEX idx_tags_owner_name (owner_id, name);
+
+ALTER TABLE projects
+    ADD COLUMN owner_id INT NOT NULL DEFAULT 0,
+    DROP INDEX idx_projects_name,
+    ADD UNIQUE INDEX idx_projects_owner_name (owner_id, name);
@@ -0,0 +1,13 @@
+CREATE TABLE shares (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    todo_id INT NULL,
+    project_id INT NULL,
+    user_id INT NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    created_at DATETIME(3),
+    updated_at DATETIME(3),
+    UNIQUE INDEX idx_shares_todo_user (todo_id, user_id),
+    UNIQUE INDEX idx_shares_project_user (project_id, user_id),
+    INDEX idx_shares_user_id (user_id),
+    CONSTRAINT fk_shares_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+ALTER TABLE todos
+    DROP INDEX idx_todos_owner_scope_title,
+    DROP COLUMN title_scope;
@@ -0,0 +1,5 @@
+ALTER TABLE todos ADD COLUMN title_scope INT NULL;
+
+UPDATE todos SET title_scope = COALESCE(project_id, 0) WHERE series_id IS NULL;
+
+ALTER TABLE todos ADD UNIQUE INDEX idx_todos_owner_scope_title (owner_id, title_scope, title(191));
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Xillon/golang-todo-api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ownerKey struct{}
+
+// WithOwner scopes repository calls made with ctx to the todos, tags and
+// projects of one user, and to the todos and projects shared with them: other
+// rows are reported as missing, and new rows belong to the user. Without an
+// owner, calls see every row, which is how API keys and the background
+// workers use the repository.
+func WithOwner(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, ownerKey{}, userID)
+}
+
+// OwnerFrom returns the user attached by WithOwner.
+func OwnerFrom(ctx context.Context) (uint, bool) {
+	owner, ok := ctx.Value(ownerKey{}).(uint)
+	return owner, ok
+}
+
+// ownerOf returns the owner new rows written with ctx belong to, or fallback
+// when ctx is not scoped to a user.
+func ownerOf(ctx context.Context, fallback uint) uint {
+	if owner, ok := OwnerFrom(ctx); ok {
+		return owner
+	}
+	return fallback
+}
+
+// placementOwner returns who a new todo written with the context of db
+// belongs to. A todo placed in a project, or below a parent, belongs to the
+// owner of that project or parent, so that a project's todos and a todo's
+// subtasks keep a single owner. Other todos belong to the context's user, or
+// keep their owner when the context has none.
+func placementOwner(db *gorm.DB, todo *models.Todo) (uint, error) {
+	if todo.ProjectID != nil {
+		var project models.Project
+		if err := db.Scopes(accessibleProjects).Select("id", "owner_id").First(&project, *todo.ProjectID).Error; err != nil {
+			return 0, translateProjectError(err)
+		}
+		return project.OwnerID, nil
+	}
+	if todo.ParentID != nil {
+		var parent models.Todo
+		err := db.Scopes(accessible).Select("id", "owner_id").First(&parent, *todo.ParentID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrParentNotFound
+		}
+		if err != nil {
+			return 0, err
+		}
+		return parent.OwnerID, nil
+	}
+	return ownerOf(db.Statement.Context, todo.OwnerID), nil
+}
+
+// visibleTo reports whether a row of the given owner may be seen with ctx.
+func visibleTo(ctx context.Context, ownerID uint) bool {
+	owner, ok := OwnerFrom(ctx)
+	return !ok || owner == ownerID
+}
+
+// owned is a GORM scope keeping the rows of the statement context's owner.
+func owned(db *gorm.DB) *gorm.DB {
+	if owner, ok := OwnerFrom(db.Statement.Context); ok {
+		return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "owner_id"}, Value: owner})
+	}
+	return db
+}
+
+// accessible is a GORM scope on todos keeping those the statement context's
+// owner may see: their own, those shared with them and those in projects
+// shared with them.
+func accessible(db *gorm.DB) *gorm.DB {
+	owner, ok := OwnerFrom(db.Statement.Context)
+	if !ok {
+		return db
+	}
+	return db.Where(clause.Expr{
+		SQL: "(? = ? OR ? IN (?) OR ? IN (?))",
+		Vars: []any{
+			clause.Column{Table: clause.CurrentTable, Name: "owner_id"}, owner,
+			clause.Column{Table: clause.CurrentTable, Name: "id"}, sharedWith(db, owner, "todo_id"),
+			clause.Column{Table: clause.CurrentTable, Name: "project_id"}, sharedWith(db, owner, "project_id"),
+		},
+	})
+}
+
+// accessibleProjects is the scope of accessible for projects.
+func accessibleProjects(db *gorm.DB) *gorm.DB {
+	owner, ok := OwnerFrom(db.Statement.Context)
+	if !ok {
+		return db
+	}
+	return db.Where(clause.Expr{
+		SQL: "(? = ? OR ? IN (?))",
+		Vars: []any{
+			clause.Column{Table: clause.CurrentTable, Name: "owner_id"}, owner,
+			clause.Column{Table: clause.CurrentTable, Name: "id"}, sharedWith(db, owner, "project_id"),
+		},
+	})
+}
+
+// sharedWith selects the given column of the shares of a user that are on
+// that kind of resource.
+func sharedWith(db *gorm.DB, userID uint, column string) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Model(&models.Share{}).
+		Select(column).Where("user_id = ?", userID).Where(clause.Neq{Column: column, Value: nil})
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+// MaxPageSize is the most todos a single page of any of the APIs lists.
+const MaxPageSize = 100
+
+// ListPage lists up to limit todos matching query in (created_at, id) order,
+// starting after position, or ending before it when backwards is set. A nil
+// position starts at the first todo, or ends at the last one. One extra row is
+// fetched to find out whether another page exists in the direction of travel,
+// which is reported as more. total counts every todo matching query, unless
+// query.SkipTotal is set.
+func ListPage(ctx context.Context, repo TodoRepository, query TodoQuery, position *TodoPosition, backwards bool, limit int) ([]models.Todo, bool, int64, error) {
+	query.Limit = limit + 1
+	query.Sort = []SortField{{Column: "created_at"}}
+	switch {
+	case backwards && position == nil:
+		query.Before = &TodoPosition{CreatedAt: time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC), ID: math.MaxInt64}
+	case backwards:
+		query.Before = position
+	default:
+		query.After = position
+	}
+
+	todos, total, err := repo.List(ctx, query)
+	if err != nil {
+		return nil, false, 0, err
+	}
+
+	more := len(todos) > limit
+	if more && backwards {
+		todos = todos[1:]
+	} else if more {
+		todos = todos[:limit]
+	}
+	return todos, more, total, nil
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/policy"
+)
+
+// PolicyTodoRepository wraps a TodoRepository and consults the policy
+// package before every change made with a context from WithOwner: editing a
+// todo needs the editor role on it, deleting, restoring, purging and sharing
+// it the owner role, and likewise for projects. Moving a todo into a project
+// needs the editor role on the project, and nesting it below another todo
+// policy.AuthorizeNesting. Denied changes fail with a *policy.DeniedError or
+// policy.ErrNestingShared.
+//
+// Reads of a single todo, its history or a project need the viewer role.
+// Lists are filtered by the repository below, which returns what RoleOf
+// grants any role on; ReadableTodos applies the same check to events.
+// Contexts without an owner, such as those of API keys and workers, are not
+// checked.
+type PolicyTodoRepository struct {
+	TodoRepository
+}
+
+func NewPolicyTodoRepository(inner TodoRepository) *PolicyTodoRepository {
+	return &PolicyTodoRepository{TodoRepository: inner}
+}
+
+func (r *PolicyTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	if user, ok := OwnerFrom(ctx); ok {
+		if err := r.authorizePlacement(ctx, user, todo.ProjectID, todo.ParentID, true); err != nil {
+			return err
+		}
+	}
+	return r.TodoRepository.Create(ctx, todo)
+}
+
+func (r *PolicyTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	if err := r.authorizeChange(ctx, todo.ID, todo.ProjectID, todo.ParentID, true); err != nil {
+		return err
+	}
+	return r.TodoRepository.Update(ctx, todo)
+}
+
+func (r *PolicyTodoRepository) Replace(ctx context.Context, todo *models.Todo) error {
+	if err := r.authorizeChange(ctx, todo.ID, todo.ProjectID, todo.ParentID, false); err != nil {
+		return err
+	}
+	return r.TodoRepository.Replace(ctx, todo)
+}
+
+func (r *PolicyTodoRepository) FindByID(ctx context.Context, id uint) (*models.Todo, error) {
+	if err := r.authorize(ctx, ShareTarget{TodoID: id}, policy.ActionRead); err != nil {
+		return nil, err
+	}
+	return r.TodoRepository.FindByID(ctx, id)
+}
+
+func (r *PolicyTodoRepository) ListRevisions(ctx context.Context, todoID uint) ([]models.TodoRevision, error) {
+	if err := r.authorize(ctx, ShareTarget{TodoID: todoID}, policy.ActionRead); err != nil {
+		return nil, err
+	}
+	return r.TodoRepository.ListRevisions(ctx, todoID)
+}
+
+func (r *PolicyTodoRepository) FindRevision(ctx context.Context, todoID, revision uint) (*models.TodoRevision, error) {
+	if err := r.authorize(ctx, ShareTarget{TodoID: todoID}, policy.ActionRead); err != nil {
+		return nil, err
+	}
+	return r.TodoRepository.FindRevision(ctx, todoID, revision)
+}
+
+func (r *PolicyTodoRepository) FindProject(ctx context.Context, id uint) (*models.Project, error) {
+	if err := r.authorize(ctx, ShareTarget{ProjectID: id}, policy.ActionRead); err != nil {
+		return nil, err
+	}
+	return r.TodoRepository.FindProject(ctx, id)
+}
+
+func (r *PolicyTodoRepository) Delete(ctx context.Context, id, version uint) error {
+	if err := r.authorize(ctx, ShareTarget{TodoID: id}, policy.ActionDelete); err != nil {
+		return err
+	}
+	return r.TodoRepository.Delete(ctx, id, version)
+}
+
+func (r *PolicyTodoRepository) Restore(ctx context.Context, id uint) error {
+	if err := r.authorize(ctx, ShareTarget{TodoID: id}, policy.ActionDelete); err != nil {
+		return err
+	}
+	return r.TodoRepository.Restore(ctx, id)
+}
+
+func (r *PolicyTodoRepository) Purge(ctx context.Context, id uint) error {
+	if err := r.authorize(ctx, ShareTarget{TodoID: id}, policy.ActionDelete); err != nil {
+		return err
+	}
+	return r.TodoRepository.Purge(ctx, id)
+}
+
+func (r *PolicyTodoRepository) AddDependency(ctx context.Context, todoID, blockerID uint) error {
+	if err := r.authorize(ctx, ShareTarget{TodoID: todoID}, policy.ActionUpdate); err != nil {
+		return err
+	}
+	return r.TodoRepository.AddDependency(ctx, todoID, blockerID)
+}
+
+func (r *PolicyTodoRepository) RemoveDependency(ctx context.Context, todoID, blockerID uint) error {
+	err := r.authorize(ctx, ShareTarget{TodoID: todoID}, policy.ActionUpdate)
+	if errors.Is(err, ErrTodoNotFound) {
+		return ErrDependencyNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return r.TodoRepository.RemoveDependency(ctx, todoID, blockerID)
+}
+
+func (r *PolicyTodoRepository) UpdateProject(ctx context.Context, project *models.Project) error {
+	if err := r.authorize(ctx, ShareTarget{ProjectID: project.ID}, policy.ActionUpdate); err != nil {
+		return err
+	}
+	return r.TodoRepository.UpdateProject(ctx, project)
+}
+
+func (r *PolicyTodoRepository) DeleteProject(ctx context.Context, id uint) error {
+	if err := r.authorize(ctx, ShareTarget{ProjectID: id}, policy.ActionDelete); err != nil {
+		return err
+	}
+	return r.TodoRepository.DeleteProject(ctx, id)
+}
+
+func (r *PolicyTodoRepository) SaveShare(ctx context.Context, share *models.Share) error {
+	if err := r.authorize(ctx, targetOf(share), policy.ActionShare); err != nil {
+		return err
+	}
+	return r.TodoRepository.SaveShare(ctx, share)
+}
+
+func (r *PolicyTodoRepository) ListShares(ctx context.Context, target ShareTarget) ([]models.Share, error) {
+	if err := r.authorize(ctx, target, policy.ActionRead); err != nil {
+		return nil, err
+	}
+	return r.TodoRepository.ListShares(ctx, target)
+}
+
+// DeleteShare also lets users give up a share they were given.
+func (r *PolicyTodoRepository) DeleteShare(ctx context.Context, target ShareTarget, userID uint) error {
+	action := policy.ActionShare
+	if user, ok := OwnerFrom(ctx); ok && user == userID {
+		action = policy.ActionRead
+	}
+	if err := r.authorize(ctx, target, action); err != nil {
+		return err
+	}
+	return r.TodoRepository.DeleteShare(ctx, target, userID)
+}
+
+func (r *PolicyTodoRepository) Transaction(ctx context.Context, fn func(repo TodoRepository) error) error {
+	return r.TodoRepository.Transaction(ctx, func(tx TodoRepository) error {
+		return fn(NewPolicyTodoRepository(tx))
+	})
+}
+
+// ReadableTodos returns an events.Filter Readable that lets ctx's user follow
+// the todos the policy lets them read: their own and those shared with them
+// directly or through a project. It returns nil for contexts without an
+// owner, which may follow every todo.
+func ReadableTodos(ctx context.Context, repo TodoRepository) func(todo models.Todo) bool {
+	user, ok := OwnerFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return func(todo models.Todo) bool {
+		// The user's own todos need no lookup, which also keeps the events
+		// of purged ones.
+		if todo.OwnerID == user {
+			return true
+		}
+		resource, err := repo.Access(ctx, ShareTarget{TodoID: todo.ID})
+		if err != nil {
+			return false
+		}
+		return policy.Authorize(policy.RoleOf(user, resource), policy.ActionRead) == nil
+	}
+}
+
+// authorize checks that ctx's user may perform action on the target. Targets
+// they cannot see are reported as missing.
+func (r *PolicyTodoRepository) authorize(ctx context.Context, target ShareTarget, action policy.Action) error {
+	user, ok := OwnerFrom(ctx)
+	if !ok {
+		return nil
+	}
+	resource, err := r.TodoRepository.Access(ctx, target)
+	if err != nil {
+		return err
+	}
+	return visibleOr(policy.Authorize(policy.RoleOf(user, resource), action), target.notFound())
+}
+
+// authorizeChange checks an edit of the todo with the given id that puts it
+// in projectID and below parentID. With partial set, nil ids leave the
+// todo's project or parent as it is.
+func (r *PolicyTodoRepository) authorizeChange(ctx context.Context, id uint, projectID, parentID *uint, partial bool) error {
+	user, ok := OwnerFrom(ctx)
+	if !ok {
+		return nil
+	}
+	if err := r.authorize(ctx, ShareTarget{TodoID: id}, policy.ActionUpdate); err != nil {
+		return err
+	}
+	current, err := r.TodoRepository.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if partial {
+		if projectID == nil {
+			projectID = current.ProjectID
+		}
+		if parentID == nil {
+			parentID = current.ParentID
+		}
+	}
+	movedProject := !sameID(projectID, current.ProjectID)
+	if !movedProject && sameID(parentID, current.ParentID) {
+		return nil
+	}
+	return r.authorizePlacement(ctx, user, projectID, parentID, movedProject)
+}
+
+// authorizePlacement checks that user may put a todo in projectID, which
+// needs the editor role on the project when checkProject is set, and below
+// parentID. Either may be nil.
+func (r *PolicyTodoRepository) authorizePlacement(ctx context.Context, user uint, projectID, parentID *uint, checkProject bool) error {
+	var project *policy.Resource
+	if projectID != nil {
+		resource, err := r.TodoRepository.Access(ctx, ShareTarget{ProjectID: *projectID})
+		switch {
+		case errors.Is(err, ErrProjectNotFound) && !checkProject:
+			// The todo stays in a project the user cannot see, which for
+			// nesting is as good as no project.
+		case err != nil:
+			return err
+		case checkProject:
+			if err := visibleOr(policy.Authorize(policy.RoleOf(user, resource), policy.ActionUpdate), ErrProjectNotFound); err != nil {
+				return err
+			}
+			fallthrough
+		default:
+			project = &resource
+		}
+	}
+	if parentID == nil {
+		return nil
+	}
+	parent, err := r.TodoRepository.Access(ctx, ShareTarget{TodoID: *parentID})
+	if errors.Is(err, ErrTodoNotFound) {
+		return ErrParentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return visibleOr(policy.AuthorizeNesting(user, parent, project), ErrParentNotFound)
+}
+
+// visibleOr replaces policy.ErrNotVisible by notFound.
+func visibleOr(err, notFound error) error {
+	if errors.Is(err, policy.ErrNotVisible) {
+		return notFound
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+// RecurringTodoRepository wraps a TodoRepository, validating the recurrence
+// rules of todos written through it. Completing a recurring todo creates its
+// next occurrence in the same transaction; both then share a SeriesID, which
+// only this wrapper assigns.
+type RecurringTodoRepository struct {
+	TodoRepository
+}
+
+func NewRecurringTodoRepository(inner TodoRepository) *RecurringTodoRepository {
+	return &RecurringTodoRepository{TodoRepository: inner}
+}
+
+func (r *RecurringTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	todo.SeriesID = nil
+	if err := todo.NormalizeRecurrence(); err != nil {
+		return err
+	}
+	return r.TodoRepository.Create(ctx, todo)
+}
+
+func (r *RecurringTodoRepository) Update(ctx context.Context, todo *models.Todo) error {
+	todo.SeriesID = nil
+	return r.write(ctx, todo.ID, func(tx TodoRepository, before *models.Todo) error {
+		merged := *before
+		if todo.Recurrence != "" {
+			merged.Recurrence = todo.Recurrence
+		}
+		if todo.Timezone != "" {
+			merged.Timezone = todo.Timezone
+		}
+		if todo.DueDate != nil {
+			merged.DueDate = todo.DueDate
+		}
+		if err := merged.NormalizeRecurrence(); err != nil {
+			return err
+		}
+		if todo.Recurrence != "" {
+			todo.Recurrence = merged.Recurrence
+		}
+		return tx.Update(ctx, todo)
+	})
+}
+
+func (r *RecurringTodoRepository) Replace(ctx context.Context, todo *models.Todo) error {
+	return r.write(ctx, todo.ID, func(tx TodoRepository, before *models.Todo) error {
+		todo.SeriesID = before.SeriesID
+		if err := todo.NormalizeRecurrence(); err != nil {
+			return err
+		}
+		return tx.Replace(ctx, todo)
+	})
+}
+
+func (r *RecurringTodoRepository) Transaction(ctx context.Context, fn func(repo TodoRepository) error) error {
+	return r.TodoRepository.Transaction(ctx, func(tx TodoRepository) error {
+		return fn(NewRecurringTodoRepository(tx))
+	})
+}
+
+// write runs an update of the todo with the given id and schedules the next
+// occurrence when the update completed a recurring todo.
+func (r *RecurringTodoRepository) write(ctx context.Context, id uint, fn func(tx TodoRepository, before *models.Todo) error) error {
+	return r.TodoRepository.Transaction(ctx, func(tx TodoRepository) error {
+		before, err := tx.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx, before); err != nil {
+			return err
+		}
+		after, err := tx.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if before.Complete || !after.Complete {
+			return nil
+		}
+		return scheduleNext(ctx, tx, after)
+	})
+}
+
+// scheduleNext creates the occurrence following a completed todo, if its rule
+// has one, and puts both in the same series.
+func scheduleNext(ctx context.Context, tx TodoRepository, done *models.Todo) error {
+	next, err := done.NextOccurrence()
+	if err != nil || next == nil {
+		return err
+	}
+	if done.SeriesID == nil {
+		series := done.ID
+		if err := tx.Update(ctx, &models.Todo{ID: done.ID, SeriesID: &series}); err != nil {
+			return err
+		}
+		done.SeriesID = &series
+	}
+	next.SeriesID = done.SeriesID
+	if _, ok := OwnerFrom(ctx); ok {
+		// The series stays with its owner, also when a user it is shared
+		// with completed the todo.
+		ctx = WithOwner(ctx, done.OwnerID)
+	}
+	return tx.Create(ctx, next)
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReminderStore persists reminders and their delivery state.
+type ReminderStore interface {
+	// Schedule stores a pending reminder unless the todo already has one of
+	// the same kind for the same time, and reports whether it was added.
+	Schedule(ctx context.Context, reminder *models.Reminder) (bool, error)
+	// Due returns up to limit pending reminders whose next attempt is not
+	// after now, oldest first.
+	Due(ctx context.Context, now time.Time, limit int) ([]models.Reminder, error)
+	// Save stores the delivery state of a reminder.
+	Save(ctx context.Context, reminder *models.Reminder) error
+}
+
+type GormReminderStore struct {
+	db *gorm.DB
+}
+
+func NewGormReminderStore(db *gorm.DB) *GormReminderStore {
+	return &GormReminderStore{db: db}
+}
+
+func ProvideReminderStore(db *gorm.DB) ReminderStore {
+	return NewGormReminderStore(db)
+}
+
+func (s *GormReminderStore) Schedule(ctx context.Context, reminder *models.Reminder) (bool, error) {
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (s *GormReminderStore) Due(ctx context.Context, now time.Time, limit int) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.ReminderPending, now).
+		Order("next_attempt_at").Order("id").
+		Limit(limit).
+		Find(&reminders).Error
+	return reminders, err
+}
+
+func (s *GormReminderStore) Save(ctx context.Context, reminder *models.Reminder) error {
+	return s.db.WithContext(ctx).Model(reminder).
+		Select("status", "attempts", "last_error", "next_attempt_at", "delivered_at").
+		Updates(reminder).Error
+}
+
+// MemoryReminderStore keeps reminders in a slice. It is meant for tests and
+// single-process setups that also use MemoryTodoRepository.
+type MemoryReminderStore struct {
+	mu        sync.Mutex
+	reminders []models.Reminder
+}
+
+func NewMemoryReminderStore() *MemoryReminderStore {
+	return &MemoryReminderStore{}
+}
+
+func (s *MemoryReminderStore) Schedule(ctx context.Context, reminder *models.Reminder) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.reminders {
+		if existing.TodoID == reminder.TodoID && existing.Kind == reminder.Kind && existing.At.Equal(reminder.At) {
+			return false, nil
+		}
+	}
+	now := time.Now().Round(0)
+	reminder.ID = uint(len(s.reminders) + 1)
+	reminder.CreatedAt, reminder.UpdatedAt = now, now
+	s.reminders = append(s.reminders, *reminder)
+	return true, nil
+}
+
+func (s *MemoryReminderStore) Due(ctx context.Context, now time.Time, limit int) ([]models.Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []models.Reminder
+	for _, reminder := range s.reminders {
+		if reminder.Status == models.ReminderPending && !reminder.NextAttemptAt.After(now) {
+			due = append(due, reminder)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b models.Reminder) int { return a.NextAttemptAt.Compare(b.NextAttemptAt) })
+	return due[:min(limit, len(due))], nil
+}
+
+func (s *MemoryReminderStore) Save(ctx context.Context, reminder *models.Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.reminders {
+		if s.reminders[i].ID == reminder.ID {
+			reminder.UpdatedAt = time.Now().Round(0)
+			s.reminders[i] = *reminder
+		}
+	}
+	return nil
+}
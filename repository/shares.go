@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/policy"
+)
+
+var (
+	ErrShareNotFound  = errors.New("share not found")
+	ErrShareWithOwner = errors.New("it already belongs to that user")
+)
+
+// ShareTarget names the todo or project shares are on. Exactly one of the
+// ids is set.
+type ShareTarget struct {
+	TodoID    uint
+	ProjectID uint
+}
+
+// targetOf returns the todo or project share is on.
+func targetOf(share *models.Share) ShareTarget {
+	var target ShareTarget
+	if share.TodoID != nil {
+		target.TodoID = *share.TodoID
+	} else if share.ProjectID != nil {
+		target.ProjectID = *share.ProjectID
+	}
+	return target
+}
+
+// notFound is the error reported when the target is missing or hidden.
+func (t ShareTarget) notFound() error {
+	if t.TodoID != 0 {
+		return ErrTodoNotFound
+	}
+	return ErrProjectNotFound
+}
+
+// apply points share at the target.
+func (t ShareTarget) apply(share *models.Share) {
+	share.TodoID, share.ProjectID = nil, nil
+	if t.TodoID != 0 {
+		id := t.TodoID
+		share.TodoID = &id
+	} else {
+		id := t.ProjectID
+		share.ProjectID = &id
+	}
+}
+
+// matches reports whether share is on the target.
+func (t ShareTarget) matches(share models.Share) bool {
+	if t.TodoID != 0 {
+		return share.TodoID != nil && *share.TodoID == t.TodoID
+	}
+	return share.ProjectID != nil && *share.ProjectID == t.ProjectID
+}
+
+// grants converts shares to the grants the policy package decides on.
+func grants(shares []models.Share) []policy.Grant {
+	grants := make([]policy.Grant, len(shares))
+	for i, share := range shares {
+		grants[i] = policy.Grant{UserID: share.UserID, Role: policy.Role(share.Role)}
+	}
+	return grants
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Xillon/golang-todo-api/models"
+)
+
+// ChildPolicy decides what deleting a todo does to its subtasks.
+type ChildPolicy string
+
+const (
+	// ChildrenBlock refuses to delete a todo that still has live subtasks.
+	ChildrenBlock ChildPolicy = "block"
+	// ChildrenReparent moves the subtasks up to the deleted todo's parent.
+	ChildrenReparent ChildPolicy = "reparent"
+	// ChildrenCascade trashes the subtasks, and theirs, along with the todo.
+	ChildrenCascade ChildPolicy = "cascade"
+)
+
+// ListChildren returns the live direct subtasks of a live todo.
+func ListChildren(ctx context.Context, repo TodoRepository, id uint) ([]models.Todo, error) {
+	if _, err := repo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return children(ctx, repo, id)
+}
+
+func children(ctx context.Context, repo TodoRepository, id uint) ([]models.Todo, error) {
+	todos, _, err := repo.List(ctx, TodoQuery{ParentID: &id, Limit: -1})
+	return todos, err
+}
+
+// DeleteTodo moves a todo to the trash, handling its subtasks as the policy
+// says. A non-zero version must match the todo's current version.
+func DeleteTodo(ctx context.Context, repo TodoRepository, id, version uint, policy ChildPolicy) error {
+	return repo.Transaction(ctx, func(tx TodoRepository) error {
+		todo, err := tx.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		subtasks, err := children(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		for _, child := range subtasks {
+			switch policy {
+			case ChildrenReparent:
+				child.ParentID = todo.ParentID
+				err = tx.Replace(ctx, &child)
+			case ChildrenCascade:
+				err = DeleteTodo(ctx, tx, child.ID, 0, ChildrenCascade)
+			default:
+				err = ErrHasSubtasks
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Delete(ctx, id, version)
+	})
+}
+
+// TodoTree returns a live todo with all of its live subtasks nested below it
+// and the completion progress of every node.
+func TodoTree(ctx context.Context, repo TodoRepository, id uint) (*models.TodoNode, error) {
+	todo, err := repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	node, err := buildTree(ctx, repo, *todo)
+	if err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+func buildTree(ctx context.Context, repo TodoRepository, todo models.Todo) (models.TodoNode, error) {
+	node := models.TodoNode{Todo: todo, Children: []models.TodoNode{}}
+	subtasks, err := children(ctx, repo, todo.ID)
+	if err != nil {
+		return node, err
+	}
+
+	for _, child := range subtasks {
+		childNode, err := buildTree(ctx, repo, child)
+		if err != nil {
+			return node, err
+		}
+		node.Children = append(node.Children, childNode)
+		node.Progress.Total += childNode.Progress.Total + 1
+		node.Progress.Complete += childNode.Progress.Complete
+		if child.Complete {
+			node.Progress.Complete++
+		}
+	}
+
+	switch {
+	case node.Progress.Total > 0:
+		node.Progress.Percent = node.Progress.Complete * 100 / node.Progress.Total
+	case todo.Complete:
+		node.Progress.Percent = 100
+	}
+	return node, nil
+}
+
+// CompleteSubtasks marks every live descendant of a todo as complete.
+func CompleteSubtasks(ctx context.Context, repo TodoRepository, id uint) error {
+	return repo.Transaction(ctx, func(tx TodoRepository) error {
+		subtasks, err := children(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		for _, child := range subtasks {
+			if !child.Complete {
+				if err := tx.Update(ctx, &models.Todo{ID: child.ID, Complete: true}); err != nil {
+					return err
+				}
+			}
+			if err := CompleteSubtasks(ctx, tx, child.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
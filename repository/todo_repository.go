@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/policy"
+)
+
+var (
+	ErrTodoNotFound       = errors.New("todo not found")
+	ErrDuplicateTitle     = errors.New("a todo with this title already exists in the project")
+	ErrRevisionNotFound   = errors.New("revision not found")
+	ErrVersionConflict    = errors.New("todo was modified by another request")
+	ErrTagNotFound        = errors.New("tag not found")
+	ErrDuplicateTag       = errors.New("a tag with this name already exists")
+	ErrProjectNotFound    = errors.New("project not found")
+	ErrDuplicateProject   = errors.New("a project with this name already exists")
+	ErrProjectNotEmpty    = errors.New("project still has todos, including trashed ones")
+	ErrParentNotFound     = errors.New("parent todo not found")
+	ErrParentCycle        = errors.New("a todo cannot be nested below itself or one of its subtasks")
+	ErrHasSubtasks        = errors.New("todo has subtasks; choose whether to reparent or cascade to them")
+	ErrBlockerNotFound    = errors.New("blocking todo not found")
+	ErrDependencyNotFound = errors.New("todo is not blocked by that todo")
+	ErrDependencyCycle    = errors.New("a todo cannot be blocked by itself or by a todo that waits for it")
+	ErrTodoBlocked        = errors.New("todo is blocked by todos that are still open")
+)
+
+// SortableTodoColumns whitelists the todos columns List can order by.
+var SortableTodoColumns = []string{"id", "title", "description", "due_date", "remind_at", "complete", "created_at", "updated_at"}
+
+// SortField orders List results by a single column from SortableTodoColumns.
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// TodoPosition identifies a row in the stable (created_at, id) ordering used
+// for keyset pagination.
+type TodoPosition struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// TodoQuery describes which slice of todos List should return. Nil and zero
+// filters are ignored; time ranges are exclusive on both ends. Results are
+// ordered by Sort and then by id so pages are deterministic.
+type TodoQuery struct {
+	Complete  *bool
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	// RemindBefore keeps todos with a reminder set before the given time.
+	RemindBefore  *time.Time
+	CreatedBefore *time.Time
+	CreatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	// Search matches todos whose title or description contains the text.
+	Search      string
+	Title       string
+	Description string
+	// Tags keeps todos carrying any of the named tags, or all of them when
+	// AllTags is set.
+	Tags      []string
+	AllTags   bool
+	ProjectID *uint
+	// ParentID keeps the direct subtasks of a todo.
+	ParentID *uint
+	Sort     []SortField
+	// After and Before switch List to keyset pagination: only rows strictly
+	// after or before the position in (created_at, id) order are returned,
+	// still in ascending order, and Sort is ignored.
+	After  *TodoPosition
+	Before *TodoPosition
+	// Trashed lists soft-deleted todos instead of live ones.
+	Trashed bool
+	// SkipTotal spares List counting the matching todos; the total it
+	// returns is then zero.
+	SkipTotal bool
+	Limit     int
+	Offset    int
+}
+
+// IsSortableTodoColumn reports whether column appears in SortableTodoColumns.
+func IsSortableTodoColumn(column string) bool {
+	return slices.Contains(SortableTodoColumns, column)
+}
+
+// TodoRepository is the storage boundary used by the HTTP handlers. Any
+// implementation must report missing rows as ErrTodoNotFound and title
+// collisions as ErrDuplicateTitle so callers can map them consistently. Titles
+// are unique per project (todos without a project form one more scope), and
+// writes naming a project that does not exist fail with ErrProjectNotFound.
+// A parent has to be a live todo (ErrParentNotFound) that is not the todo
+// itself or one of its subtasks (ErrParentCycle).
+//
+// A todo cannot be completed while one of its live blockers is still open
+// (ErrTodoBlocked) unless the context comes from AllowBlockedCompletion.
+//
+// Every write bumps the todo's Version. Update, Replace and Delete take the
+// version the caller last saw (todo.Version or the version argument) and fail
+// with ErrVersionConflict when it no longer matches; zero skips the check.
+//
+// Deletes are soft: trashed todos are hidden from every method except Restore,
+// Purge, PurgeTrash and List with Trashed set. Their titles stay reserved.
+//
+// Todos are returned with their tags. On Create, Update and Replace the tags
+// of the todo are looked up by name (or by id when no name is given) and
+// created on the fly when no tag of that name exists yet.
+//
+// Todos, tags and projects belong to an owner. A context from WithOwner only
+// sees the rows of that user, the todos and projects shared with them and the
+// todos of those projects; other rows are reported as missing. New todos
+// belong to the owner of their project or parent, or else to the context's
+// user, as do new tags and projects. Projects, parents, blockers and tags of
+// a todo always belong to the todo's owner. The repository does not check
+// roles; callers consult the policy package with what Access returns.
+type TodoRepository interface {
+	Create(ctx context.Context, todo *models.Todo) error
+	// Update applies the non-zero fields of todo to the row identified by todo.ID.
+	// It returns ErrTodoNotFound when no such row exists.
+	Update(ctx context.Context, todo *models.Todo) error
+	// Replace overwrites every user-editable field of the row identified by
+	// todo.ID, including zero values.
+	Replace(ctx context.Context, todo *models.Todo) error
+	FindByID(ctx context.Context, id uint) (*models.Todo, error)
+	List(ctx context.Context, query TodoQuery) ([]models.Todo, int64, error)
+	// Delete moves a todo to the trash. It returns ErrTodoNotFound when no
+	// live todo has that id.
+	Delete(ctx context.Context, id, version uint) error
+	// Restore takes a todo out of the trash, or returns ErrTodoNotFound.
+	Restore(ctx context.Context, id uint) error
+	// Purge permanently removes a trashed todo, or returns ErrTodoNotFound.
+	Purge(ctx context.Context, id uint) error
+	// PurgeTrash permanently removes todos trashed before the given time and
+	// reports how many were removed. A context from WithOwner only purges the
+	// user's own todos, not those shared with them.
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+	// AddRevision stores a revision, numbering it after the todo's latest one.
+	AddRevision(ctx context.Context, revision *models.TodoRevision) error
+	// ListRevisions returns a todo's revisions, oldest first. It also works
+	// for trashed todos, and for purged todos unless ctx has an owner.
+	ListRevisions(ctx context.Context, todoID uint) ([]models.TodoRevision, error)
+	FindRevision(ctx context.Context, todoID, revision uint) (*models.TodoRevision, error)
+	// CreateTag stores a new tag, or returns ErrDuplicateTag.
+	CreateTag(ctx context.Context, tag *models.Tag) error
+	// UpdateTag overwrites the name and colour of a tag.
+	UpdateTag(ctx context.Context, tag *models.Tag) error
+	FindTag(ctx context.Context, id uint) (*models.Tag, error)
+	// ListTags returns every tag ordered by name.
+	ListTags(ctx context.Context) ([]models.Tag, error)
+	// DeleteTag removes a tag from every todo carrying it and then deletes it.
+	DeleteTag(ctx context.Context, id uint) error
+	// CreateProject stores a new project, or returns ErrDuplicateProject.
+	CreateProject(ctx context.Context, project *models.Project) error
+	// UpdateProject overwrites the name and description of a project.
+	UpdateProject(ctx context.Context, project *models.Project) error
+	FindProject(ctx context.Context, id uint) (*models.Project, error)
+	// ListProjects returns every project ordered by name.
+	ListProjects(ctx context.Context) ([]models.Project, error)
+	// DeleteProject removes a project that no todo, trashed or not, belongs
+	// to. Otherwise it returns ErrProjectNotEmpty.
+	DeleteProject(ctx context.Context, id uint) error
+	// AddDependency makes a live todo wait for another live todo. It fails
+	// with ErrBlockerNotFound when the blocker is missing and with
+	// ErrDependencyCycle when the blocker already waits for the todo, directly
+	// or not. Adding an existing dependency does nothing.
+	AddDependency(ctx context.Context, todoID, blockerID uint) error
+	// RemoveDependency stops a todo from waiting for the blocker, or returns
+	// ErrDependencyNotFound.
+	RemoveDependency(ctx context.Context, todoID, blockerID uint) error
+	// SaveShare gives share.UserID share.Role on the live todo or project the
+	// share names, replacing any role they had on it. It fails with
+	// ErrShareWithOwner when the user owns the todo or project.
+	SaveShare(ctx context.Context, share *models.Share) error
+	// ListShares returns the shares on a todo or project, oldest first.
+	ListShares(ctx context.Context, target ShareTarget) ([]models.Share, error)
+	// DeleteShare removes a user's share on a todo or project, or returns
+	// ErrShareNotFound.
+	DeleteShare(ctx context.Context, target ShareTarget, userID uint) error
+	// Access describes the owner and shares of a todo, trashed ones included,
+	// or of a project. It returns ErrTodoNotFound or ErrProjectNotFound when
+	// ctx may not see it.
+	Access(ctx context.Context, target ShareTarget) (policy.Resource, error)
+	// Transaction runs fn against a repository bound to a single transaction.
+	// Everything fn did is rolled back when it returns an error.
+	Transaction(ctx context.Context, fn func(repo TodoRepository) error) error
+}
+
+// sortTags puts tags in the name order todos are returned with.
+func sortTags(tags []models.Tag) {
+	slices.SortFunc(tags, func(a, b models.Tag) int { return strings.Compare(a.Name, b.Name) })
+}
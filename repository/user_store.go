@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound  = errors.New("user not found")
+	ErrDuplicateUser = errors.New("a user with this name already exists")
+	ErrTokenNotFound = errors.New("token is invalid or has expired")
+)
+
+// UserStore persists user accounts and their login tokens. Tokens are looked
+// up by the hash of the value handed to the user.
+type UserStore interface {
+	// CreateUser stores a new user, or returns ErrDuplicateUser.
+	CreateUser(ctx context.Context, user *models.User) error
+	FindUser(ctx context.Context, id uint) (*models.User, error)
+	// FindUserByName returns the user with the given name, or ErrUserNotFound.
+	FindUserByName(ctx context.Context, username string) (*models.User, error)
+	CreateToken(ctx context.Context, token *models.AuthToken) error
+	// FindToken returns the token with the given hash together with its user,
+	// or ErrTokenNotFound when there is none or it expired before now.
+	FindToken(ctx context.Context, hash string, now time.Time) (*models.AuthToken, error)
+	// DeleteToken revokes a token. Revoking an unknown token does nothing.
+	DeleteToken(ctx context.Context, hash string) error
+	// PurgeExpiredTokens removes tokens that expired before the given time.
+	PurgeExpiredTokens(ctx context.Context, before time.Time) (int64, error)
+}
+
+type GormUserStore struct {
+	db *gorm.DB
+}
+
+func NewGormUserStore(db *gorm.DB) *GormUserStore {
+	return &GormUserStore{db: db}
+}
+
+func ProvideUserStore(db *gorm.DB) UserStore {
+	return NewGormUserStore(db)
+}
+
+func (s *GormUserStore) CreateUser(ctx context.Context, user *models.User) error {
+	err := s.db.WithContext(ctx).Create(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicateUser
+	}
+	return err
+}
+
+func (s *GormUserStore) FindUser(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, translateUserError(err)
+	}
+	return &user, nil
+}
+
+func (s *GormUserStore) FindUserByName(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, translateUserError(err)
+	}
+	return &user, nil
+}
+
+func (s *GormUserStore) CreateToken(ctx context.Context, token *models.AuthToken) error {
+	return s.db.WithContext(ctx).Omit("User").Create(token).Error
+}
+
+func (s *GormUserStore) FindToken(ctx context.Context, hash string, now time.Time) (*models.AuthToken, error) {
+	var token models.AuthToken
+	err := s.db.WithContext(ctx).Joins("User").Where("hash = ? AND expires_at > ?", hash, now).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *GormUserStore) DeleteToken(ctx context.Context, hash string) error {
+	return s.db.WithContext(ctx).Where("hash = ?", hash).Delete(&models.AuthToken{}).Error
+}
+
+func (s *GormUserStore) PurgeExpiredTokens(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at <= ?", before).Delete(&models.AuthToken{})
+	return result.RowsAffected, result.Error
+}
+
+func translateUserError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// MemoryUserStore keeps users and tokens in slices. It is meant for tests and
+// single-process setups that also use MemoryTodoRepository.
+type MemoryUserStore struct {
+	mu          sync.Mutex
+	users       []models.User
+	tokens      []models.AuthToken
+	nextUserID  uint
+	nextTokenID uint
+}
+
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{}
+}
+
+func (s *MemoryUserStore) CreateUser(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.users, func(u models.User) bool { return u.Username == user.Username }) {
+		return ErrDuplicateUser
+	}
+	now := time.Now().Round(0)
+	s.nextUserID++
+	user.ID = s.nextUserID
+	user.CreatedAt, user.UpdatedAt = now, now
+	s.users = append(s.users, *user)
+	return nil
+}
+
+func (s *MemoryUserStore) FindUser(ctx context.Context, id uint) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.findUser(func(u models.User) bool { return u.ID == id })
+}
+
+func (s *MemoryUserStore) FindUserByName(ctx context.Context, username string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.findUser(func(u models.User) bool { return u.Username == username })
+}
+
+func (s *MemoryUserStore) findUser(match func(models.User) bool) (*models.User, error) {
+	i := slices.IndexFunc(s.users, match)
+	if i < 0 {
+		return nil, ErrUserNotFound
+	}
+	user := s.users[i]
+	return &user, nil
+}
+
+func (s *MemoryUserStore) CreateToken(ctx context.Context, token *models.AuthToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextTokenID++
+	token.ID = s.nextTokenID
+	token.CreatedAt = time.Now().Round(0)
+	stored := *token
+	stored.User = models.User{}
+	s.tokens = append(s.tokens, stored)
+	return nil
+}
+
+func (s *MemoryUserStore) FindToken(ctx context.Context, hash string, now time.Time) (*models.AuthToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.tokens, func(t models.AuthToken) bool { return t.Hash == hash && t.ExpiresAt.After(now) })
+	if i < 0 {
+		return nil, ErrTokenNotFound
+	}
+	token := s.tokens[i]
+	user, err := s.findUser(func(u models.User) bool { return u.ID == token.UserID })
+	if err != nil {
+		return nil, ErrTokenNotFound
+	}
+	token.User = *user
+	return &token, nil
+}
+
+func (s *MemoryUserStore) DeleteToken(ctx context.Context, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = slices.DeleteFunc(s.tokens, func(t models.AuthToken) bool { return t.Hash == hash })
+	return nil
+}
+
+func (s *MemoryUserStore) PurgeExpiredTokens(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := len(s.tokens)
+	s.tokens = slices.DeleteFunc(s.tokens, func(t models.AuthToken) bool { return !t.ExpiresAt.After(before) })
+	return int64(count - len(s.tokens)), nil
+}
@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookStore persists webhook subscriptions and their deliveries.
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	// UpdateWebhook overwrites the URL, secret, events and active flag of a
+	// webhook, or returns ErrWebhookNotFound.
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+	FindWebhook(ctx context.Context, id uint) (*models.Webhook, error)
+	// ListWebhooks returns every webhook ordered by id.
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	// DeleteWebhook removes a webhook together with its deliveries.
+	DeleteWebhook(ctx context.Context, id uint) error
+	AddDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// FindDelivery returns a delivery of the given webhook, or
+	// ErrDeliveryNotFound.
+	FindDelivery(ctx context.Context, webhookID, id uint) (*models.WebhookDelivery, error)
+	// ListDeliveries returns up to limit deliveries of a webhook, newest first.
+	ListDeliveries(ctx context.Context, webhookID uint, limit int) ([]models.WebhookDelivery, error)
+	// DueDeliveries returns up to limit pending deliveries whose next attempt
+	// is not after now, oldest first.
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+	// SaveDelivery stores the delivery state of a delivery.
+	SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+}
+
+type GormWebhookStore struct {
+	db *gorm.DB
+}
+
+func NewGormWebhookStore(db *gorm.DB) *GormWebhookStore {
+	return &GormWebhookStore{db: db}
+}
+
+func ProvideWebhookStore(db *gorm.DB) WebhookStore {
+	return NewGormWebhookStore(db)
+}
+
+func (s *GormWebhookStore) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return s.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (s *GormWebhookStore) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	result := s.db.WithContext(ctx).Model(webhook).
+		Select("url", "secret", "events", "active", "updated_at").
+		Updates(webhook)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *GormWebhookStore) FindWebhook(ctx context.Context, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := s.db.WithContext(ctx).First(&webhook, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (s *GormWebhookStore) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := s.db.WithContext(ctx).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (s *GormWebhookStore) DeleteWebhook(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Webhook{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrWebhookNotFound
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+func (s *GormWebhookStore) AddDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return s.db.WithContext(ctx).Create(delivery).Error
+}
+
+func (s *GormWebhookStore) FindDelivery(ctx context.Context, webhookID, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := s.db.WithContext(ctx).Where("webhook_id = ?", webhookID).First(&delivery, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (s *GormWebhookStore) ListDeliveries(ctx context.Context, webhookID uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := s.db.WithContext(ctx).
+		Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (s *GormWebhookStore) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at").Order("id").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (s *GormWebhookStore) SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return s.db.WithContext(ctx).Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "response_status", "last_error", "delivered_at").
+		Updates(delivery).Error
+}
+
+// MemoryWebhookStore keeps webhooks and deliveries in slices. It is meant for
+// tests and single-process setups that also use MemoryTodoRepository.
+type MemoryWebhookStore struct {
+	mu         sync.Mutex
+	webhooks   []models.Webhook
+	deliveries []models.WebhookDelivery
+	nextID     uint
+}
+
+func NewMemoryWebhookStore() *MemoryWebhookStore {
+	return &MemoryWebhookStore{}
+}
+
+func (s *MemoryWebhookStore) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Round(0)
+	s.nextID++
+	webhook.ID = s.nextID
+	webhook.CreatedAt, webhook.UpdatedAt = now, now
+	s.webhooks = append(s.webhooks, cloneWebhook(*webhook))
+	return nil
+}
+
+func (s *MemoryWebhookStore) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.webhooks, func(w models.Webhook) bool { return w.ID == webhook.ID })
+	if i < 0 {
+		return ErrWebhookNotFound
+	}
+	webhook.CreatedAt = s.webhooks[i].CreatedAt
+	webhook.UpdatedAt = time.Now().Round(0)
+	s.webhooks[i] = cloneWebhook(*webhook)
+	return nil
+}
+
+func (s *MemoryWebhookStore) FindWebhook(ctx context.Context, id uint) (*models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, webhook := range s.webhooks {
+		if webhook.ID == id {
+			found := cloneWebhook(webhook)
+			return &found, nil
+		}
+	}
+	return nil, ErrWebhookNotFound
+}
+
+func (s *MemoryWebhookStore) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhooks := make([]models.Webhook, len(s.webhooks))
+	for i, webhook := range s.webhooks {
+		webhooks[i] = cloneWebhook(webhook)
+	}
+	return webhooks, nil
+}
+
+func (s *MemoryWebhookStore) DeleteWebhook(ctx context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.webhooks, func(w models.Webhook) bool { return w.ID == id })
+	if i < 0 {
+		return ErrWebhookNotFound
+	}
+	s.webhooks = slices.Delete(s.webhooks, i, i+1)
+	s.deliveries = slices.DeleteFunc(s.deliveries, func(d models.WebhookDelivery) bool { return d.WebhookID == id })
+	return nil
+}
+
+func (s *MemoryWebhookStore) AddDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Round(0)
+	s.nextID++
+	delivery.ID = s.nextID
+	delivery.CreatedAt, delivery.UpdatedAt = now, now
+	s.deliveries = append(s.deliveries, *delivery)
+	return nil
+}
+
+func (s *MemoryWebhookStore) FindDelivery(ctx context.Context, webhookID, id uint) (*models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, delivery := range s.deliveries {
+		if delivery.ID == id && delivery.WebhookID == webhookID {
+			return &delivery, nil
+		}
+	}
+	return nil, ErrDeliveryNotFound
+}
+
+func (s *MemoryWebhookStore) ListDeliveries(ctx context.Context, webhookID uint, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deliveries []models.WebhookDelivery
+	for i := len(s.deliveries) - 1; i >= 0 && len(deliveries) != limit; i-- {
+		if s.deliveries[i].WebhookID == webhookID {
+			deliveries = append(deliveries, s.deliveries[i])
+		}
+	}
+	return deliveries, nil
+}
+
+func (s *MemoryWebhookStore) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []models.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.Status == models.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b models.WebhookDelivery) int { return a.NextAttemptAt.Compare(b.NextAttemptAt) })
+	return due[:min(limit, len(due))], nil
+}
+
+func (s *MemoryWebhookStore) SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.deliveries {
+		if s.deliveries[i].ID == delivery.ID {
+			delivery.UpdatedAt = time.Now().Round(0)
+			s.deliveries[i] = *delivery
+		}
+	}
+	return nil
+}
+
+func cloneWebhook(webhook models.Webhook) models.Webhook {
+	webhook.Events = slices.Clone(webhook.Events)
+	return webhook
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/repository"
+)
+
+// AuthTokenPurger deletes login tokens once they have expired. Expired tokens
+// are already refused, so this only reclaims space.
+type AuthTokenPurger struct {
+	users    repository.UserStore
+	interval time.Duration
+}
+
+func NewAuthTokenPurger(users repository.UserStore, interval time.Duration) *AuthTokenPurger {
+	return &AuthTokenPurger{users: users, interval: interval}
+}
+
+func ProvideAuthTokenPurger(users repository.UserStore) *AuthTokenPurger {
+	return NewAuthTokenPurger(users, time.Hour)
+}
+
+// Run purges once immediately and then on every interval until ctx is done.
+func (p *AuthTokenPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if purged, err := p.PurgeOnce(ctx); err != nil {
+			log.Printf("auth token purge failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d expired auth tokens", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce removes every token that has already expired.
+func (p *AuthTokenPurger) PurgeOnce(ctx context.Context) (int64, error) {
+	return p.users.PurgeExpiredTokens(ctx, time.Now())
+}
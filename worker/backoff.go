@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+const maxBackoff = time.Hour
+
+// backoff doubles the wait after every failed attempt, starting at base and
+// capped at an hour.
+func backoff(base time.Duration, attempts int) time.Duration {
+	wait := max(base, time.Second)
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/repository"
+)
+
+// EventLogPurger deletes todo events once they are older than the retention.
+// Clients that resume from a purged event only get the events still logged.
+type EventLogPurger struct {
+	events    repository.EventLog
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewEventLogPurger(events repository.EventLog, retention, interval time.Duration) *EventLogPurger {
+	return &EventLogPurger{events: events, retention: retention, interval: interval}
+}
+
+// ProvideEventLogPurger reads the retention from EVENT_LOG_RETENTION_HOURS
+// (default 168, a week). Zero keeps events forever.
+func ProvideEventLogPurger(events repository.EventLog) *EventLogPurger {
+	hours := envInt("EVENT_LOG_RETENTION_HOURS", 168)
+	return NewEventLogPurger(events, time.Duration(hours)*time.Hour, time.Hour)
+}
+
+// Run purges once immediately and then on every interval until ctx is done.
+func (p *EventLogPurger) Run(ctx context.Context) {
+	if p.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if purged, err := p.PurgeOnce(ctx); err != nil {
+			log.Printf("event log purge failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d logged todo events", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce removes every event older than the retention.
+func (p *EventLogPurger) PurgeOnce(ctx context.Context) (int64, error) {
+	return p.events.PurgeBefore(ctx, time.Now().Add(-p.retention))
+}
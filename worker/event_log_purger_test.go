@@ -0,0 +1,34 @@
+package worker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/Xillon/golang-todo-api/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLogPurgerRemovesOldEvents(t *testing.T) {
+	events := repository.NewMemoryEventLog()
+	for _, age := range []time.Duration{48 * time.Hour, 2 * time.Hour, time.Minute} {
+		event := &models.TodoEvent{Type: models.EventTodoCreated, OccurredAt: time.Now().Add(-age)}
+		require.NoError(t, events.Append(t.Context(), event))
+	}
+
+	purged, err := worker.NewEventLogPurger(events, 24*time.Hour, time.Hour).PurgeOnce(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	// Resuming still works by id after the oldest events are gone.
+	remaining, err := events.Since(t.Context(), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, remaining, 2)
+	assert.Equal(t, uint(2), remaining[0].ID)
+	remaining, err = events.Since(t.Context(), 2, 10)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, uint(3), remaining[0].ID)
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/repository"
+)
+
+// IdempotencyPurger deletes idempotency keys once they have expired. Expired
+// keys are already ignored by the store, so this only reclaims space.
+type IdempotencyPurger struct {
+	keys     repository.IdempotencyStore
+	interval time.Duration
+}
+
+func NewIdempotencyPurger(keys repository.IdempotencyStore, interval time.Duration) *IdempotencyPurger {
+	return &IdempotencyPurger{keys: keys, interval: interval}
+}
+
+func ProvideIdempotencyPurger(keys repository.IdempotencyStore) *IdempotencyPurger {
+	return NewIdempotencyPurger(keys, time.Hour)
+}
+
+// Run purges once immediately and then on every interval until ctx is done.
+func (p *IdempotencyPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if purged, err := p.PurgeOnce(ctx); err != nil {
+			log.Printf("idempotency key purge failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d expired idempotency keys", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce removes every key that has already expired.
+func (p *IdempotencyPurger) PurgeOnce(ctx context.Context) (int64, error) {
+	return p.keys.PurgeExpired(ctx, time.Now())
+}
@@ -0,0 +1,28 @@
+package worker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/Xillon/golang-todo-api/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyPurgerRemovesExpiredKeys(t *testing.T) {
+	keys := repository.NewMemoryIdempotencyStore()
+	_, err := keys.Reserve(t.Context(), &models.IdempotencyKey{Key: "expired", ExpiresAt: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+	_, err = keys.Reserve(t.Context(), &models.IdempotencyKey{Key: "live", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	purged, err := worker.NewIdempotencyPurger(keys, time.Hour).PurgeOnce(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	existing, err := keys.Reserve(t.Context(), &models.IdempotencyKey{Key: "live", ExpiresAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.NotNil(t, existing)
+}
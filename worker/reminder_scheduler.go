@@ -0,0 +1,167 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/notify"
+	"github.com/Xillon/golang-todo-api/repository"
+)
+
+const reminderBatchSize = 100
+
+// ReminderScheduler sends reminders for open todos that are about to fall
+// due, are overdue or have reached their RemindAt time. Every reminder is
+// recorded before it is sent and retried with exponential backoff until the
+// notifier accepts it, so it is delivered at least once.
+type ReminderScheduler struct {
+	todos     repository.TodoRepository
+	reminders repository.ReminderStore
+	notifier  notify.Notifier
+	lead      time.Duration
+	interval  time.Duration
+}
+
+// NewReminderScheduler announces due dates lead ahead of time and checks for
+// work on every interval.
+func NewReminderScheduler(todos repository.TodoRepository, reminders repository.ReminderStore, notifier notify.Notifier, lead, interval time.Duration) *ReminderScheduler {
+	return &ReminderScheduler{todos: todos, reminders: reminders, notifier: notifier, lead: lead, interval: interval}
+}
+
+// ProvideReminderScheduler reads REMINDER_LEAD_MINUTES (default 60) and
+// REMINDER_INTERVAL_SECONDS (default 60). An interval of zero disables
+// reminders.
+func ProvideReminderScheduler(todos repository.TodoRepository, reminders repository.ReminderStore, notifier notify.Notifier) *ReminderScheduler {
+	lead := envInt("REMINDER_LEAD_MINUTES", 60)
+	interval := envInt("REMINDER_INTERVAL_SECONDS", 60)
+	return NewReminderScheduler(todos, reminders, notifier, time.Duration(lead)*time.Minute, time.Duration(interval)*time.Second)
+}
+
+// Run checks for reminders once immediately and then on every interval until
+// ctx is done.
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if sent, err := s.RunOnce(ctx, time.Now()); err != nil {
+			log.Printf("reminder run failed: %v", err)
+		} else if sent > 0 {
+			log.Printf("sent %d reminders", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce records the reminders that became due by now and tries to deliver
+// every pending one. It reports how many were delivered.
+func (s *ReminderScheduler) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	if err := s.schedule(ctx, now); err != nil {
+		return 0, err
+	}
+
+	due, err := s.reminders.Due(ctx, now, reminderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, reminder := range due {
+		delivered, err := s.deliver(ctx, now, &reminder)
+		if err != nil {
+			return sent, err
+		}
+		if delivered {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// schedule records a pending reminder for every open todo whose due date is
+// within the lead time or whose RemindAt has passed. The todos are read in
+// batches of reminderBatchSize, so a run never holds more than one batch.
+func (s *ReminderScheduler) schedule(ctx context.Context, now time.Time) error {
+	open := false
+	dueBy, remindBy := now.Add(s.lead), now.Add(time.Nanosecond)
+	queries := []struct {
+		kind  string
+		query repository.TodoQuery
+		at    func(todo models.Todo) time.Time
+	}{
+		{models.ReminderDue, repository.TodoQuery{Complete: &open, DueBefore: &dueBy, SkipTotal: true}, func(todo models.Todo) time.Time { return *todo.DueDate }},
+		{models.ReminderRemindAt, repository.TodoQuery{Complete: &open, RemindBefore: &remindBy, SkipTotal: true}, func(todo models.Todo) time.Time { return *todo.RemindAt }},
+	}
+
+	for _, q := range queries {
+		var position *repository.TodoPosition
+		for {
+			todos, more, _, err := repository.ListPage(ctx, s.todos, q.query, position, false, reminderBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, todo := range todos {
+				reminder := &models.Reminder{
+					TodoID:        todo.ID,
+					Kind:          q.kind,
+					At:            q.at(todo),
+					Status:        models.ReminderPending,
+					NextAttemptAt: now,
+				}
+				if _, err := s.reminders.Schedule(ctx, reminder); err != nil {
+					return err
+				}
+			}
+			if !more {
+				break
+			}
+			last := todos[len(todos)-1]
+			position = &repository.TodoPosition{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+	}
+	return nil
+}
+
+// deliver sends one pending reminder, unless the todo has been completed,
+// deleted or rescheduled since, and stores the outcome.
+func (s *ReminderScheduler) deliver(ctx context.Context, now time.Time, reminder *models.Reminder) (bool, error) {
+	todo, err := s.todos.FindByID(ctx, reminder.TodoID)
+	if err != nil && !errors.Is(err, repository.ErrTodoNotFound) {
+		return false, err
+	}
+	if todo == nil || todo.Complete || !reminderStillApplies(reminder, todo) {
+		reminder.Status = models.ReminderCancelled
+		return false, s.reminders.Save(ctx, reminder)
+	}
+
+	reminder.Attempts++
+	err = s.notifier.Notify(ctx, notify.Notification{Kind: reminder.Kind, At: reminder.At, Todo: *todo})
+	if err != nil {
+		reminder.LastError = err.Error()
+		reminder.NextAttemptAt = now.Add(backoff(s.interval, reminder.Attempts))
+		log.Printf("reminder %d for todo %d failed (attempt %d): %v", reminder.ID, todo.ID, reminder.Attempts, err)
+		return false, s.reminders.Save(ctx, reminder)
+	}
+	reminder.Status = models.ReminderDelivered
+	reminder.LastError = ""
+	reminder.DeliveredAt = &now
+	return true, s.reminders.Save(ctx, reminder)
+}
+
+func reminderStillApplies(reminder *models.Reminder, todo *models.Todo) bool {
+	at := todo.DueDate
+	if reminder.Kind == models.ReminderRemindAt {
+		at = todo.RemindAt
+	}
+	return at != nil && at.Equal(reminder.At)
+}
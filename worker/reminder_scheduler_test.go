@@ -0,0 +1,133 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/events"
+	"github.com/Xillon/golang-todo-api/helpers"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/notify"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/Xillon/golang-todo-api/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyNotifier fails the first failures deliveries and records the rest.
+type flakyNotifier struct {
+	mu        sync.Mutex
+	failures  int
+	delivered []notify.Notification
+}
+
+func (n *flakyNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("mail server unavailable")
+	}
+	n.delivered = append(n.delivered, notification)
+	return nil
+}
+
+func TestReminderSchedulerDeliversAtLeastOnce(t *testing.T) {
+	stores := map[string]func(t *testing.T) (repository.TodoRepository, repository.ReminderStore){
+		"sqlite": func(t *testing.T) (repository.TodoRepository, repository.ReminderStore) {
+			db := helpers.OpenSQLite(t)
+			return repository.ProvideTodoRepository(db, events.NewBus(nil)), repository.NewGormReminderStore(db)
+		},
+		"memory": func(t *testing.T) (repository.TodoRepository, repository.ReminderStore) {
+			return repository.NewMemoryTodoRepository(), repository.NewMemoryReminderStore()
+		},
+	}
+	for name, setup := range stores {
+		t.Run(name, func(t *testing.T) {
+			todos, reminders := setup(t)
+			now := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+			at := func(d time.Duration) *time.Time {
+				t := now.Add(d)
+				return &t
+			}
+			seed := []models.Todo{
+				{Title: "Overdue", DueDate: at(-24 * time.Hour)},
+				{Title: "Due soon", DueDate: at(30 * time.Minute)},
+				{Title: "Due later", DueDate: at(3 * time.Hour)},
+				{Title: "Nudge me", RemindAt: at(-time.Minute), DueDate: at(48 * time.Hour)},
+				{Title: "Already done", DueDate: at(-time.Hour), Complete: true},
+			}
+			for i := range seed {
+				require.NoError(t, todos.Create(t.Context(), &seed[i]))
+			}
+
+			notifier := &flakyNotifier{failures: 1}
+			scheduler := worker.NewReminderScheduler(todos, reminders, notifier, time.Hour, time.Minute)
+
+			// The first delivery fails and is retried after the backoff.
+			sent, err := scheduler.RunOnce(t.Context(), now)
+			require.NoError(t, err)
+			assert.Equal(t, 2, sent)
+
+			// A restarted scheduler picks the failed reminder up again and
+			// does not repeat the delivered ones.
+			scheduler = worker.NewReminderScheduler(todos, reminders, notifier, time.Hour, time.Minute)
+			sent, err = scheduler.RunOnce(t.Context(), now.Add(time.Minute))
+			require.NoError(t, err)
+			assert.Equal(t, 1, sent)
+			sent, err = scheduler.RunOnce(t.Context(), now.Add(2*time.Minute))
+			require.NoError(t, err)
+			assert.Zero(t, sent)
+
+			var titles []string
+			for _, n := range notifier.delivered {
+				titles = append(titles, n.Kind+" "+n.Todo.Title)
+			}
+			assert.ElementsMatch(t, []string{"due Overdue", "due Due soon", "remind_at Nudge me"}, titles)
+
+			// Rescheduling a todo announces the new due date; completing one
+			// cancels its pending reminder.
+			later := models.Todo{ID: seed[2].ID, DueDate: at(90 * time.Minute)}
+			require.NoError(t, todos.Update(t.Context(), &later))
+			notifier.failures = 1
+			sent, err = scheduler.RunOnce(t.Context(), now.Add(45*time.Minute))
+			require.NoError(t, err)
+			assert.Zero(t, sent)
+			require.NoError(t, todos.Update(t.Context(), &models.Todo{ID: seed[2].ID, Complete: true}))
+			sent, err = scheduler.RunOnce(t.Context(), now.Add(2*time.Hour))
+			require.NoError(t, err)
+			assert.Zero(t, sent)
+			assert.Len(t, notifier.delivered, 3)
+		})
+	}
+}
+
+func TestReminderSchedulerReadsTodosInBatches(t *testing.T) {
+	db := helpers.OpenSQLite(t)
+	todos, reminders := repository.ProvideTodoRepository(db, events.NewBus(nil)), repository.NewGormReminderStore(db)
+	now := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	overdue := now.Add(-time.Hour)
+	for i := range 250 {
+		require.NoError(t, todos.Create(t.Context(), &models.Todo{Title: "Overdue " + strconv.Itoa(i), DueDate: &overdue}))
+	}
+
+	// Every todo gets one reminder even though they span several batches;
+	// delivery also goes a batch at a time.
+	notifier := &flakyNotifier{}
+	scheduler := worker.NewReminderScheduler(todos, reminders, notifier, time.Hour, time.Minute)
+	for _, want := range []int{100, 100, 50, 0} {
+		sent, err := scheduler.RunOnce(t.Context(), now)
+		require.NoError(t, err)
+		assert.Equal(t, want, sent)
+	}
+	seen := map[uint]bool{}
+	for _, n := range notifier.delivered {
+		seen[n.Todo.ID] = true
+	}
+	assert.Len(t, seen, 250)
+}
@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/repository"
+)
+
+// TrashPurger permanently removes todos that have been in the trash for longer
+// than the configured retention.
+type TrashPurger struct {
+	todos     repository.TodoRepository
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewTrashPurger(todos repository.TodoRepository, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{todos: todos, retention: retention, interval: interval}
+}
+
+// ProvideTrashPurger reads the retention from TRASH_RETENTION_DAYS. Automatic
+// purging is disabled when it is unset or zero.
+func ProvideTrashPurger(todos repository.TodoRepository) *TrashPurger {
+	days, _ := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if days < 0 {
+		days = 0
+	}
+	return NewTrashPurger(todos, time.Duration(days)*24*time.Hour, time.Hour)
+}
+
+// Run purges once immediately and then on every interval until ctx is done.
+func (p *TrashPurger) Run(ctx context.Context) {
+	if p.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if purged, err := p.PurgeOnce(ctx); err != nil {
+			log.Printf("trash purge failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d todos from the trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce removes every todo trashed before now minus the retention.
+func (p *TrashPurger) PurgeOnce(ctx context.Context) (int64, error) {
+	return p.todos.PurgeTrash(ctx, time.Now().Add(-p.retention))
+}
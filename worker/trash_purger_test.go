@@ -0,0 +1,28 @@
+package worker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+	"github.com/Xillon/golang-todo-api/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashPurgerRespectsRetention(t *testing.T) {
+	repo := repository.NewMemoryTodoRepository()
+	todo := models.Todo{Title: "Trashed"}
+	require.NoError(t, repo.Create(t.Context(), &todo))
+	require.NoError(t, repo.Delete(t.Context(), todo.ID, 0))
+
+	purged, err := worker.NewTrashPurger(repo, time.Hour, time.Hour).PurgeOnce(t.Context())
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = worker.NewTrashPurger(repo, -time.Minute, time.Hour).PurgeOnce(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	assert.ErrorIs(t, repo.Restore(t.Context(), todo.ID), repository.ErrTodoNotFound)
+}
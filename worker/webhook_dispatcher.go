@@ -0,0 +1,224 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xillon/golang-todo-api/events"
+	"github.com/Xillon/golang-todo-api/models"
+	"github.com/Xillon/golang-todo-api/repository"
+)
+
+const (
+	webhookBatchSize = 100
+	// maxWebhookErrorBody caps how much of a failing receiver's answer is
+	// kept in the delivery log.
+	maxWebhookErrorBody = 512
+)
+
+// WebhookDispatcher posts todo events to the webhooks subscribed to them.
+// Every delivery is recorded before it is sent and retried with exponential
+// backoff until the receiver answers 2xx or the attempts run out, so a
+// receiver sees each event at least once while the webhook stays subscribed.
+type WebhookDispatcher struct {
+	webhooks    repository.WebhookStore
+	client      *http.Client
+	interval    time.Duration
+	maxAttempts int
+	wake        chan struct{}
+}
+
+// NewWebhookDispatcher checks for pending deliveries on every interval and
+// gives up on a delivery after maxAttempts failures.
+func NewWebhookDispatcher(webhooks repository.WebhookStore, client *http.Client, interval time.Duration, maxAttempts int) *WebhookDispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookDispatcher{
+		webhooks:    webhooks,
+		client:      client,
+		interval:    interval,
+		maxAttempts: max(maxAttempts, 1),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// ProvideWebhookDispatcher reads WEBHOOK_INTERVAL_SECONDS (default 10) and
+// WEBHOOK_MAX_ATTEMPTS (default 8) and subscribes the dispatcher to bus. An
+// interval of zero stops deliveries from being sent; they are still logged.
+func ProvideWebhookDispatcher(webhooks repository.WebhookStore, bus *events.Bus) *WebhookDispatcher {
+	interval := envInt("WEBHOOK_INTERVAL_SECONDS", 10)
+	attempts := envInt("WEBHOOK_MAX_ATTEMPTS", 8)
+	dispatcher := NewWebhookDispatcher(webhooks, nil, time.Duration(interval)*time.Second, attempts)
+	bus.Subscribe(dispatcher.Enqueue)
+	return dispatcher
+}
+
+// Enqueue records a pending delivery of event for every webhook that wants
+// it. Its signature makes it an events.Handler.
+func (d *WebhookDispatcher) Enqueue(ctx context.Context, event models.TodoEvent) {
+	if err := d.enqueue(ctx, event); err != nil {
+		log.Printf("queueing %s webhooks for todo %d failed: %v", event.Type, event.Todo.ID, err)
+	}
+}
+
+func (d *WebhookDispatcher) enqueue(ctx context.Context, event models.TodoEvent) error {
+	webhooks, err := d.webhooks.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	queued := false
+	for _, webhook := range webhooks {
+		if !webhook.Wants(event.Type) {
+			continue
+		}
+		delivery := &models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event.Type,
+			Payload:       payload,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: event.OccurredAt,
+		}
+		if err := d.webhooks.AddDelivery(ctx, delivery); err != nil {
+			return err
+		}
+		queued = true
+	}
+	if queued {
+		d.Wake()
+	}
+	return nil
+}
+
+// Wake makes Run look for pending deliveries right away instead of waiting
+// for the next tick.
+func (d *WebhookDispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run sends pending deliveries once immediately and then on every interval,
+// or sooner when woken, until ctx is done.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	if d.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.RunOnce(ctx, time.Now()); err != nil {
+			log.Printf("webhook run failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// RunOnce tries every delivery that is pending by now and reports how many
+// of them were accepted by their receivers.
+func (d *WebhookDispatcher) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	due, err := d.webhooks.DueDeliveries(ctx, now, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, delivery := range due {
+		delivered, err := d.deliver(ctx, now, &delivery)
+		if err != nil {
+			return sent, err
+		}
+		if delivered {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// deliver posts one pending delivery and stores the outcome.
+func (d *WebhookDispatcher) deliver(ctx context.Context, now time.Time, delivery *models.WebhookDelivery) (bool, error) {
+	webhook, err := d.webhooks.FindWebhook(ctx, delivery.WebhookID)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		// The webhook was deleted while the delivery was in flight.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	delivery.Attempts++
+	delivery.ResponseStatus, err = d.post(ctx, webhook, delivery)
+	if err != nil {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= d.maxAttempts {
+			delivery.Status = models.DeliveryFailed
+		} else {
+			delivery.NextAttemptAt = now.Add(backoff(d.interval, delivery.Attempts))
+		}
+		log.Printf("webhook delivery %d to %s failed (attempt %d): %v", delivery.ID, webhook.URL, delivery.Attempts, err)
+		return false, d.webhooks.SaveDelivery(ctx, delivery)
+	}
+	delivery.Status = models.DeliveryDelivered
+	delivery.LastError = ""
+	delivery.DeliveredAt = &now
+	return true, d.webhooks.SaveDelivery(ctx, delivery)
+}
+
+// post sends the signed payload and returns the receiver's status code, or 0
+// when it could not be reached.
+func (d *WebhookDispatcher) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "golang-todo-api-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Signature-256", SignWebhookPayload(webhook.Secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookErrorBody))
+		return resp.StatusCode, fmt.Errorf("webhook answered %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature-256 header sent with
+// payload: "sha256=" followed by the hex encoded HMAC-SHA256 of the raw body,
+// keyed with the webhook secret. Receivers should recompute it and compare
+// the two with hmac.Equal.
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}